@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+)
+
+// commands run instead of the server when named as the first argument. Their flags are parsed
+// together with the server flags, so QuickBooks settings come from the same env and .env file.
+var commands = map[string]func(ctx context.Context) error{
+	"schema":         schemaCommand,
+	"sync":           syncCommand,
+	"webhook-replay": webhookReplayCommand,
+}
+
+// schemaCommand writes the Fibery schema of every registered type to stdout, with the field
+// mappings applied when a mapping file is given.
+func schemaCommand(ctx context.Context) error {
+	mappings := flag.String("field_mappings", os.Getenv("FIELD_MAPPINGS_FILE"), "json file renaming, hiding and re-labelling type fields for this deployment")
+	flag.Parse()
+	if *mappings != "" {
+		if err := app.UseFieldMappings(*mappings); err != nil {
+			return err
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(app.Types.Schemas())
+}
+
+type offlineFlags struct {
+	realm     *string
+	tokenFile *string
+	types     *string
+}
+
+func registerOfflineFlags() offlineFlags {
+	return offlineFlags{
+		realm:     flag.String("realm", "", "quickbooks realm id, overrides the realmId in the token file"),
+		tokenFile: flag.String("token-file", "", "json file holding the fibery account: realmId, access_token and refresh_token"),
+		types:     flag.String("types", "", "comma separated type ids, all registered types when empty"),
+	}
+}
+
+// newOfflineIntegration loads the config and account and builds an integration that is driven
+// directly instead of through Fibery.
+func newOfflineIntegration(ctx context.Context, f offlineFlags) (*app.Integration, app.QuickBooksAccountInfo, []string, error) {
+	var account app.QuickBooksAccountInfo
+
+	config, err := app.NewConfig(version)
+	if err != nil {
+		return nil, account, nil, fmt.Errorf("unable to build config: %w", err)
+	}
+	// every type is requested at once, so the operation has nothing to wait for
+	config.FetchDelay = min(config.FetchDelay, time.Second)
+
+	if *f.tokenFile == "" {
+		return nil, account, nil, fmt.Errorf("--token-file is required")
+	}
+	raw, err := os.ReadFile(*f.tokenFile)
+	if err != nil {
+		return nil, account, nil, fmt.Errorf("unable to read token file: %w", err)
+	}
+	if err := json.Unmarshal(raw, &account); err != nil {
+		return nil, account, nil, fmt.Errorf("unable to decode token file: %w", err)
+	}
+	if *f.realm != "" {
+		account.RealmId = *f.realm
+	}
+
+	types := app.Types.TypeIds()
+	if *f.types != "" {
+		types = strings.Split(*f.types, ",")
+	}
+	for _, typeId := range types {
+		if _, ok := app.Types.Get(typeId); !ok {
+			return nil, account, nil, fmt.Errorf("type %s not found in registered types", typeId)
+		}
+	}
+
+	a, err := app.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, account, nil, fmt.Errorf("unable to create new integration: %w", err)
+	}
+	return a, account, types, nil
+}
+
+// syncCommand runs a full sync, or a delta sync with --since, and writes one jsonl file per type.
+func syncCommand(ctx context.Context) error {
+	f := registerOfflineFlags()
+	since := flag.String("since", "", "RFC3339 time of the last sync, runs a delta sync when set")
+	out := flag.String("out", ".", "directory for the {type}.jsonl output files")
+
+	a, account, types, err := newOfflineIntegration(ctx, f)
+	if err != nil {
+		return err
+	}
+	defer a.Shutdown(context.Background())
+
+	if account.RealmId == "" {
+		return fmt.Errorf("--realm is required when the token file has no realmId")
+	}
+
+	var lastSynced time.Time
+	if *since != "" {
+		lastSynced, err = time.Parse(time.RFC3339, *since)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return fmt.Errorf("unable to create output directory: %w", err)
+	}
+	writers := make(map[string]*bufio.Writer, len(types))
+	for _, typeId := range types {
+		file, err := os.Create(filepath.Join(*out, typeId+".jsonl"))
+		if err != nil {
+			return fmt.Errorf("unable to create output file: %w", err)
+		}
+		defer file.Close()
+		writer := bufio.NewWriter(file)
+		defer writer.Flush()
+		writers[typeId] = writer
+	}
+
+	counts := make(map[string]int, len(types))
+	err = a.SyncAll(ctx, app.SyncRequest{
+		Types:             types,
+		Account:           account,
+		LastSyncronizedAt: lastSynced,
+	}, func(typeId string, items []map[string]any) error {
+		encoder := json.NewEncoder(writers[typeId])
+		for _, item := range items {
+			if err := encoder.Encode(item); err != nil {
+				return err
+			}
+		}
+		counts[typeId] += len(items)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("sync failed: %w", err)
+	}
+
+	for _, typeId := range types {
+		fmt.Fprintf(os.Stderr, "%s: %d items\n", typeId, counts[typeId])
+	}
+	return nil
+}
+
+// webhookReplayCommand transforms a saved QuickBooks webhook payload the way the
+// webhooks/transform endpoint does. Webhooks need the realm's id cache, so a full sync of the
+// types runs first and its items are discarded.
+func webhookReplayCommand(ctx context.Context) error {
+	f := registerOfflineFlags()
+	payloadFile := flag.String("payload", "", "json file holding a quickbooks webhook payload")
+
+	a, account, types, err := newOfflineIntegration(ctx, f)
+	if err != nil {
+		return err
+	}
+	defer a.Shutdown(context.Background())
+
+	if *payloadFile == "" {
+		return fmt.Errorf("--payload is required")
+	}
+	raw, err := os.ReadFile(*payloadFile)
+	if err != nil {
+		return fmt.Errorf("unable to read payload: %w", err)
+	}
+
+	req := app.WebhookRequest{Types: types}
+	if err := json.Unmarshal(raw, &req.Payload); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+	if account.RealmId == "" && len(req.Payload.EventNotifications) > 0 {
+		account.RealmId = req.Payload.EventNotifications[0].RealmId
+	}
+	req.Account = account
+
+	err = a.SyncAll(ctx, app.SyncRequest{Types: types, Account: account}, func(string, []map[string]any) error {
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("unable to seed id cache: %w", err)
+	}
+
+	resp, err := a.TransformWebhook(ctx, req)
+	if err != nil {
+		return fmt.Errorf("webhook transform failed: %w", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(resp)
+}
@@ -26,6 +26,20 @@ func main() {
 	)
 	defer shutdownCancel()
 
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Args = append(os.Args[:1], os.Args[2:]...)
+			if err := command(shutdownCtx); err != nil {
+				log.Fatalf("%s", err.Error())
+			}
+			return
+		}
+	}
+
+	serve(shutdownCtx)
+}
+
+func serve(shutdownCtx context.Context) {
 	a, err := app.New(shutdownCtx, version)
 	if err != nil {
 		log.Fatalf("unable to create new integration: %s", err.Error())
@@ -52,6 +66,9 @@ func main() {
 		if err := server.Shutdown(ctx); err != nil {
 			slog.Error(fmt.Sprintf("Could not gracefully shutdown the server %+v", err))
 		}
+		if err := a.Shutdown(ctx); err != nil {
+			slog.Error(fmt.Sprintf("Could not flush traces %+v", err))
+		}
 	}()
 
 	slog.Info(fmt.Sprintf("Server starting at port %s...", a.Port()))
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type readinessCheck struct {
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+	Error string `json:"error,omitempty"`
+}
+
+func newReadinessCheck(name string, ready bool, reason string) readinessCheck {
+	check := readinessCheck{Name: name, Ready: ready}
+	if !ready {
+		check.Error = reason
+	}
+	return check
+}
+
+func (i *Integration) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	RespondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// ReadyHandler reports the integration ready once QuickBooks discovery has loaded and the client is built.
+// The app keeps all sync state in memory, so the only store to check is the in-process IdStore.
+func (i *Integration) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	checks := []readinessCheck{
+		newReadinessCheck("discovery", i.discovery != nil, "discovery api not loaded"),
+		newReadinessCheck("client", i.client != nil && i.client.qb != nil, "quickbooks client not built"),
+		newReadinessCheck("idStore", i.idStore != nil, "id store not initialized"),
+		newReadinessCheck("context", i.ctx != nil && i.ctx.Err() == nil, "integration is shutting down"),
+	}
+
+	code, status := http.StatusOK, "ready"
+	for _, check := range checks {
+		if !check.Ready {
+			code, status = http.StatusServiceUnavailable, "not ready"
+		}
+	}
+
+	RespondWithJSON(w, code, struct {
+		Status string           `json:"status"`
+		Checks []readinessCheck `json:"checks"`
+	}{
+		Status: status,
+		Checks: checks,
+	})
+}
+
+// adminAuth requires a bearer token matching ADMIN_TOKEN. Admin routes are disabled when no token is configured.
+func (i *Integration) adminAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if i.config.AdminToken == "" {
+			RespondWithError(w, http.StatusNotFound, fmt.Errorf("admin api is disabled"))
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(i.config.AdminToken)) != 1 {
+			RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("invalid admin token"))
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (i *Integration) AdminOperationsHandler(w http.ResponseWriter, r *http.Request) {
+	RespondWithJSON(w, http.StatusOK, i.opManager.List())
+}
+
+func (i *Integration) AdminCancelOperationHandler(w http.ResponseWriter, r *http.Request) {
+	operationId := r.PathValue("operationId")
+	if !i.opManager.CancelOperation(operationId, fmt.Errorf("operation %s cancelled by admin", operationId)) {
+		RespondWithError(w, http.StatusNotFound, fmt.Errorf("operation %s not found", operationId))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (i *Integration) AdminRealmsHandler(w http.ResponseWriter, r *http.Request) {
+	RespondWithJSON(w, http.StatusOK, i.idStore.Realms())
+}
+
+func (i *Integration) AdminEvictRealmHandler(w http.ResponseWriter, r *http.Request) {
+	realmId := r.PathValue("realmId")
+	if !i.idStore.Evict(realmId) {
+		RespondWithError(w, http.StatusNotFound, fmt.Errorf("no id cache for realm %s", realmId))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (i *Integration) AdminRateLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	RespondWithJSON(w, http.StatusOK, i.governor.Stats())
+}
+
+func registerAdminRoutes(mux *http.ServeMux, i *Integration) {
+	mux.HandleFunc("GET /healthz", i.HealthHandler)
+	mux.HandleFunc("GET /readyz", i.ReadyHandler)
+
+	mux.HandleFunc("GET /admin/operations", i.adminAuth(i.AdminOperationsHandler))
+	mux.HandleFunc("DELETE /admin/operations/{operationId}", i.adminAuth(i.AdminCancelOperationHandler))
+	mux.HandleFunc("GET /admin/realms", i.adminAuth(i.AdminRealmsHandler))
+	mux.HandleFunc("DELETE /admin/realms/{realmId}", i.adminAuth(i.AdminEvictRealmHandler))
+	mux.HandleFunc("GET /admin/ratelimits", i.adminAuth(i.AdminRateLimitsHandler))
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newAdminTestIntegration(t *testing.T) (*Integration, http.Handler) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	i := &Integration{
+		config:    Config{AdminToken: "secret"},
+		governor:  NewRateGovernor(500, 10, time.Second, time.Minute),
+		opManager: NewOperationManager(time.Minute),
+		idStore:   NewIdStore(time.Minute),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	mux := http.NewServeMux()
+	registerAdminRoutes(mux, i)
+	return i, mux
+}
+
+func adminRequest(method, path, token string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestAdminAuth(t *testing.T) {
+	t.Parallel()
+	i, handler := newAdminTestIntegration(t)
+
+	for token, want := range map[string]int{
+		"":       http.StatusUnauthorized,
+		"wrong":  http.StatusUnauthorized,
+		"secret": http.StatusOK,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/operations", token))
+		if rec.Code != want {
+			t.Errorf("token %q: expected %d, got %d", token, want, rec.Code)
+		}
+	}
+
+	i.config.AdminToken = ""
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/operations", "secret"))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected admin api to be disabled without a token, got %d", rec.Code)
+	}
+}
+
+func TestAdminOperationsAndRealms(t *testing.T) {
+	t.Parallel()
+	i, handler := newAdminTestIntegration(t)
+
+	req := SyncRequest{
+		OperationId: "op-1",
+		Types:       []string{"vendor"},
+		Account:     QuickBooksAccountInfo{RealmId: "realm-1"},
+	}
+	op, err := i.opManager.GetOrAddOperation(context.Background(), req, i)
+	if err != nil {
+		t.Fatalf("unexpected error creating operation: %v", err)
+	}
+	pending := make(chan OperationDataHandlerResponse, 1)
+	op.chans[ResponseChannelKey("vendor", 1)] = pending
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/operations", "secret"))
+	var ops []OperationInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &ops); err != nil {
+		t.Fatalf("unable to decode operations: %v", err)
+	}
+	if len(ops) != 1 || ops[0].Id != "op-1" || ops[0].RealmId != "realm-1" || len(ops[0].PendingKeys) != 1 {
+		t.Errorf("unexpected operations listing: %+v", ops)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/realms", "secret"))
+	var realms []IdCacheInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &realms); err != nil {
+		t.Fatalf("unable to decode realms: %v", err)
+	}
+	if len(realms) != 1 || realms[0].RealmId != "realm-1" {
+		t.Errorf("unexpected realms listing: %+v", realms)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, adminRequest(http.MethodDelete, "/admin/operations/op-1", "secret"))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected operation cancel to succeed, got %d", rec.Code)
+	}
+	if resp := <-pending; resp.Error == nil {
+		t.Error("expected pending channel to receive a cancellation error")
+	}
+	if i.opManager.Len() != 0 {
+		t.Error("expected cancelled operation to be removed")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, adminRequest(http.MethodDelete, "/admin/realms/realm-1", "secret"))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected realm eviction to succeed, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, adminRequest(http.MethodDelete, "/admin/realms/realm-1", "secret"))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected missing realm eviction to 404, got %d", rec.Code)
+	}
+}
+
+func TestReadyHandler(t *testing.T) {
+	t.Parallel()
+	_, handler := newAdminTestIntegration(t)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected healthz to return 200, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected readyz to return 503 without a quickbooks client, got %d", rec.Code)
+	}
+}
@@ -31,6 +31,10 @@ func indexAttachables(
 	}
 
 	for _, att := range attachables {
+		// note-only attachables have no file to link and are only synced as attachable items
+		if att.FileName == "" {
+			continue
+		}
 		for _, ref := range att.AttachableRef {
 			if ref.EntityRef.Type != entityType {
 				continue
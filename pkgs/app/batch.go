@@ -1,13 +1,17 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/tommyhedley/quickbooks-go"
 )
 
+const MaxBatchItems = 30
+
 func EncodeQueryBID(entityType string, page int, attachable bool) string {
 	if attachable {
 		return fmt.Sprintf("attachable:%s:%d", entityType, page)
@@ -45,12 +49,12 @@ func batchQueryRequest(entityType string, ids []string, page, pageSize int, atta
 			idString := "'" + strings.Join(ids, "','") + "'"
 			return quickbooks.BatchItemRequest{
 				BID:   EncodeQueryBID(entityType, page, true),
-				Query: fmt.Sprintf("Select Id, AttachableRef From Attachable Where AttachableRef.EntityRef.Type = '%s' And AttachableRef.EntityRef.Value in (%s) STARTPOSITION %d MAXRESULTS %d", entityType, idString, startPosition(page, pageSize), pageSize),
+				Query: fmt.Sprintf("Select Id, FileName, AttachableRef From Attachable Where AttachableRef.EntityRef.Type = '%s' And AttachableRef.EntityRef.Value in (%s) STARTPOSITION %d MAXRESULTS %d", entityType, idString, startPosition(page, pageSize), pageSize),
 			}
 		} else {
 			return quickbooks.BatchItemRequest{
 				BID:   EncodeQueryBID(entityType, page, true),
-				Query: fmt.Sprintf("Select Id, AttachableRef From Attachable Where AttachableRef.EntityRef.Type = '%s' STARTPOSITION %d MAXRESULTS %d", entityType, startPosition(page, pageSize), pageSize),
+				Query: fmt.Sprintf("Select Id, FileName, AttachableRef From Attachable Where AttachableRef.EntityRef.Type = '%s' STARTPOSITION %d MAXRESULTS %d", entityType, startPosition(page, pageSize), pageSize),
 			}
 		}
 	} else {
@@ -68,3 +72,144 @@ func batchQueryRequest(entityType string, ids []string, page, pageSize int, atta
 		}
 	}
 }
+
+type BatchRequester interface {
+	BatchRequest(params quickbooks.RequestParameters, req []quickbooks.BatchItemRequest) ([]quickbooks.BatchItemResponse, error)
+}
+
+type Batcher struct {
+	client      BatchRequester
+	size        int
+	concurrency int
+}
+
+func NewBatcher(client BatchRequester, size, concurrency int) *Batcher {
+	if size < 1 || size > MaxBatchItems {
+		size = MaxBatchItems
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Batcher{
+		client:      client,
+		size:        size,
+		concurrency: concurrency,
+	}
+}
+
+func splitBatchRequest(req []quickbooks.BatchItemRequest, size int) [][]quickbooks.BatchItemRequest {
+	chunks := make([][]quickbooks.BatchItemRequest, 0, (len(req)+size-1)/size)
+	for start := 0; start < len(req); start += size {
+		end := min(start+size, len(req))
+		chunks = append(chunks, req[start:end])
+	}
+	return chunks
+}
+
+func mergeBatchResponses(req []quickbooks.BatchItemRequest, chunks [][]quickbooks.BatchItemResponse) ([]quickbooks.BatchItemResponse, error) {
+	expected := make(map[string]struct{}, len(req))
+	for _, r := range req {
+		if _, exists := expected[r.BID]; exists {
+			return nil, fmt.Errorf("duplicate BID in batch request: %s", r.BID)
+		}
+		expected[r.BID] = struct{}{}
+	}
+
+	merged := make([]quickbooks.BatchItemResponse, 0, len(req))
+	for _, chunk := range chunks {
+		for _, resp := range chunk {
+			if _, ok := expected[resp.BID]; !ok {
+				return nil, fmt.Errorf("unexpected BID in batch response: %s", resp.BID)
+			}
+			delete(expected, resp.BID)
+			merged = append(merged, resp)
+		}
+	}
+
+	if len(expected) > 0 {
+		missing := make([]string, 0, len(expected))
+		for bid := range expected {
+			missing = append(missing, bid)
+		}
+		return nil, fmt.Errorf("batch response missing BIDs: %s", strings.Join(missing, ", "))
+	}
+
+	return merged, nil
+}
+
+func (b *Batcher) Do(params quickbooks.RequestParameters, req []quickbooks.BatchItemRequest) ([]quickbooks.BatchItemResponse, error) {
+	if len(req) == 0 {
+		return nil, nil
+	}
+
+	chunks := splitBatchRequest(req, b.size)
+	results := make([][]quickbooks.BatchItemResponse, len(chunks))
+
+	if len(chunks) == 1 || b.concurrency == 1 {
+		for idx, chunk := range chunks {
+			resp, err := b.client.BatchRequest(params, chunk)
+			if err != nil {
+				return nil, fmt.Errorf("batch chunk %d of %d failed: %w", idx+1, len(chunks), err)
+			}
+			results[idx] = resp
+		}
+		return mergeBatchResponses(req, results)
+	}
+
+	parent := params.Ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+
+	sem := make(chan struct{}, b.concurrency)
+
+	for idx, chunk := range chunks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(idx int, chunk []quickbooks.BatchItemRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			chunkParams := params
+			chunkParams.Ctx = ctx
+
+			resp, err := b.client.BatchRequest(chunkParams, chunk)
+			if err != nil {
+				once.Do(func() {
+					first = fmt.Errorf("batch chunk %d of %d failed: %w", idx+1, len(chunks), err)
+					cancel()
+				})
+				return
+			}
+			results[idx] = resp
+		}(idx, chunk)
+	}
+
+	wg.Wait()
+
+	if first != nil {
+		return nil, first
+	}
+
+	if err := parent.Err(); err != nil {
+		return nil, err
+	}
+
+	return mergeBatchResponses(req, results)
+}
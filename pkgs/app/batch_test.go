@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+func TestQueryBIDRoundTrip(t *testing.T) {
+	t.Parallel()
+	for _, attachable := range []bool{false, true} {
+		bid := EncodeQueryBID("Bill", 3, attachable)
+		entityType, page, isAttachable, err := DecodeQueryBID(bid)
+		if err != nil {
+			t.Fatalf("unexpected error decoding %s: %v", bid, err)
+		}
+		if entityType != "Bill" || page != 3 || isAttachable != attachable {
+			t.Errorf("DecodeQueryBID(%q) = %s, %d, %v", bid, entityType, page, isAttachable)
+		}
+	}
+}
+
+func TestSplitBatchRequest(t *testing.T) {
+	t.Parallel()
+	req := make([]quickbooks.BatchItemRequest, 65)
+	for i := range req {
+		req[i].BID = fmt.Sprintf("Bill:%d", i+1)
+	}
+
+	chunks := splitBatchRequest(req, MaxBatchItems)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+
+	total := 0
+	for _, chunk := range chunks {
+		if len(chunk) > MaxBatchItems {
+			t.Errorf("chunk of %d exceeds limit of %d", len(chunk), MaxBatchItems)
+		}
+		total += len(chunk)
+	}
+	if total != len(req) {
+		t.Errorf("expected %d requests across chunks, got %d", len(req), total)
+	}
+
+	if chunks := splitBatchRequest(nil, MaxBatchItems); len(chunks) != 0 {
+		t.Errorf("expected no chunks for empty request, got %d", len(chunks))
+	}
+}
+
+type fakeBatchRequester struct {
+	mu    sync.Mutex
+	calls int
+	fail  string
+}
+
+func (f *fakeBatchRequester) BatchRequest(params quickbooks.RequestParameters, req []quickbooks.BatchItemRequest) ([]quickbooks.BatchItemResponse, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+
+	if len(req) > MaxBatchItems {
+		return nil, fmt.Errorf("batch of %d exceeds limit", len(req))
+	}
+
+	resp := make([]quickbooks.BatchItemResponse, 0, len(req))
+	for _, r := range req {
+		if r.BID == f.fail {
+			return nil, fmt.Errorf("failed on %s", r.BID)
+		}
+		resp = append(resp, quickbooks.BatchItemResponse{BID: r.BID})
+	}
+	return resp, nil
+}
+
+func TestBatcherDo(t *testing.T) {
+	t.Parallel()
+	req := make([]quickbooks.BatchItemRequest, 0, 70)
+	for i := 1; i <= 35; i++ {
+		req = append(req, batchQueryRequest("Bill", nil, i, 10, false))
+		req = append(req, batchQueryRequest("Bill", nil, i, 10, true))
+	}
+
+	for _, concurrency := range []int{1, 4} {
+		client := &fakeBatchRequester{}
+		batcher := NewBatcher(client, MaxBatchItems, concurrency)
+
+		resp, err := batcher.Do(quickbooks.RequestParameters{Ctx: context.Background()}, req)
+		if err != nil {
+			t.Fatalf("concurrency %d: unexpected error: %v", concurrency, err)
+		}
+		if client.calls != 3 {
+			t.Errorf("concurrency %d: expected 3 batch calls, got %d", concurrency, client.calls)
+		}
+		if len(resp) != len(req) {
+			t.Fatalf("concurrency %d: expected %d responses, got %d", concurrency, len(req), len(resp))
+		}
+		for idx, r := range resp {
+			if r.BID != req[idx].BID {
+				t.Errorf("concurrency %d: response %d has BID %s, want %s", concurrency, idx, r.BID, req[idx].BID)
+			}
+		}
+	}
+}
+
+func TestBatcherDoError(t *testing.T) {
+	t.Parallel()
+	req := make([]quickbooks.BatchItemRequest, 0, 60)
+	for i := 1; i <= 60; i++ {
+		req = append(req, batchQueryRequest("Vendor", nil, i, 10, false))
+	}
+
+	client := &fakeBatchRequester{fail: EncodeQueryBID("Vendor", 45, false)}
+	batcher := NewBatcher(client, MaxBatchItems, 2)
+
+	if _, err := batcher.Do(quickbooks.RequestParameters{Ctx: context.Background()}, req); err == nil {
+		t.Error("expected error from failed chunk, got nil")
+	}
+}
+
+func TestMergeBatchResponsesMissingBID(t *testing.T) {
+	t.Parallel()
+	req := []quickbooks.BatchItemRequest{
+		{BID: EncodeQueryBID("Item", 1, false)},
+		{BID: EncodeQueryBID("Item", 1, true)},
+	}
+	chunks := [][]quickbooks.BatchItemResponse{
+		{{BID: EncodeQueryBID("Item", 1, false)}},
+	}
+
+	if _, err := mergeBatchResponses(req, chunks); err == nil {
+		t.Error("expected error for missing BID, got nil")
+	}
+}
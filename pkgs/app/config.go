@@ -54,6 +54,20 @@ func parseIntEnv(key string) (int, error) {
 	return n, nil
 }
 
+func parseOptionalIntEnv(key string, def int) (int, error) {
+	if os.Getenv(key) == "" {
+		return def, nil
+	}
+	return parseIntEnv(key)
+}
+
+func parseOptionalDurationEnv(key string, def time.Duration) (time.Duration, error) {
+	if os.Getenv(key) == "" {
+		return def, nil
+	}
+	return parseDurationEnv(key)
+}
+
 type Config struct {
 	fiberyApp          fibery.AppConfig
 	fiberySync         fibery.SyncConfig
@@ -67,8 +81,19 @@ type Config struct {
 	AttachableFieldId  string
 	OperationTTL       time.Duration
 	IdCacheTTL         time.Duration
+	FetchDelay         time.Duration
+	TracingEndpoint    string
+	AdminToken         string
+	RecordDir          string
+	RecordKeepPII      bool
+	ReplayDir          string
+	FieldMappingsFile  string
 	QuickBooks         struct {
 		PageSize                    int
+		BatchConcurrency            int
+		RateLimitPerMinute          int
+		ConcurrencyLimit            int
+		RateLimitMaxWait            time.Duration
 		MinorVersion                string
 		Scope                       string
 		WebhookToken                string
@@ -115,9 +140,20 @@ func (c *Config) Load() error {
 	flag.DurationVar(&c.TokenRefreshWindow, "token_refresh", 0, "duration before token expiration to refresh token")
 	flag.DurationVar(&c.OperationTTL, "op_ttl", 0, "operation time to live")
 	flag.DurationVar(&c.IdCacheTTL, "cache_ttl", 0, "cache time to live")
+	flag.DurationVar(&c.FetchDelay, "fetch_delay", -1, "delay before an operation starts fetching from quickbooks")
 
 	flag.IntVar(&c.QuickBooks.PageSize, "page_size", 0, "quickbooks query page size → max 1000")
+	flag.IntVar(&c.QuickBooks.BatchConcurrency, "batch_concurrency", 0, "max concurrent quickbooks batch requests per batch")
+	flag.IntVar(&c.QuickBooks.RateLimitPerMinute, "rate_limit", 0, "max quickbooks requests per minute per realm")
+	flag.IntVar(&c.QuickBooks.ConcurrencyLimit, "concurrency_limit", 0, "max concurrent quickbooks requests per realm")
+	flag.DurationVar(&c.QuickBooks.RateLimitMaxWait, "rate_limit_wait", 0, "max time to wait for a quickbooks request slot before asking fibery to retry")
 	flag.StringVar(&c.AttachableFieldId, "attachable_field", os.Getenv("ATTACHABLE_FIELD_ID"), "attachables field id")
+	flag.StringVar(&c.AdminToken, "admin_token", os.Getenv("ADMIN_TOKEN"), "bearer token for the /admin api, admin routes are disabled when empty")
+	flag.StringVar(&c.RecordDir, "record_dir", os.Getenv("QBO_RECORD_DIR"), "directory to record redacted quickbooks traffic to, one file per realm")
+	flag.BoolVar(&c.RecordKeepPII, "record_keep_pii", os.Getenv("QBO_RECORD_KEEP_PII") == "true", "record names, contact details and addresses instead of hashing them")
+	flag.StringVar(&c.ReplayDir, "replay_dir", os.Getenv("QBO_REPLAY_DIR"), "directory of recorded quickbooks traffic to replay instead of calling quickbooks")
+	flag.StringVar(&c.FieldMappingsFile, "field_mappings", os.Getenv("FIELD_MAPPINGS_FILE"), "json file renaming, hiding and re-labelling type fields for this deployment")
+	flag.StringVar(&c.TracingEndpoint, "otlp_endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "otlp/http trace collector url, tracing is disabled when empty")
 
 	flag.Parse()
 
@@ -127,6 +163,9 @@ func (c *Config) Load() error {
 	if c.Port == "" {
 		return fmt.Errorf("PORT is required")
 	}
+	if c.RecordDir != "" && c.ReplayDir != "" {
+		return fmt.Errorf("record_dir and replay_dir cannot both be set")
+	}
 
 	if lvl, ok := loggerLevels[logLevelStr]; ok {
 		c.LoggerLevel = lvl
@@ -163,6 +202,14 @@ func (c *Config) Load() error {
 		c.IdCacheTTL = d
 	}
 
+	if c.FetchDelay < 0 {
+		d, err := parseOptionalDurationEnv("FETCH_DELAY", 30*time.Second)
+		if err != nil {
+			return err
+		}
+		c.FetchDelay = d
+	}
+
 	if c.QuickBooks.PageSize == 0 {
 		n, err := parseIntEnv("PAGE_SIZE")
 		if err != nil {
@@ -174,6 +221,47 @@ func (c *Config) Load() error {
 		return fmt.Errorf("page_size must be between 1 and 1000")
 	}
 
+	if c.QuickBooks.BatchConcurrency == 0 {
+		n, err := parseOptionalIntEnv("BATCH_CONCURRENCY", 1)
+		if err != nil {
+			return err
+		}
+		c.QuickBooks.BatchConcurrency = n
+	}
+	if c.QuickBooks.BatchConcurrency < 1 || c.QuickBooks.BatchConcurrency > 10 {
+		return fmt.Errorf("batch_concurrency must be between 1 and 10")
+	}
+
+	if c.QuickBooks.RateLimitPerMinute == 0 {
+		n, err := parseOptionalIntEnv("RATE_LIMIT_PER_MINUTE", 500)
+		if err != nil {
+			return err
+		}
+		c.QuickBooks.RateLimitPerMinute = n
+	}
+	if c.QuickBooks.RateLimitPerMinute < 1 || c.QuickBooks.RateLimitPerMinute > 500 {
+		return fmt.Errorf("rate_limit must be between 1 and 500")
+	}
+
+	if c.QuickBooks.ConcurrencyLimit == 0 {
+		n, err := parseOptionalIntEnv("CONCURRENCY_LIMIT", 10)
+		if err != nil {
+			return err
+		}
+		c.QuickBooks.ConcurrencyLimit = n
+	}
+	if c.QuickBooks.ConcurrencyLimit < 1 || c.QuickBooks.ConcurrencyLimit > 10 {
+		return fmt.Errorf("concurrency_limit must be between 1 and 10")
+	}
+
+	if c.QuickBooks.RateLimitMaxWait == 0 {
+		d, err := parseOptionalDurationEnv("RATE_LIMIT_MAX_WAIT", 30*time.Second)
+		if err != nil {
+			return err
+		}
+		c.QuickBooks.RateLimitMaxWait = d
+	}
+
 	if c.AttachableFieldId == "" {
 		return fmt.Errorf("ATTACHABLE_FIELD_ID is required")
 	}
@@ -256,6 +344,23 @@ func (c *Config) NewClientRequest(discovery *quickbooks.DiscoveryAPI, client *ht
 	return clientRequest
 }
 
+// Transport returns the base transport for QuickBooks requests, recording or replaying
+// traffic when configured.
+func (c *Config) Transport() (http.RoundTripper, error) {
+	switch {
+	case c.ReplayDir != "":
+		return NewReplayTransport(c.ReplayDir)
+	case c.RecordDir != "":
+		piiKeys := DefaultPIIKeys
+		if c.RecordKeepPII {
+			piiKeys = nil
+		}
+		return NewRecordingTransport(http.DefaultTransport, c.RecordDir, piiKeys)
+	default:
+		return http.DefaultTransport, nil
+	}
+}
+
 func (c *Config) DiscoverURL() string {
 	switch c.Mode {
 	case "production":
@@ -0,0 +1,41 @@
+package app_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/qbosim"
+)
+
+func TestFiberyConformance(t *testing.T) {
+	h := newHarness(t)
+
+	var account map[string]any
+	raw, _ := json.Marshal(h.account)
+	json.Unmarshal(raw, &account)
+
+	conformance := &fibery.Conformance{
+		Integration: h.integration,
+		Account:     account,
+		BeforeDelta: func(ctx context.Context) error {
+			return h.sim.Upsert(h.account.RealmId, "Vendor", map[string]any{"Id": "4", "DisplayName": "Cal Telephone Co", "Active": true})
+		},
+		WebhookPayload: json.RawMessage(qbosim.WebhookPayload(h.account.RealmId, qbosim.WebhookEntity{
+			Name:        "Vendor",
+			Id:          "4",
+			Operation:   "Update",
+			LastUpdated: time.Now(),
+		})),
+	}
+
+	report := conformance.Run(context.Background())
+	for _, v := range report.Violations {
+		t.Error(v)
+	}
+	if report.Full["vendor"] != 5 || report.Delta["vendor"] != 1 || report.Webhook["vendor"] != 1 {
+		t.Errorf("unexpected vendor counts full=%d delta=%d webhook=%d", report.Full["vendor"], report.Delta["vendor"], report.Webhook["vendor"])
+	}
+}
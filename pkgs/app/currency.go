@@ -0,0 +1,111 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+)
+
+// DefaultCurrency formats money fields when a realm's home currency is unknown.
+const DefaultCurrency = "USD"
+
+// MoneyFormat is the Fibery format of money fields. Its currency code is replaced with the
+// realm's home currency when the schema is requested.
+func MoneyFormat() map[string]any {
+	return map[string]any{
+		"format":               "Money",
+		"currencyCode":         DefaultCurrency,
+		"hasThousandSeperator": true,
+		"precision":            2,
+	}
+}
+
+// ExchangeRateFormat is the Fibery format of transaction exchange rates.
+func ExchangeRateFormat() map[string]any {
+	return map[string]any{
+		"format":               "Number",
+		"hasThousandSeparator": true,
+		"precision":            6,
+	}
+}
+
+// ForeignAmountFormat is the Fibery format of money fields held in a transaction's or entity's
+// own currency, which varies from item to item.
+func ForeignAmountFormat() map[string]any {
+	return map[string]any{
+		"format":               "Number",
+		"hasThousandSeparator": true,
+		"precision":            2,
+	}
+}
+
+// ForeignAmountRegistry maps type ids to the money fields held in a transaction's or entity's
+// own currency rather than the home currency, such as a bill's total or a vendor's balance.
+type ForeignAmountRegistry map[string]map[string]struct{}
+
+func (fr ForeignAmountRegistry) Register(typeId string, fieldIds ...string) {
+	fields, ok := fr[typeId]
+	if !ok {
+		fields = make(map[string]struct{}, len(fieldIds))
+		fr[typeId] = fields
+	}
+	for _, fieldId := range fieldIds {
+		fields[fieldId] = struct{}{}
+	}
+}
+
+var ForeignAmounts = make(ForeignAmountRegistry)
+
+// WithCurrency returns a copy of schema with every money field formatted in currency. The foreign
+// amounts are left as plain numbers instead, since currency does not describe them.
+func WithCurrency(schema map[string]fibery.Field, currency string, foreign map[string]struct{}) map[string]fibery.Field {
+	out := make(map[string]fibery.Field, len(schema))
+	for fieldId, field := range schema {
+		if _, ok := foreign[fieldId]; ok && field.Format["format"] == "Money" {
+			field.Format = ForeignAmountFormat()
+			if field.Description == "" {
+				field.Description = "In the currency of the transaction or contact, not the home currency"
+			}
+		} else if field.Format["format"] == "Money" {
+			format := make(map[string]any, len(field.Format))
+			for key, value := range field.Format {
+				format[key] = value
+			}
+			format["currencyCode"] = currency
+			field.Format = format
+		}
+		out[fieldId] = field
+	}
+	return out
+}
+
+// HomeAmount converts a transaction amount to the home currency. Transactions in the home
+// currency have no exchange rate and are returned unchanged.
+func HomeAmount(amount, exchangeRate json.Number) (float64, error) {
+	if amount == "" {
+		return 0, nil
+	}
+	value, err := amount.Float64()
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", amount, err)
+	}
+	if exchangeRate == "" {
+		return value, nil
+	}
+	rate, err := exchangeRate.Float64()
+	if err != nil {
+		return 0, fmt.Errorf("invalid exchange rate %q: %w", exchangeRate, err)
+	}
+	return math.Round(value*rate*100) / 100, nil
+}
+
+// ExchangeRate returns a transaction's exchange rate, which QuickBooks leaves out for
+// transactions in the home currency.
+func ExchangeRate(exchangeRate json.Number) json.Number {
+	if exchangeRate == "" {
+		return "1"
+	}
+	return exchangeRate
+}
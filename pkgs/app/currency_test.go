@@ -0,0 +1,66 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+)
+
+func TestHomeAmount(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		amount, rate json.Number
+		want         float64
+	}{
+		{"100", "", 100},
+		{"103.55", "1.35", 139.79},
+		{"", "1.35", 0},
+	}
+	for _, c := range cases {
+		got, err := HomeAmount(c.amount, c.rate)
+		if err != nil || got != c.want {
+			t.Errorf("HomeAmount(%q, %q) = %v, %v; want %v", c.amount, c.rate, got, err, c.want)
+		}
+	}
+	if _, err := HomeAmount("100", "abc"); err == nil {
+		t.Error("expected an invalid exchange rate to fail")
+	}
+}
+
+func TestWithCurrency(t *testing.T) {
+	t.Parallel()
+	schema := map[string]fibery.Field{
+		"total": {Name: "Total", Type: fibery.Number, Format: MoneyFormat()},
+		"qty":   {Name: "Qty", Type: fibery.Number, Format: map[string]any{"format": "Number"}},
+		"home":  {Name: "Home", Type: fibery.Number, Format: MoneyFormat()},
+	}
+
+	cad := WithCurrency(schema, "CAD", map[string]struct{}{"total": {}})
+	if _, ok := cad["total"].Format["currencyCode"]; ok || cad["total"].Format["format"] != "Number" {
+		t.Errorf("expected foreign amounts to be plain numbers, got %v", cad["total"].Format)
+	}
+	if cad["total"].Description == "" {
+		t.Error("expected foreign amounts to be described")
+	}
+	if cad["home"].Format["currencyCode"] != "CAD" {
+		t.Errorf("expected CAD money format, got %v", cad["home"].Format)
+	}
+	if _, ok := cad["qty"].Format["currencyCode"]; ok {
+		t.Error("expected non money fields to be left alone")
+	}
+	if schema["total"].Format["currencyCode"] != DefaultCurrency {
+		t.Error("expected the registered schema to be left unchanged")
+	}
+
+	cad = WithCurrency(schema, "CAD", nil)
+	if cad["total"].Format["currencyCode"] != "CAD" {
+		t.Errorf("expected CAD money format, got %v", cad["total"].Format)
+	}
+	if _, ok := cad["qty"].Format["currencyCode"]; ok {
+		t.Error("expected non money fields to be left alone")
+	}
+	if schema["total"].Format["currencyCode"] != DefaultCurrency {
+		t.Error("expected the registered schema to be left unchanged")
+	}
+}
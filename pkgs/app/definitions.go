@@ -12,34 +12,72 @@ import (
 )
 
 type Integration struct {
-	appConfig  fibery.AppConfig
-	syncConfig fibery.SyncConfig
-	config     Config
-	types      TypeRegistry
-	client     *quickbooks.Client
-	opManager  *OperationManager
-	idStore    *IdStore
-	ctx        context.Context
-	cancel     context.CancelFunc
+	appConfig   fibery.AppConfig
+	syncConfig  fibery.SyncConfig
+	config      Config
+	types       TypeRegistry
+	discovery   *quickbooks.DiscoveryAPI
+	client      *Client
+	governor    *RateGovernor
+	batcher     *Batcher
+	metrics     *Metrics
+	opManager   *OperationManager
+	idStore     *IdStore
+	preferences *preferencesStore
+	tracing     func(context.Context) error
+	ctx         context.Context
+	cancel      context.CancelFunc
 }
 
 func New(parentCtx context.Context, version string) (*Integration, error) {
-	ctx, cancel := context.WithCancel(parentCtx)
 	config, err := NewConfig(version)
 	if err != nil {
-		cancel()
 		return nil, fmt.Errorf("unable to build config: %w", err)
 	}
 
-	discoveryAPI, err := quickbooks.CallDiscoveryAPI(config.DiscoverURL())
+	return NewWithConfig(parentCtx, config)
+}
+
+// NewWithConfig builds the integration from an already loaded Config, skipping flag and env parsing.
+func NewWithConfig(parentCtx context.Context, config Config) (*Integration, error) {
+	if config.FieldMappingsFile != "" {
+		if err := UseFieldMappings(config.FieldMappingsFile); err != nil {
+			return nil, err
+		}
+	}
+	if err := Types.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid type schemas: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(parentCtx)
+
+	transport, err := config.Transport()
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("unable to setup quickbooks transport: %w", err)
+	}
+
+	var discoveryAPI *quickbooks.DiscoveryAPI
+	if transport == http.DefaultTransport {
+		discoveryAPI, err = quickbooks.CallDiscoveryAPI(config.DiscoverURL())
+	} else {
+		discoveryAPI, err = discover(&http.Client{Transport: transport}, config.DiscoverURL())
+	}
 	if err != nil {
 		cancel()
 		return nil, fmt.Errorf("error calling discovery API: %w", err)
 	}
 
-	clientReq := config.NewClientRequest(discoveryAPI, http.DefaultClient)
+	shutdownTracing, err := SetupTracing(ctx, config)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("unable to setup tracing: %w", err)
+	}
 
-	client, err := quickbooks.NewClient(clientReq)
+	httpClient := &http.Client{Transport: NewTracingTransport(transport)}
+	clientReq := config.NewClientRequest(discoveryAPI, httpClient)
+
+	qbClient, err := quickbooks.NewClient(clientReq)
 	if err != nil {
 		cancel()
 		return nil, fmt.Errorf("error creating quickbooks client: %w", err)
@@ -47,6 +85,17 @@ func New(parentCtx context.Context, version string) (*Integration, error) {
 
 	opManager := NewOperationManager(config.OperationTTL)
 	idStore := NewIdStore(config.IdCacheTTL)
+	metrics := NewMetrics(opManager, idStore)
+
+	governor := NewRateGovernor(
+		config.QuickBooks.RateLimitPerMinute,
+		config.QuickBooks.ConcurrencyLimit,
+		config.QuickBooks.RateLimitMaxWait,
+		config.IdCacheTTL,
+	)
+	governor.metrics = metrics
+	client := NewClient(qbClient, governor, metrics)
+
 	integration := &Integration{
 		appConfig: fibery.AppConfig{
 			Id:          "qbo",
@@ -77,20 +126,40 @@ func New(parentCtx context.Context, version string) (*Integration, error) {
 			Actions: Actions.GetAll(),
 		},
 		syncConfig: fibery.SyncConfig{
-			Types:   Types.GetAll(),
-			Filters: []fibery.SyncFilter{},
+			Types: Types.GetAll(),
+			Filters: []fibery.SyncFilter{
+				{
+					Id:       ReportPeriodsFilterId,
+					Title:    "Report Periods",
+					Type:     "multidropdown",
+					Datalist: true,
+					Optional: true,
+				},
+				{
+					Id:       ExchangeRateDaysFilterId,
+					Title:    "Exchange Rate Days",
+					Type:     "number",
+					Optional: true,
+				},
+			},
 			Webhooks: fibery.SyncConfigWebhook{
 				Enabled: true,
 				Type:    "ui",
 			},
 		},
-		config:    config,
-		types:     Types,
-		client:    client,
-		opManager: opManager,
-		idStore:   idStore,
-		ctx:       ctx,
-		cancel:    cancel,
+		config:      config,
+		types:       Types,
+		discovery:   discoveryAPI,
+		client:      client,
+		governor:    governor,
+		batcher:     NewBatcher(client, MaxBatchItems, config.QuickBooks.BatchConcurrency),
+		metrics:     metrics,
+		opManager:   opManager,
+		idStore:     idStore,
+		preferences: newPreferencesStore(config.IdCacheTTL),
+		tracing:     shutdownTracing,
+		ctx:         ctx,
+		cancel:      cancel,
 	}
 	integration.StartCacheCleaner()
 	slog.SetDefault(config.BuildLogger())
@@ -100,6 +169,13 @@ func New(parentCtx context.Context, version string) (*Integration, error) {
 func (i *Integration) Cleanup() {
 	i.opManager.CleanupExpired()
 	i.idStore.CleanupExpired()
+	i.governor.CleanupIdle()
+}
+
+// Shutdown flushes any buffered spans to the trace exporter.
+func (i *Integration) Shutdown(ctx context.Context) error {
+	i.cancel()
+	return i.tracing(ctx)
 }
 
 func (i *Integration) StartCacheCleaner() {
@@ -0,0 +1,318 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+// DependentUnionSource is one QuickBooks entity whose items each yield items of a
+// DependentUnionType.
+type DependentUnionSource interface {
+	Source() StandardType
+	Schema() map[string]fibery.Field
+	ProcessBatchQuery(batch *quickbooks.BatchItemResponse, pageSize int) ([]map[string]any, bool, error)
+	ProcessCDCQuery(cdc *quickbooks.ChangeDataCapture, pageSize int) ([]map[string]any, error)
+	ProcessWebhookUpdates(batch *quickbooks.BatchItemResponse, pageSize int) ([]map[string]any, bool, error)
+	ProcessWebhookDeletions(sourceIds []string) []map[string]any
+}
+
+// DependentUnionSourceDef describes the items a source entity yields. ItemIds lists every id a
+// source item can yield, so that items it no longer yields can be removed without an id cache.
+type DependentUnionSourceDef[ST, T any] struct {
+	SourceType          StandardType
+	SourceId            func(ST) string
+	SourceStatus        func(ST) string
+	ItemId              func(ST, T) string
+	ItemIds             func(sourceId string) []string
+	ItemExtractor       func(ST) []T
+	Fields              map[string]DependentFieldDef[ST, T]
+	BatchQueryExtractor func(quickbooks.BatchQueryResponse) []ST
+	CDCQueryExtractor   func(quickbooks.CDCQueryResponse) []ST
+}
+
+// DependentUnionTypeDef is a single Fibery type whose items depend on items of several
+// QuickBooks entities, such as the addresses of customers, vendors and employees. It is synced
+// as a union of its sources. Fields missing from a source are emitted empty.
+type DependentUnionTypeDef struct {
+	FiberyId   string
+	FiberyName string
+	Sources    []DependentUnionSource
+}
+
+func NewDependentUnionSource[ST, T any](
+	sourceType StandardType,
+	sourceId func(ST) string,
+	sourceStatus func(ST) string,
+	itemId func(ST, T) string,
+	itemIds func(sourceId string) []string,
+	itemExtractor func(ST) []T,
+	batchQueryExtractor func(quickbooks.BatchQueryResponse) []ST,
+	cdcQueryExtractor func(quickbooks.CDCQueryResponse) []ST,
+	fields map[string]DependentFieldDef[ST, T],
+) *DependentUnionSourceDef[ST, T] {
+	return &DependentUnionSourceDef[ST, T]{
+		SourceType:          sourceType,
+		SourceId:            sourceId,
+		SourceStatus:        sourceStatus,
+		ItemId:              itemId,
+		ItemIds:             itemIds,
+		ItemExtractor:       itemExtractor,
+		Fields:              fields,
+		BatchQueryExtractor: batchQueryExtractor,
+		CDCQueryExtractor:   cdcQueryExtractor,
+	}
+}
+
+func NewDependentUnionType(fiberyId, fiberyName string, sources ...DependentUnionSource) *DependentUnionTypeDef {
+	return &DependentUnionTypeDef{
+		FiberyId:   fiberyId,
+		FiberyName: fiberyName,
+		Sources:    sources,
+	}
+}
+
+// --- DependentUnionSourceDef[ST, T] methods ---
+
+func (s *DependentUnionSourceDef[ST, T]) Source() StandardType {
+	return s.SourceType
+}
+
+func (s *DependentUnionSourceDef[ST, T]) Schema() map[string]fibery.Field {
+	schema := make(map[string]fibery.Field, len(s.Fields))
+	for id, field := range s.Fields {
+		schema[id] = field.Params
+	}
+	return schema
+}
+
+func (s *DependentUnionSourceDef[ST, T]) convert(source ST) ([]map[string]any, error) {
+	items := s.ItemExtractor(source)
+	output := make([]map[string]any, 0, len(items))
+	for _, i := range items {
+		o := make(map[string]any, len(s.Fields))
+		for id, field := range s.Fields {
+			fieldValue, err := field.Convert(DependentData[ST, T]{SourceItem: source, Item: i})
+			if err != nil {
+				return nil, fmt.Errorf("error converting %s: %w", s.ItemId(source, i), err)
+			}
+			o[id] = fieldValue
+		}
+		output = append(output, o)
+	}
+	return output, nil
+}
+
+func (s *DependentUnionSourceDef[ST, T]) removals(sourceId string, keep map[string]struct{}) []map[string]any {
+	output := []map[string]any{}
+	for _, id := range s.ItemIds(sourceId) {
+		if _, ok := keep[id]; ok {
+			continue
+		}
+		output = append(output, map[string]any{
+			"id":           id,
+			"__syncAction": fibery.REMOVE,
+		})
+	}
+	return output
+}
+
+func (s *DependentUnionSourceDef[ST, T]) ProcessBatchQuery(batch *quickbooks.BatchItemResponse, pageSize int) ([]map[string]any, bool, error) {
+	input := quickbooks.BatchQueryExtractor(batch, s.BatchQueryExtractor)
+
+	more := len(input) == pageSize
+
+	output := []map[string]any{}
+	for _, source := range input {
+		items, err := s.convert(source)
+		if err != nil {
+			return nil, more, err
+		}
+		output = append(output, items...)
+	}
+	return output, more, nil
+}
+
+func (s *DependentUnionSourceDef[ST, T]) ProcessCDCQuery(cdc *quickbooks.ChangeDataCapture, pageSize int) ([]map[string]any, error) {
+	input := quickbooks.CDCQueryExtractor(cdc, s.CDCQueryExtractor)
+
+	if len(input) == pageSize {
+		return nil, fmt.Errorf("cdc response for %s is equal to pageSize, please force full sync", s.SourceType.Id())
+	}
+
+	output := []map[string]any{}
+	for _, source := range input {
+		sourceId := s.SourceId(source)
+		if s.SourceStatus(source) == "Deleted" {
+			output = append(output, s.removals(sourceId, nil)...)
+			continue
+		}
+
+		items, err := s.update(source)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, items...)
+	}
+	return output, nil
+}
+
+// update converts a changed source item and removes the items it no longer yields.
+func (s *DependentUnionSourceDef[ST, T]) update(source ST) ([]map[string]any, error) {
+	items, err := s.convert(source)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := make(map[string]struct{}, len(items))
+	for _, i := range s.ItemExtractor(source) {
+		keep[s.ItemId(source, i)] = struct{}{}
+	}
+	return append(items, s.removals(s.SourceId(source), keep)...), nil
+}
+
+func (s *DependentUnionSourceDef[ST, T]) ProcessWebhookUpdates(batch *quickbooks.BatchItemResponse, pageSize int) ([]map[string]any, bool, error) {
+	input := quickbooks.BatchQueryExtractor(batch, s.BatchQueryExtractor)
+
+	more := len(input) == pageSize
+
+	output := []map[string]any{}
+	for _, source := range input {
+		items, err := s.update(source)
+		if err != nil {
+			return nil, more, err
+		}
+		output = append(output, items...)
+	}
+	return output, more, nil
+}
+
+func (s *DependentUnionSourceDef[ST, T]) ProcessWebhookDeletions(sourceIds []string) []map[string]any {
+	output := []map[string]any{}
+	for _, sourceId := range sourceIds {
+		output = append(output, s.removals(sourceId, nil)...)
+	}
+	return output
+}
+
+// --- DependentUnionTypeDef methods ---
+
+func (t *DependentUnionTypeDef) Id() string {
+	return t.FiberyId
+}
+
+func (t *DependentUnionTypeDef) Name() string {
+	return t.FiberyName
+}
+
+func (t *DependentUnionTypeDef) Schema() map[string]fibery.Field {
+	schema := make(map[string]fibery.Field)
+	for _, source := range t.Sources {
+		for id, field := range source.Schema() {
+			schema[id] = field
+		}
+	}
+	return FieldMappings.Schema(t.FiberyId, schema)
+}
+
+func (t *DependentUnionTypeDef) Types() []StandardType {
+	types := make([]StandardType, 0, len(t.Sources))
+	for _, source := range t.Sources {
+		types = append(types, source.Source())
+	}
+	return types
+}
+
+func (t *DependentUnionTypeDef) CDC() bool {
+	for _, source := range t.Sources {
+		if _, ok := source.Source().(CDCType); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *DependentUnionTypeDef) Webhook() bool {
+	for _, source := range t.Sources {
+		if _, ok := source.Source().(WebhookType); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// fill gives source items every field of the type, mapping select values on the way.
+func (t *DependentUnionTypeDef) fill(items []map[string]any) []map[string]any {
+	schema := t.Schema()
+	for _, item := range items {
+		if item["__syncAction"] == fibery.REMOVE {
+			continue
+		}
+		for id := range schema {
+			if _, ok := item[id]; !ok {
+				item[id] = nil
+			}
+		}
+		FieldMappings.Item(t.FiberyId, item)
+	}
+	return items
+}
+
+func (t *DependentUnionTypeDef) ProcessBatchQuery(batches map[string]*quickbooks.BatchItemResponse, pageSize int) ([]map[string]any, map[string]struct{}, error) {
+	return t.processBatches(batches, pageSize, DependentUnionSource.ProcessBatchQuery)
+}
+
+// ProcessWebhookUpdates converts the updated source items like ProcessBatchQuery and also
+// removes the items they no longer yield, such as a cleared shipping address.
+func (t *DependentUnionTypeDef) ProcessWebhookUpdates(batches map[string]*quickbooks.BatchItemResponse, pageSize int) ([]map[string]any, map[string]struct{}, error) {
+	return t.processBatches(batches, pageSize, DependentUnionSource.ProcessWebhookUpdates)
+}
+
+func (t *DependentUnionTypeDef) processBatches(
+	batches map[string]*quickbooks.BatchItemResponse,
+	pageSize int,
+	process func(DependentUnionSource, *quickbooks.BatchItemResponse, int) ([]map[string]any, bool, error),
+) ([]map[string]any, map[string]struct{}, error) {
+	output := make([]map[string]any, 0)
+	more := make(map[string]struct{})
+	for _, source := range t.Sources {
+		sourceType := source.Source().Type()
+		batch, ok := batches[sourceType]
+		if !ok {
+			continue
+		}
+
+		items, m, err := process(source, batch, pageSize)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error processing batch for %s: %w", sourceType, err)
+		}
+		output = append(output, t.fill(items)...)
+		if m {
+			more[sourceType] = struct{}{}
+		}
+	}
+	return output, more, nil
+}
+
+func (t *DependentUnionTypeDef) ProcessCDCQuery(cdc *quickbooks.ChangeDataCapture, pageSize int) ([]map[string]any, error) {
+	output := []map[string]any{}
+	for _, source := range t.Sources {
+		items, err := source.ProcessCDCQuery(cdc, pageSize)
+		if err != nil {
+			return nil, fmt.Errorf("error processing cdc for %s: %w", source.Source().Type(), err)
+		}
+		output = append(output, t.fill(items)...)
+	}
+	return output, nil
+}
+
+func (t *DependentUnionTypeDef) ProcessWebhookDeletions(deletedSources map[string][]string) ([]map[string]any, error) {
+	output := []map[string]any{}
+	for _, source := range t.Sources {
+		if ids, ok := deletedSources[source.Source().Type()]; ok {
+			output = append(output, source.ProcessWebhookDeletions(ids)...)
+		}
+	}
+	return output, nil
+}
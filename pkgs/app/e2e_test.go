@@ -0,0 +1,938 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	_ "github.com/tommyhedley/fibery-quickbooks-app/pkgs/app/types"
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/qbosim"
+)
+
+const (
+	attachableFieldId = "attachables"
+	adminToken        = "e2e-admin"
+)
+
+type e2eHarness struct {
+	sim         *qbosim.Server
+	integration *app.Integration
+	server      *httptest.Server
+	account     app.QuickBooksAccountInfo
+}
+
+func newHarness(t *testing.T) *e2eHarness {
+	t.Helper()
+	return newHarnessWithFixture(t, qbosim.SandboxFixture())
+}
+
+func newHarnessWithFixture(t *testing.T, fixture qbosim.Fixture, configure ...func(*app.Config)) *e2eHarness {
+	t.Helper()
+	sim := qbosim.New(fixture)
+	t.Cleanup(sim.Close)
+
+	config := app.Config{
+		Version:           "e2e",
+		Mode:              "sandbox",
+		LoggerStyle:       "text",
+		LoggerLevel:       8,
+		AttachableFieldId: attachableFieldId,
+		AdminToken:        adminToken,
+		OperationTTL:      time.Minute,
+		IdCacheTTL:        time.Minute,
+	}
+	config.QuickBooks.PageSize = 3
+	config.QuickBooks.BatchConcurrency = 1
+	config.QuickBooks.RateLimitPerMinute = 500
+	config.QuickBooks.ConcurrencyLimit = 10
+	config.QuickBooks.RateLimitMaxWait = 5 * time.Second
+	config.QuickBooks.WebhookToken = qbosim.WebhookToken
+	config.QuickBooks.DiscoveryEndpointSandbox = sim.DiscoveryURL()
+	config.QuickBooks.EndpointSandbox = sim.URL
+	config.QuickBooks.OauthClientIdSandbox = qbosim.ClientId
+	config.QuickBooks.OauthClientSecretSandbox = qbosim.ClientSecret
+	for _, c := range configure {
+		c(&config)
+	}
+
+	integration, err := app.NewWithConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("unable to build integration: %v", err)
+	}
+	t.Cleanup(func() { integration.Shutdown(context.Background()) })
+
+	server := httptest.NewServer(app.NewHandler(integration))
+	t.Cleanup(server.Close)
+
+	return &e2eHarness{
+		sim:         sim,
+		integration: integration,
+		server:      server,
+		account:     app.QuickBooksAccountInfo{RealmId: fixture.RealmId, BearerToken: sim.IssueToken(fixture.RealmId)},
+	}
+}
+
+func (h *e2eHarness) post(t *testing.T, path string, body any, header http.Header) *http.Response {
+	t.Helper()
+	var payload []byte
+	switch b := body.(type) {
+	case []byte:
+		payload = b
+	default:
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			t.Fatalf("unable to encode request: %v", err)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, h.server.URL+path, bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request to %s failed: %v", path, err)
+	}
+	return resp
+}
+
+func (h *e2eHarness) postJSON(t *testing.T, path string, body, out any) {
+	t.Helper()
+	resp := h.post(t, path, body, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		t.Fatalf("%s returned %d: %s", path, resp.StatusCode, msg)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("unable to decode %s response: %v", path, err)
+	}
+}
+
+// sync runs one Fibery synchronization of types, requesting every type's pages concurrently
+// the way Fibery does, and returns the items for each type keyed by id. Pages answered with
+// 429 are retried, as Fibery does when asked to try later.
+func (h *e2eHarness) sync(t *testing.T, operationId string, types []string, lastSynced time.Time) map[string]map[string]map[string]any {
+	t.Helper()
+	since := make(map[string]time.Time, len(types))
+	for _, typeId := range types {
+		since[typeId] = lastSynced
+	}
+	results, _ := h.syncSince(t, operationId, types, since)
+	return results
+}
+
+// syncSince runs a sync like sync, requesting each type with its own lastSynchronizedAt, and
+// also returns the synchronizationType of each type's pages.
+func (h *e2eHarness) syncSince(t *testing.T, operationId string, types []string, lastSynced map[string]time.Time) (map[string]map[string]map[string]any, map[string]fibery.SyncType) {
+	t.Helper()
+	schema := map[string]map[string]fibery.Field{}
+	h.postJSON(t, "/api/v1/synchronizer/schema", map[string]any{"types": types, "account": h.account}, &schema)
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		results   = make(map[string]map[string]map[string]any, len(types))
+		syncTypes = make(map[string]fibery.SyncType, len(types))
+		errs      = make(chan error, len(types))
+	)
+	for _, typeId := range types {
+		wg.Add(1)
+		go func(typeId string) {
+			defer wg.Done()
+			items := map[string]map[string]any{}
+			for page := 1; ; page++ {
+				req := app.SyncRequest{
+					RequestedType:     typeId,
+					OperationId:       operationId,
+					Types:             types,
+					Schema:            schema,
+					Account:           h.account,
+					LastSyncronizedAt: lastSynced[typeId],
+					Pagination:        fibery.NextPageConfig{Page: page},
+				}
+				payload, _ := json.Marshal(req)
+				resp, err := http.Post(h.server.URL+"/api/v1/synchronizer/data", "application/json", bytes.NewReader(payload))
+				if err != nil {
+					errs <- err
+					return
+				}
+				if resp.StatusCode == http.StatusTooManyRequests {
+					resp.Body.Close()
+					time.Sleep(10 * time.Millisecond)
+					page--
+					continue
+				}
+				var data fibery.DataHandlerResponse
+				err = json.NewDecoder(resp.Body).Decode(&data)
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK || err != nil {
+					errs <- fmt.Errorf("%s page %d returned %d: %v", typeId, page, resp.StatusCode, err)
+					return
+				}
+				for _, item := range data.Items {
+					items[fmt.Sprint(item["id"])] = item
+				}
+				mu.Lock()
+				syncTypes[typeId] = data.SynchronizationType
+				mu.Unlock()
+				if !data.Pagination.HasNext {
+					break
+				}
+			}
+			mu.Lock()
+			results[typeId] = items
+			mu.Unlock()
+		}(typeId)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	return results, syncTypes
+}
+
+// syncAll runs an offline sync of types with filter and returns the items for each type keyed
+// by id.
+func (h *e2eHarness) syncAll(t *testing.T, types []string, filter map[string]any) map[string]map[string]map[string]any {
+	t.Helper()
+	items := map[string]map[string]map[string]any{}
+	err := h.integration.SyncAll(context.Background(), app.SyncRequest{
+		Types:   types,
+		Account: h.account,
+		Filter:  filter,
+	}, func(typeId string, page []map[string]any) error {
+		if items[typeId] == nil {
+			items[typeId] = map[string]map[string]any{}
+		}
+		for _, item := range page {
+			items[typeId][fmt.Sprint(item["id"])] = item
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("offline sync of %v failed: %v", types, err)
+	}
+	return items
+}
+
+func TestE2EFullAndDeltaSync(t *testing.T) {
+	h := newHarness(t)
+	types := []string{"vendor", "bill", "billItemLine"}
+
+	full := h.sync(t, "full", types, time.Time{})
+	if n := len(full["vendor"]); n != 5 {
+		t.Errorf("expected 5 vendors across pages, got %d", n)
+	}
+	if n := len(full["bill"]); n != 3 {
+		t.Errorf("expected 3 bills across pages, got %d", n)
+	}
+	if n := len(full["billItemLine"]); n != 3 {
+		t.Errorf("expected 3 bill item lines, got %d", n)
+	}
+	if files, _ := full["bill"]["26"][attachableFieldId].([]any); len(files) != 1 {
+		t.Errorf("expected bill 26 to carry its attachable, got %v", full["bill"]["26"][attachableFieldId])
+	}
+
+	lastSynced := time.Now().Add(-2 * time.Second)
+	if err := h.sim.Upsert(h.account.RealmId, "Vendor", map[string]any{"Id": "2", "DisplayName": "Books by Bessie Inc", "Active": true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.sim.Delete(h.account.RealmId, "Vendor", "5"); err != nil {
+		t.Fatal(err)
+	}
+
+	delta := h.sync(t, "delta", []string{"vendor"}, lastSynced)
+	vendors := delta["vendor"]
+	if got := vendors["2"]["displayName"]; got != "Books by Bessie Inc" {
+		t.Errorf("expected updated vendor in delta, got %v", vendors["2"])
+	}
+	if got := vendors["5"]["__syncAction"]; got != string(fibery.REMOVE) {
+		t.Errorf("expected vendor 5 to be removed, got %v", vendors["5"])
+	}
+	if _, ok := vendors["1"]; ok {
+		t.Error("expected unchanged vendor to be left out of the delta")
+	}
+}
+
+func TestE2ERateLimitRetry(t *testing.T) {
+	h := newHarnessWithFixture(t, qbosim.SandboxFixture(), func(config *app.Config) {
+		config.QuickBooks.RateLimitPerMinute = 1200
+		config.QuickBooks.ConcurrencyLimit = 1
+		config.QuickBooks.RateLimitMaxWait = time.Millisecond
+	})
+
+	types := []string{"vendor", "bill", "billItemLine", "companyInfo"}
+	full := h.sync(t, "limited", types, time.Time{})
+	if n := len(full["vendor"]); n != 5 {
+		t.Errorf("expected 5 vendors across pages, got %d", n)
+	}
+	if n := len(full["bill"]); n != 3 {
+		t.Errorf("expected 3 bills across pages, got %d", n)
+	}
+	if n := len(full["companyInfo"]); n != 1 {
+		t.Errorf("expected the company info once rejected pages were retried, got %d", n)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, h.server.URL+"/admin/ratelimits", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	var stats []app.RealmLimitStats
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].Rejected == 0 {
+		t.Errorf("expected the sync to run into rejected requests, got %+v", stats)
+	}
+}
+
+func TestE2EWebhook(t *testing.T) {
+	h := newHarness(t)
+	h.sync(t, "seed", []string{"vendor"}, time.Time{})
+
+	if err := h.sim.Upsert(h.account.RealmId, "Vendor", map[string]any{"Id": "3", "DisplayName": "Brosnahan Insurance", "Active": true}); err != nil {
+		t.Fatal(err)
+	}
+	payload := qbosim.WebhookPayload(h.account.RealmId, qbosim.WebhookEntity{
+		Name:        "Vendor",
+		Id:          "3",
+		Operation:   "Update",
+		LastUpdated: time.Now(),
+	})
+
+	unsigned := h.post(t, "/api/v1/synchronizer/webhooks/pre-process", payload, http.Header{"Intuit-Signature": {qbosim.SignWebhook(payload, "wrong")}})
+	unsigned.Body.Close()
+	if unsigned.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected forged signature to be rejected, got %d", unsigned.StatusCode)
+	}
+
+	signed := h.post(t, "/api/v1/synchronizer/webhooks/pre-process", payload, http.Header{"Intuit-Signature": {qbosim.SignWebhook(payload, qbosim.WebhookToken)}})
+	var preProcess struct {
+		WorkspaceIds []string `json:"workspaceIds"`
+	}
+	json.NewDecoder(signed.Body).Decode(&preProcess)
+	signed.Body.Close()
+	if signed.StatusCode != http.StatusOK || len(preProcess.WorkspaceIds) != 1 || preProcess.WorkspaceIds[0] != h.account.RealmId {
+		t.Fatalf("unexpected pre-process response %d: %+v", signed.StatusCode, preProcess)
+	}
+
+	var transformReq app.WebhookRequest
+	transformReq.Types = []string{"vendor"}
+	transformReq.Account = h.account
+	if err := json.Unmarshal(payload, &transformReq.Payload); err != nil {
+		t.Fatal(err)
+	}
+
+	var transformed fibery.WebhookTransformResponse
+	h.postJSON(t, "/api/v1/synchronizer/webhooks/transform", transformReq, &transformed)
+	vendors := transformed.Data["vendor"]
+	if len(vendors) != 1 || vendors[0]["displayName"] != "Brosnahan Insurance" {
+		t.Errorf("unexpected webhook transform output: %v", transformed.Data)
+	}
+}
+
+func TestE2EWebhookAddressRemoval(t *testing.T) {
+	h := newHarness(t)
+	types := []string{"customer", "address"}
+	h.sync(t, "full", types, time.Time{})
+
+	if err := h.sim.Upsert(h.account.RealmId, "Customer", map[string]any{
+		"Id": "1", "DisplayName": "Amy's Bird Sanctuary", "Active": true,
+		"BillAddr": map[string]any{"Id": "2", "Line1": "12 Wren Ave.", "City": "Bayshore"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	req := app.WebhookRequest{Types: types, Account: h.account}
+	payload := qbosim.WebhookPayload(h.account.RealmId, qbosim.WebhookEntity{Name: "Customer", Id: "1", Operation: "Update", LastUpdated: time.Now()})
+	if err := json.Unmarshal(payload, &req.Payload); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := h.integration.TransformWebhook(context.Background(), req)
+	if err != nil {
+		t.Fatalf("webhook transform failed: %v", err)
+	}
+	addresses := map[string]map[string]any{}
+	for _, item := range resp.Data["address"] {
+		addresses[fmt.Sprint(item["id"])] = item
+	}
+	if len(addresses) != 2 || addresses["customer:1:billing"]["line1"] != "12 Wren Ave." {
+		t.Errorf("expected the updated billing address and the cleared shipping address, got %v", addresses)
+	}
+	if action := addresses["customer:1:shipping"]["__syncAction"]; fmt.Sprint(action) != string(fibery.REMOVE) {
+		t.Errorf("expected the cleared shipping address to be removed, got %v", addresses["customer:1:shipping"])
+	}
+}
+
+func TestE2EOfflineDeltaSync(t *testing.T) {
+	h := newHarness(t)
+	types := []string{"vendor", "bill", "billItemLine"}
+
+	lastSynced := time.Now().Add(-2 * time.Second)
+	if err := h.sim.Upsert(h.account.RealmId, "Vendor", map[string]any{"Id": "2", "DisplayName": "Books by Bessie Inc", "Active": true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.sim.Delete(h.account.RealmId, "Vendor", "5"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the harness starts without an id cache, as the sync command does
+	items := map[string]map[string]map[string]any{}
+	err := h.integration.SyncAll(context.Background(), app.SyncRequest{
+		Types:             types,
+		Account:           h.account,
+		LastSyncronizedAt: lastSynced,
+	}, func(typeId string, page []map[string]any) error {
+		if items[typeId] == nil {
+			items[typeId] = map[string]map[string]any{}
+		}
+		for _, item := range page {
+			items[typeId][fmt.Sprint(item["id"])] = item
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("offline delta sync failed: %v", err)
+	}
+
+	vendors := items["vendor"]
+	if len(vendors) != 2 || vendors["2"]["displayName"] != "Books by Bessie Inc" || fmt.Sprint(vendors["5"]["__syncAction"]) != string(fibery.REMOVE) {
+		t.Errorf("expected only the changed and removed vendors, got %v", vendors)
+	}
+	if len(items["bill"]) != 0 || len(items["billItemLine"]) != 0 {
+		t.Errorf("expected no unchanged bills or lines, got %v and %v", items["bill"], items["billItemLine"])
+	}
+}
+
+func TestE2EAttachableResource(t *testing.T) {
+	h := newHarness(t)
+
+	resp := h.post(t, "/api/v1/synchronizer/resource", map[string]any{
+		"account": h.account,
+		"params":  map[string]string{"type": "attachable", "id": "5000000000000001"},
+	}, nil)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "%PDF-1.4 receipt for B-26" {
+		t.Errorf("unexpected resource response %d: %q", resp.StatusCode, body)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("expected pdf content type, got %q", ct)
+	}
+}
+
+func TestE2EOfflineSyncAndWebhook(t *testing.T) {
+	h := newHarness(t)
+	types := []string{"vendor", "bill", "billItemLine"}
+
+	counts := map[string]int{}
+	err := h.integration.SyncAll(context.Background(), app.SyncRequest{Types: types, Account: h.account}, func(typeId string, items []map[string]any) error {
+		counts[typeId] += len(items)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("offline sync failed: %v", err)
+	}
+	if counts["vendor"] != 5 || counts["bill"] != 3 || counts["billItemLine"] != 3 {
+		t.Errorf("unexpected offline sync counts: %v", counts)
+	}
+
+	if err := h.sim.Upsert(h.account.RealmId, "Vendor", map[string]any{"Id": "4", "DisplayName": "Cal Telephone", "Active": true}); err != nil {
+		t.Fatal(err)
+	}
+	req := app.WebhookRequest{Types: []string{"vendor"}, Account: h.account}
+	payload := qbosim.WebhookPayload(h.account.RealmId, qbosim.WebhookEntity{Name: "Vendor", Id: "4", Operation: "Update", LastUpdated: time.Now()})
+	if err := json.Unmarshal(payload, &req.Payload); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := h.integration.TransformWebhook(context.Background(), req)
+	if err != nil {
+		t.Fatalf("webhook transform failed: %v", err)
+	}
+	if vendors := resp.Data["vendor"]; len(vendors) != 1 || vendors[0]["displayName"] != "Cal Telephone" {
+		t.Errorf("unexpected webhook transform output: %v", resp.Data)
+	}
+}
+
+func TestE2EHomeCurrency(t *testing.T) {
+	fixture := qbosim.SandboxFixture()
+	fixture.Preferences["CurrencyPrefs"] = map[string]any{"MultiCurrencyEnabled": true, "HomeCurrency": map[string]any{"value": "CAD"}}
+	for _, bill := range fixture.Entities["Bill"] {
+		if bill["Id"] == "25" {
+			bill["CurrencyRef"] = map[string]any{"value": "USD", "name": "United States Dollar"}
+			bill["ExchangeRate"] = 1.35
+		}
+	}
+	h := newHarnessWithFixture(t, fixture)
+
+	schema := map[string]map[string]fibery.Field{}
+	h.postJSON(t, "/api/v1/synchronizer/schema", map[string]any{"types": []string{"bill"}, "account": h.account}, &schema)
+	if got := schema["bill"]["homeTotalAmt"].Format["currencyCode"]; got != "CAD" {
+		t.Errorf("expected home amounts in the home currency, got %v", got)
+	}
+	if format := schema["bill"]["totalAmt"].Format; format["format"] != "Number" || format["currencyCode"] != nil {
+		t.Errorf("expected transaction amounts to be plain numbers, got %v", format)
+	}
+
+	bills := h.sync(t, "currency", []string{"bill"}, time.Time{})["bill"]
+	bill := bills["25"]
+	if bill["currency"] != "USD" || bill["exchangeRate"] != 1.35 || bill["homeTotalAmt"] != 139.79 {
+		t.Errorf("unexpected currency fields on bill 25: currency=%v rate=%v home=%v", bill["currency"], bill["exchangeRate"], bill["homeTotalAmt"])
+	}
+	if rate := bills["26"]["exchangeRate"]; rate != 1.0 {
+		t.Errorf("expected home currency bills to have an exchange rate of 1, got %v", rate)
+	}
+}
+
+func TestE2ERealmTypes(t *testing.T) {
+	h := newHarness(t)
+
+	realm := h.sync(t, "realm", []string{"companyInfo", "preferences", "bill"}, time.Time{})
+	info := realm["companyInfo"]["1"]
+	if info["legalName"] != "Sandbox Landscaping Company LLC" || info["addressCity"] != "San Pablo" || info["companyStartDate"] != "2024-01-01T00:00:00Z" {
+		t.Errorf("unexpected company info: %v", info)
+	}
+	prefs := realm["preferences"]["1"]
+	if prefs["homeCurrency"] != "USD" || prefs["multiCurrencyEnabled"] != false || prefs["classTrackingPerTxnLine"] != true {
+		t.Errorf("unexpected preferences: %v", prefs)
+	}
+	if len(realm["bill"]) != 3 {
+		t.Errorf("expected realm types to sync alongside batched types, got %d bills", len(realm["bill"]))
+	}
+
+	schema := map[string]map[string]fibery.Field{}
+	h.postJSON(t, "/api/v1/synchronizer/schema", map[string]any{"types": []string{"bill"}, "account": h.account}, &schema)
+	if _, ok := schema["bill"]["exchangeRate"]; ok {
+		t.Error("expected multi-currency fields to be left out when multi-currency is disabled")
+	}
+	if _, ok := realm["bill"]["25"]["exchangeRate"]; ok {
+		t.Error("expected items to only carry fields from the schema")
+	}
+}
+
+func TestE2EReports(t *testing.T) {
+	h := newHarness(t)
+	items := h.syncAll(t, []string{"profitAndLoss", "balanceSheet", "agedReceivables", "agedPayables", "vendor"},
+		map[string]any{app.ReportPeriodsFilterId: []any{"This Month", "Last Month"}})
+
+	if len(items["profitAndLoss"]) != 10 {
+		t.Errorf("expected 5 profit and loss rows per period, got %d", len(items["profitAndLoss"]))
+	}
+	materials := items["profitAndLoss"]["ProfitAndLoss:Last Month:Expenses:Job Materials"]
+	if materials["accountId"] != "33" || materials["amount"] != 425.5 || materials["period"] != "Last Month" {
+		t.Errorf("unexpected profit and loss row: %v", materials)
+	}
+	if ap := items["balanceSheet"]["BalanceSheet:This Month:Liabilities:Accounts Payable:Accounts Payable (A/P)"]; ap["accountId"] != "7" {
+		t.Errorf("unexpected balance sheet row: %v", ap)
+	}
+	if len(items["agedReceivables"]) != 3 || len(items["agedPayables"]) == 0 {
+		t.Errorf("expected aging reports to run once, got %d receivable rows", len(items["agedReceivables"]))
+	}
+	if cars := items["agedReceivables"]["AgedReceivables:Today:Cool Cars"]; cars["customerId"] != "3" || cars["days91AndOver"] != 1000.0 {
+		t.Errorf("unexpected aged receivables row: %v", cars)
+	}
+	if len(items["vendor"]) != 5 {
+		t.Errorf("expected reports to sync alongside batched types, got %d vendors", len(items["vendor"]))
+	}
+}
+
+// deltaCase is a change to the books between a full and a delta sync of types. full holds the
+// number of items each type must have after the full sync, and want the fields of items the
+// delta must carry, such as the removal of derived items whose source changed.
+type deltaCase struct {
+	name   string
+	types  []string
+	full   map[string]int
+	change func(h *e2eHarness) error
+	want   map[string]map[string]map[string]any
+}
+
+func TestE2EDeltaSync(t *testing.T) {
+	for _, tc := range []deltaCase{
+		{
+			name:  "attachable note",
+			types: []string{"attachable", "bill"},
+			full:  map[string]int{"attachable": 3},
+			change: func(h *e2eHarness) error {
+				return h.sim.Upsert(h.account.RealmId, "Attachable", map[string]any{"Id": "5000000000000003", "Note": "Gate code changed to 5521", "AttachableRef": []any{}})
+			},
+			want: map[string]map[string]map[string]any{
+				"attachable": {"5000000000000003": {"note": "Gate code changed to 5521", "noteOnly": true}},
+			},
+		},
+		{
+			name:  "addresses",
+			types: []string{"address", "customer", "vendor", "employee"},
+			full:  map[string]int{"address": 3},
+			change: func(h *e2eHarness) error {
+				if err := h.sim.Upsert(h.account.RealmId, "Customer", map[string]any{
+					"Id": "1", "DisplayName": "Amy's Bird Sanctuary", "Active": true,
+					"BillAddr": map[string]any{"Id": "2", "Line1": "12 Wren Ave.", "City": "Bayshore"},
+				}); err != nil {
+					return err
+				}
+				return h.sim.Delete(h.account.RealmId, "Vendor", "2")
+			},
+			want: map[string]map[string]map[string]any{
+				"address": {
+					"customer:1:billing":  {"line1": "12 Wren Ave.", "formatted": "12 Wren Ave., Bayshore", "customerId": "1"},
+					"customer:1:shipping": {"__syncAction": fibery.REMOVE},
+					"vendor:2:billing":    {"__syncAction": fibery.REMOVE},
+				},
+			},
+		},
+		{
+			name:  "item group components",
+			types: []string{"item", "itemGroupComponent"},
+			full:  map[string]int{"itemGroupComponent": 2},
+			change: func(h *e2eHarness) error {
+				return h.sim.Upsert(h.account.RealmId, "Item", map[string]any{
+					"Id": "19", "Name": "Fountain Kit", "FullyQualifiedName": "Fountain Kit", "Active": true, "Type": "Group",
+					"ItemGroupDetail": map[string]any{"ItemGroupLine": []any{map[string]any{"ItemRef": map[string]any{"value": "11", "name": "Pump"}, "Qty": 3}}},
+				})
+			},
+			want: map[string]map[string]map[string]any{
+				"itemGroupComponent": {
+					"19:g:11": {"qty": 3, "groupId": "19"},
+					"19:g:3":  {"__syncAction": fibery.REMOVE},
+				},
+			},
+		},
+		{
+			name:  "budget lines",
+			types: []string{"budget", "budgetLine", "account"},
+			full:  map[string]int{"budgetLine": 3},
+			change: func(h *e2eHarness) error {
+				return h.sim.Upsert(h.account.RealmId, "Budget", map[string]any{
+					"Id": "60", "Name": "Materials 2024", "BudgetType": "ProfitAndLoss", "BudgetEntryType": "Monthly", "Active": true,
+					"BudgetDetail": []any{map[string]any{"BudgetDate": "2024-05-01", "Amount": 450, "AccountRef": map[string]any{"value": "33"}}},
+				})
+			},
+			want: map[string]map[string]map[string]any{
+				"budgetLine": {
+					"60:33:2024-05-01:::":  {"amount": 450, "departmentId": ""},
+					"60:33:2024-05-01:::1": {"__syncAction": fibery.REMOVE},
+					"60:33:2024-06-01:::":  {"__syncAction": fibery.REMOVE},
+				},
+			},
+		},
+		{
+			name:  "transaction tax lines",
+			types: []string{"txnTaxLine", "bill"},
+			full:  map[string]int{"txnTaxLine": 1},
+			change: func(h *e2eHarness) error {
+				return h.sim.Upsert(h.account.RealmId, "Bill", map[string]any{
+					"Id": "26", "DocNumber": "B-26", "TotalAmt": 50, "VendorRef": map[string]any{"value": "5"}, "Line": []any{},
+				})
+			},
+			want: map[string]map[string]map[string]any{
+				"txnTaxLine": {"26:t:3": {"__syncAction": fibery.REMOVE}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHarness(t)
+			full := h.sync(t, "full", tc.types, time.Time{})
+			for typeId, n := range tc.full {
+				if len(full[typeId]) != n {
+					t.Errorf("expected %d %s items after the full sync, got %v", n, typeId, full[typeId])
+				}
+			}
+
+			lastSynced := time.Now().Add(-2 * time.Second)
+			if err := tc.change(h); err != nil {
+				t.Fatal(err)
+			}
+			delta := h.sync(t, "delta", tc.types, lastSynced)
+			for typeId, items := range tc.want {
+				if len(delta[typeId]) != len(items) {
+					t.Errorf("expected %d %s items in the delta, got %v", len(items), typeId, delta[typeId])
+				}
+				for id, fields := range items {
+					for field, want := range fields {
+						if got := delta[typeId][id][field]; fmt.Sprint(got) != fmt.Sprint(want) {
+							t.Errorf("expected %s %s %s to be %v, got %v", typeId, id, field, want, got)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestE2EInventory(t *testing.T) {
+	h := newHarness(t)
+	types := []string{"item", "itemQuantitySnapshot", "inventoryAdjustment", "inventoryAdjustmentLine"}
+	today := time.Now().UTC().Format(time.DateOnly)
+
+	full := h.sync(t, "full", types, time.Time{})
+	if len(full["inventoryAdjustment"]) != 1 || len(full["inventoryAdjustmentLine"]) != 1 {
+		t.Errorf("expected the adjustment and its line, got %v and %v", full["inventoryAdjustment"], full["inventoryAdjustmentLine"])
+	}
+	snapshots := full["itemQuantitySnapshot"]
+	if len(snapshots) != 1 {
+		t.Fatalf("expected a snapshot of the one inventory item, got %v", snapshots)
+	}
+	if snapshot := snapshots["11:"+today]; snapshot["itemId"] != "11" || snapshot["qtyOnHand"] != float64(25) {
+		t.Errorf("unexpected quantity snapshot: %v", snapshots)
+	}
+
+	lastSynced := time.Now().Add(-2 * time.Second)
+	if err := h.sim.Upsert(h.account.RealmId, "Item", map[string]any{"Id": "11", "Name": "Pump", "FullyQualifiedName": "Pump", "Active": true, "Type": "Inventory", "QtyOnHand": 23}); err != nil {
+		t.Fatal(err)
+	}
+	delta := h.sync(t, "delta", types, lastSynced)
+	if delta["item"]["11"]["qtyOnHand"] != float64(23) {
+		t.Errorf("expected the changed item, got %v", delta["item"])
+	}
+	if snapshot := delta["itemQuantitySnapshot"]["11:"+today]; len(delta["itemQuantitySnapshot"]) != 1 || snapshot["qtyOnHand"] != float64(23) {
+		t.Errorf("expected a snapshot of the changed quantity during a delta sync, got %v", delta["itemQuantitySnapshot"])
+	}
+
+	// a full sync of another type in the same request must not replace the snapshot history
+	since := map[string]time.Time{
+		"item":                    lastSynced,
+		"itemQuantitySnapshot":    lastSynced,
+		"inventoryAdjustment":     {},
+		"inventoryAdjustmentLine": {},
+	}
+	mixed, syncTypes := h.syncSince(t, "mixed", types, since)
+	if syncTypes["itemQuantitySnapshot"] != fibery.Delta || len(mixed["itemQuantitySnapshot"]) != 1 {
+		t.Errorf("expected a delta page with the snapshot, got %s with %v", syncTypes["itemQuantitySnapshot"], mixed["itemQuantitySnapshot"])
+	}
+	if syncTypes["inventoryAdjustment"] != fibery.Full {
+		t.Errorf("expected a full page for the type synced from scratch, got %s", syncTypes["inventoryAdjustment"])
+	}
+}
+
+func TestE2ERecurringTransactions(t *testing.T) {
+	h := newHarness(t)
+
+	templates := h.sync(t, "full", []string{"recurringTransaction", "vendor", "customer"}, time.Time{})["recurringTransaction"]
+	if len(templates) != 2 || templates["Bill:12"]["vendorId"] != "4" || templates["Invoice:12"]["customerId"] != "2" {
+		t.Errorf("expected a bill and an invoice template sharing an id, got %v", templates)
+	}
+}
+
+func TestE2ETaxes(t *testing.T) {
+	h := newHarness(t)
+
+	full := h.sync(t, "full", []string{"taxAgency", "taxRate", "taxCode", "taxCodeRate", "txnTaxLine", "bill"}, time.Time{})
+	if rate := full["taxRate"]["3"]; rate["rateValue"] != float64(8) || rate["agencyId"] != "1" {
+		t.Errorf("unexpected tax rate: %v", rate)
+	}
+	if line := full["txnTaxLine"]["26:t:3"]; line["billId"] != "26" || line["taxRateId"] != "3" {
+		t.Errorf("expected bill 26 to carry its tax line, got %v", full["txnTaxLine"])
+	}
+	if len(full["taxAgency"]) == 0 || len(full["taxCode"]) == 0 || len(full["taxCodeRate"]) != 2 {
+		t.Errorf("expected agencies, codes and 2 code rates, got %d, %d and %d", len(full["taxAgency"]), len(full["taxCode"]), len(full["taxCodeRate"]))
+	}
+}
+
+func TestE2EVendorYearSummaries(t *testing.T) {
+	h := newHarness(t)
+	today := time.Now().UTC().Format(time.DateOnly)
+	for entity, items := range map[string][]map[string]any{
+		"BillPayment": {
+			{"Id": "90", "TxnDate": today, "TotalAmt": 100, "VendorRef": map[string]any{"value": "4", "name": "Cal Telephone"}, "PayType": "Check"},
+			{"Id": "91", "TxnDate": today, "TotalAmt": 20, "VendorRef": map[string]any{"value": "4", "name": "Cal Telephone"}, "PayType": "CreditCard"},
+		},
+		"Purchase": {
+			{"Id": "92", "TxnDate": today, "TotalAmt": 50, "EntityRef": map[string]any{"value": "3", "name": "Brosnahan Insurance Agency", "type": "Vendor"}, "PaymentType": "Cash"},
+			{"Id": "93", "TxnDate": today, "TotalAmt": 10, "EntityRef": map[string]any{"value": "3", "name": "Brosnahan Insurance Agency", "type": "Vendor"}, "PaymentType": "CreditCard", "Credit": true},
+			{"Id": "94", "TxnDate": today, "TotalAmt": 999, "EntityRef": map[string]any{"value": "1", "type": "Customer"}, "PaymentType": "Cash"},
+		},
+	} {
+		for _, item := range items {
+			if err := h.sim.Upsert(h.account.RealmId, entity, item); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	full := h.sync(t, "full", []string{"vendor", "vendorYearSummary"}, time.Time{})
+	summaries := full["vendorYearSummary"]
+	if len(summaries) != 2 {
+		t.Fatalf("expected a summary for each paid vendor, got %v", summaries)
+	}
+	year := strconv.Itoa(time.Now().UTC().Year())
+	phone := summaries["4:"+year]
+	if phone["vendorId"] != "4" || phone["billPayments"] != float64(120) || phone["totalPaid"] != float64(120) || phone["paid1099"] != float64(100) {
+		t.Errorf("unexpected bill payment summary: %v", phone)
+	}
+	insurance := summaries["3:"+year]
+	if insurance["purchases"] != float64(40) || insurance["totalPaid"] != float64(40) || insurance["paid1099"] != float64(50) {
+		t.Errorf("unexpected purchase summary: %v", insurance)
+	}
+}
+
+func TestE2EExchangeRates(t *testing.T) {
+	fixture := qbosim.SandboxFixture()
+	fixture.Preferences["CurrencyPrefs"] = map[string]any{"MultiCurrencyEnabled": true, "HomeCurrency": map[string]any{"value": "USD"}}
+	h := newHarnessWithFixture(t, fixture)
+	today := time.Now().UTC()
+	for _, rate := range []map[string]any{
+		{"Id": "EUR:today", "SourceCurrencyCode": "EUR", "TargetCurrencyCode": "USD", "Rate": 1.0815, "AsOfDate": today.Format(time.DateOnly)},
+		{"Id": "EUR:old", "SourceCurrencyCode": "EUR", "TargetCurrencyCode": "USD", "Rate": 1.0644, "AsOfDate": today.AddDate(0, 0, -40).Format(time.DateOnly)},
+		{"Id": "CAD:today", "SourceCurrencyCode": "CAD", "TargetCurrencyCode": "USD", "Rate": 0.7311, "AsOfDate": today.Format(time.DateOnly)},
+	} {
+		if err := h.sim.Upsert(h.account.RealmId, "ExchangeRate", rate); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	types := []string{"currency", "exchangeRate"}
+	items := h.syncAll(t, types, nil)
+	if eur, cad := items["currency"]["EUR"], items["currency"]["CAD"]; eur["name"] != "Euro" || eur["active"] != true || cad["active"] != false {
+		t.Errorf("unexpected currencies: %v and %v", eur, cad)
+	}
+	if len(items["exchangeRate"]) != 1 {
+		t.Fatalf("expected only today's rate for the active currency, got %v", items["exchangeRate"])
+	}
+	rate := items["exchangeRate"]["EUR:"+today.Format(time.DateOnly)]
+	if fmt.Sprint(rate["rate"]) != "1.0815" || rate["currencyId"] != "EUR" || rate["targetCurrency"] != "USD" {
+		t.Errorf("unexpected exchange rate: %v", rate)
+	}
+
+	if rates := h.syncAll(t, types, map[string]any{app.ExchangeRateDaysFilterId: float64(45)})["exchangeRate"]; len(rates) != 2 {
+		t.Errorf("expected a wider window to pick up the older rate, got %v", rates)
+	}
+
+	for days, want := range map[any]int{float64(app.MaxExchangeRateDays): http.StatusOK, float64(app.MaxExchangeRateDays + 1): http.StatusBadRequest} {
+		resp := h.post(t, "/api/v1/synchronizer/filter/validate", map[string]any{
+			"filter":  map[string]any{app.ExchangeRateDaysFilterId: days},
+			"account": h.account,
+		}, nil)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("expected filter of %v days to return %d, got %d", days, want, resp.StatusCode)
+		}
+	}
+}
+
+func TestE2ESingleCurrencyRealm(t *testing.T) {
+	h := newHarness(t)
+
+	// the sandbox realm has multi-currency turned off, where QuickBooks faults currency queries
+	full := h.sync(t, "full", []string{"vendor", "currency", "exchangeRate"}, time.Time{})
+	if len(full["currency"]) != 0 || len(full["exchangeRate"]) != 0 {
+		t.Errorf("expected no currencies or rates without multi-currency, got %v and %v", full["currency"], full["exchangeRate"])
+	}
+	if len(full["vendor"]) != 5 {
+		t.Errorf("expected the rest of the sync to go through, got %d vendors", len(full["vendor"]))
+	}
+}
+
+func TestE2ESchemaWithoutPreferences(t *testing.T) {
+	h := newHarnessWithFixture(t, qbosim.SandboxFixture(), func(c *app.Config) { c.IdCacheTTL = time.Millisecond })
+	revoked := h.account
+	revoked.BearerToken.AccessToken = "revoked"
+
+	// the sandbox realm has multi-currency turned off, so the last preferences seen drop currency
+	schema := map[string]map[string]fibery.Field{}
+	h.postJSON(t, "/api/v1/synchronizer/schema", map[string]any{"types": []string{"bill"}, "account": h.account}, &schema)
+	time.Sleep(5 * time.Millisecond)
+	stale := map[string]map[string]fibery.Field{}
+	h.postJSON(t, "/api/v1/synchronizer/schema", map[string]any{"types": []string{"bill"}, "account": revoked}, &stale)
+	if _, ok := stale["bill"]["currency"]; ok || len(stale["bill"]) != len(schema["bill"]) {
+		t.Errorf("expected the last preferences to be used, got %d fields instead of %d", len(stale["bill"]), len(schema["bill"]))
+	}
+
+	// a realm never seen before gets every field
+	revoked.RealmId = "unknown"
+	unfiltered := map[string]map[string]fibery.Field{}
+	h.postJSON(t, "/api/v1/synchronizer/schema", map[string]any{"types": []string{"bill"}, "account": revoked}, &unfiltered)
+	if _, ok := unfiltered["bill"]["currency"]; !ok {
+		t.Error("expected an unfiltered schema without preferences")
+	}
+	if format := unfiltered["bill"]["totalAmt"].Format; format["format"] != "Number" {
+		t.Errorf("expected transaction amounts to be plain numbers, got %v", format)
+	}
+}
+
+func TestE2EFieldMappings(t *testing.T) {
+	path := t.TempDir() + "/mappings.json"
+	mappings := `{
+		"item": {
+			"currency": "EUR",
+			"fields": {
+				"sku": {"hidden": true},
+				"unitPrice": {"name": "Sale Price"},
+				"type": {"options": {"Service": "Labour"}}
+			}
+		}
+	}`
+	if err := os.WriteFile(path, []byte(mappings), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// the mappings are global, so this test must not run in parallel with other tests
+	previous := app.FieldMappings
+	t.Cleanup(func() { app.FieldMappings = previous })
+
+	h := newHarness(t)
+	if err := app.UseFieldMappings(path); err != nil {
+		t.Fatalf("unable to use field mappings: %v", err)
+	}
+
+	schema := map[string]map[string]fibery.Field{}
+	h.postJSON(t, "/api/v1/synchronizer/schema", map[string]any{"types": []string{"item", "bill"}, "account": h.account}, &schema)
+	item := schema["item"]
+	if _, ok := item["sku"]; ok {
+		t.Error("expected hidden fields to be left out of the schema")
+	}
+	if item["unitPrice"].Name != "Sale Price" {
+		t.Errorf("expected renamed field, got %q", item["unitPrice"].Name)
+	}
+	if item["unitPrice"].Format["currencyCode"] != "EUR" || schema["bill"]["totalAmt"].Format["currencyCode"] != "USD" {
+		t.Errorf("expected the mapped currency on item money fields only, got %v and %v",
+			item["unitPrice"].Format["currencyCode"], schema["bill"]["totalAmt"].Format["currencyCode"])
+	}
+
+	items := h.sync(t, "mappings", []string{"item"}, time.Time{})["item"]
+	if items["3"]["type"] != "Labour" || items["11"]["type"] != "Inventory" {
+		t.Errorf("expected renamed select values, got %v and %v", items["3"]["type"], items["11"]["type"])
+	}
+	if _, ok := items["3"]["sku"]; ok {
+		t.Error("expected hidden fields to be left out of items")
+	}
+
+	invalid := t.TempDir() + "/invalid.json"
+	if err := os.WriteFile(invalid, []byte(`{"item": {"fields": {"id": {"hidden": true}, "nope": {}}}, "widget": {}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := app.UseFieldMappings(invalid)
+	var schemaErr *app.SchemaError
+	if !errors.As(err, &schemaErr) || len(schemaErr.Problems) != 3 {
+		t.Fatalf("expected three mapping problems, got %v", err)
+	}
+	if _, ok := app.FieldMappings["item"].Fields["sku"]; !ok {
+		t.Error("expected invalid mappings to leave the applied mappings in place")
+	}
+}
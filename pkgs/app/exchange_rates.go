@@ -0,0 +1,62 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ExchangeRateDaysFilterId is the sync filter holding how many days of exchange rates are synced.
+const ExchangeRateDaysFilterId = "exchangeRateDays"
+
+// DefaultExchangeRateDays is used when no window is set on the sync filter.
+const DefaultExchangeRateDays = 30
+
+// MaxExchangeRateDays bounds the window, as every day in it is a query of its own.
+const MaxExchangeRateDays = 366
+
+// ExchangeRateDays reads the exchange rate window from the sync filter, falling back to
+// DefaultExchangeRateDays when it is not set.
+func ExchangeRateDays(filter map[string]any) (int, error) {
+	var days float64
+	switch v := filter[ExchangeRateDaysFilterId].(type) {
+	case nil:
+		return DefaultExchangeRateDays, nil
+	case float64:
+		days = v
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("%s must be a number: %w", ExchangeRateDaysFilterId, err)
+		}
+		days = f
+	case string:
+		if v == "" {
+			return DefaultExchangeRateDays, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be a number: %w", ExchangeRateDaysFilterId, err)
+		}
+		days = f
+	default:
+		return 0, fmt.Errorf("%s must be a number, got %T", ExchangeRateDaysFilterId, v)
+	}
+
+	if days != math.Trunc(days) || days < 1 || days > MaxExchangeRateDays {
+		return 0, fmt.Errorf("%s must be a whole number from 1 to %d", ExchangeRateDaysFilterId, MaxExchangeRateDays)
+	}
+	return int(days), nil
+}
+
+// ExchangeRateDates returns the dates of a window of days ending with today, oldest first.
+func ExchangeRateDates(days int, today time.Time) []string {
+	today = today.UTC().Truncate(24 * time.Hour)
+	dates := make([]string, 0, days)
+	for offset := days - 1; offset >= 0; offset-- {
+		dates = append(dates, today.AddDate(0, 0, -offset).Format(time.DateOnly))
+	}
+	return dates
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExchangeRateDays(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		value   any
+		want    int
+		invalid bool
+	}{
+		"unset":             {value: nil, want: DefaultExchangeRateDays},
+		"empty string":      {value: "", want: DefaultExchangeRateDays},
+		"zero":              {value: float64(0), invalid: true},
+		"negative":          {value: float64(-1), invalid: true},
+		"one":               {value: float64(1), want: 1},
+		"maximum":           {value: float64(MaxExchangeRateDays), want: MaxExchangeRateDays},
+		"above maximum":     {value: float64(MaxExchangeRateDays + 1), invalid: true},
+		"fractional":        {value: 1.5, invalid: true},
+		"numeric string":    {value: "45", want: 45},
+		"fractional string": {value: "45.5", invalid: true},
+		"text":              {value: "soon", invalid: true},
+		"json number":       {value: json.Number("366"), want: 366},
+		"invalid json":      {value: json.Number("1e"), invalid: true},
+		"boolean":           {value: true, invalid: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ExchangeRateDays(map[string]any{ExchangeRateDaysFilterId: tc.value})
+			if tc.invalid {
+				if err == nil {
+					t.Errorf("expected %v to be rejected, got %d", tc.value, got)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Errorf("ExchangeRateDays(%v) = %d, %v; want %d", tc.value, got, err, tc.want)
+			}
+		})
+	}
+
+	if got, err := ExchangeRateDays(nil); err != nil || got != DefaultExchangeRateDays {
+		t.Errorf("expected the default for a missing filter, got %d, %v", got, err)
+	}
+}
+
+func TestExchangeRateDates(t *testing.T) {
+	t.Parallel()
+	// late in the day west of UTC is already the next day in UTC
+	today := time.Date(2024, 3, 1, 20, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+
+	for _, tc := range []struct {
+		days        int
+		first, last string
+	}{
+		{1, "2024-03-02", "2024-03-02"},
+		{3, "2024-02-29", "2024-03-02"},
+		{MaxExchangeRateDays, "2023-03-03", "2024-03-02"},
+	} {
+		dates := ExchangeRateDates(tc.days, today)
+		if len(dates) != tc.days || dates[0] != tc.first || dates[len(dates)-1] != tc.last {
+			t.Errorf("ExchangeRateDates(%d) = %s ... %s (%d dates); want %s ... %s",
+				tc.days, dates[0], dates[len(dates)-1], len(dates), tc.first, tc.last)
+		}
+		seen := make(map[string]bool, len(dates))
+		for _, date := range dates {
+			if seen[date] {
+				t.Errorf("duplicate date %s in %s", date, strings.Join(dates, ","))
+			}
+			seen[date] = true
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package app
+
+import (
+	"encoding/json"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+// Field builders generate FieldDef[T] from an accessor on the QuickBooks item, so type files
+// only declare what each field reads. Optional QuickBooks structs are read through pointer
+// accessors and emit empty values when missing.
+
+// Field is a field of any type whose value is returned unchanged by get.
+func Field[T, V any](params fibery.Field, get func(T) V) FieldDef[T] {
+	return FieldDef[T]{
+		Params: params,
+		Convert: func(sd StandardData[T]) (any, error) {
+			return get(sd.Item), nil
+		},
+	}
+}
+
+func TextField[T any](name string, get func(T) string) FieldDef[T] {
+	return Field(fibery.Field{Name: name, Type: fibery.Text}, get)
+}
+
+func TitleField[T any](name string, get func(T) string) FieldDef[T] {
+	return Field(fibery.Field{Name: name, Type: fibery.Text, SubType: fibery.Title}, get)
+}
+
+func MarkdownField[T any](name string, get func(T) string) FieldDef[T] {
+	return Field(fibery.Field{Name: name, Type: fibery.Text, SubType: fibery.MD}, get)
+}
+
+func BoolField[T any](name string, get func(T) bool) FieldDef[T] {
+	return Field(fibery.Field{Name: name, Type: fibery.Text, SubType: fibery.Boolean}, get)
+}
+
+func IntegerField[T, V any](name string, get func(T) V) FieldDef[T] {
+	return Field(fibery.Field{Name: name, Type: fibery.Number, SubType: fibery.Integer}, get)
+}
+
+func NumberField[T, V any](name string, format map[string]any, get func(T) V) FieldDef[T] {
+	return Field(fibery.Field{Name: name, Type: fibery.Number, Format: format}, get)
+}
+
+// MoneyField is formatted in the realm's home currency when the schema is requested.
+func MoneyField[T, V any](name string, get func(T) V) FieldDef[T] {
+	return NumberField(name, MoneyFormat(), get)
+}
+
+// ExchangeRateField emits the transaction's exchange rate, 1 for home currency transactions.
+func ExchangeRateField[T any](get func(T) json.Number) FieldDef[T] {
+	params := fibery.Field{
+		Name:        "Exchange Rate",
+		Type:        fibery.Number,
+		Format:      ExchangeRateFormat(),
+		Description: "Home currency units per unit of the transaction currency",
+	}
+	return Field(params, func(item T) json.Number {
+		return ExchangeRate(get(item))
+	})
+}
+
+// HomeMoneyField converts an amount in the transaction currency to the home currency.
+func HomeMoneyField[T any](name string, amount, exchangeRate func(T) json.Number) FieldDef[T] {
+	return FieldDef[T]{
+		Params: fibery.Field{Name: name, Type: fibery.Number, Format: MoneyFormat()},
+		Convert: func(sd StandardData[T]) (any, error) {
+			return HomeAmount(amount(sd.Item), exchangeRate(sd.Item))
+		},
+	}
+}
+
+// QuantityFormat is the Fibery format of item quantities.
+func QuantityFormat() map[string]any {
+	return map[string]any{
+		"format":               "Number",
+		"hasThousandSeparator": true,
+		"precision":            2,
+	}
+}
+
+// PercentFormat is the Fibery format of percentages.
+func PercentFormat() map[string]any {
+	return map[string]any{
+		"format":    "Percent",
+		"precision": 2,
+	}
+}
+
+// SyncActionField always sets the item, removals are emitted by the type itself.
+func SyncActionField[T any]() FieldDef[T] {
+	return Field(fibery.Field{Name: "Sync Action", Type: fibery.Text}, func(T) fibery.SyncAction {
+		return fibery.SET
+	})
+}
+
+// DayField emits a missing or zero date as an empty string.
+func DayField[T any](name string, get func(T) *quickbooks.Date) FieldDef[T] {
+	return Field(fibery.Field{Name: name, Type: fibery.DateType, SubType: fibery.Day}, func(item T) string {
+		date := get(item)
+		if date == nil || date.IsZero() {
+			return ""
+		}
+		return date.Format(fibery.DateFormat)
+	})
+}
+
+func EmailField[T any](name string, get func(T) *quickbooks.EmailAddress) FieldDef[T] {
+	return Field(fibery.Field{Name: name, Type: fibery.Text, SubType: fibery.Email}, func(item T) string {
+		if email := get(item); email != nil {
+			return email.Address
+		}
+		return ""
+	})
+}
+
+func PhoneField[T any](name string, get func(T) *quickbooks.TelephoneNumber) FieldDef[T] {
+	return Field(fibery.Field{Name: name, Type: fibery.Text, Format: map[string]any{"format": "phone"}}, func(item T) string {
+		if phone := get(item); phone != nil {
+			return phone.FreeFormNumber
+		}
+		return ""
+	})
+}
+
+func WebsiteField[T any](name string, get func(T) *quickbooks.WebSiteAddress) FieldDef[T] {
+	return Field(fibery.Field{Name: name, Type: fibery.Text, SubType: fibery.URL}, func(item T) string {
+		if site := get(item); site != nil {
+			return site.URI
+		}
+		return ""
+	})
+}
+
+// ReferenceField emits the id of a QuickBooks reference. Add a relation with Relation.
+func ReferenceField[T any](name string, get func(T) *quickbooks.ReferenceType) FieldDef[T] {
+	return TextField(name, func(item T) string {
+		if ref := get(item); ref != nil {
+			return ref.Value
+		}
+		return ""
+	})
+}
+
+// EnumOption maps a QuickBooks enum value to the name of its select option.
+type EnumOption struct {
+	Value string
+	Name  string
+}
+
+// EnumOptions are options whose names are the QuickBooks values themselves.
+func EnumOptions(values ...string) []EnumOption {
+	options := make([]EnumOption, 0, len(values))
+	for _, value := range values {
+		options = append(options, EnumOption{Value: value, Name: value})
+	}
+	return options
+}
+
+// SelectField is a single select with one option per enum value. Values without an option are
+// emitted unchanged.
+func SelectField[T any](name string, options []EnumOption, get func(T) string) FieldDef[T] {
+	names := make([]string, 0, len(options))
+	mapping := make(map[string]string, len(options))
+	for _, option := range options {
+		names = append(names, option.Name)
+		mapping[option.Value] = option.Name
+	}
+
+	params := fibery.Field{
+		Name:    name,
+		Type:    fibery.Text,
+		SubType: fibery.SingleSelect,
+		Options: SelectOptions(names...),
+	}
+	return Field(params, func(item T) string {
+		value := get(item)
+		if name, ok := mapping[value]; ok {
+			return name
+		}
+		return value
+	})
+}
+
+// AddressPart is one field of an address block.
+type AddressPart struct {
+	Id   string
+	Name string
+	get  func(*quickbooks.PhysicalAddress) string
+}
+
+var (
+	AddressLine1      = AddressPart{"Line1", "Line 1", func(a *quickbooks.PhysicalAddress) string { return a.Line1 }}
+	AddressLine2      = AddressPart{"Line2", "Line 2", func(a *quickbooks.PhysicalAddress) string { return a.Line2 }}
+	AddressLine3      = AddressPart{"Line3", "Line 3", func(a *quickbooks.PhysicalAddress) string { return a.Line3 }}
+	AddressLine4      = AddressPart{"Line4", "Line 4", func(a *quickbooks.PhysicalAddress) string { return a.Line4 }}
+	AddressLine5      = AddressPart{"Line5", "Line 5", func(a *quickbooks.PhysicalAddress) string { return a.Line5 }}
+	AddressCity       = AddressPart{"City", "City", func(a *quickbooks.PhysicalAddress) string { return a.City }}
+	AddressState      = AddressPart{"State", "State", func(a *quickbooks.PhysicalAddress) string { return a.CountrySubDivisionCode }}
+	AddressPostalCode = AddressPart{"PostalCode", "Postal Code", func(a *quickbooks.PhysicalAddress) string { return a.PostalCode }}
+	AddressCountry    = AddressPart{"Country", "Country", func(a *quickbooks.PhysicalAddress) string { return a.Country }}
+	AddressLat        = AddressPart{"Lat", "Latitude", func(a *quickbooks.PhysicalAddress) string { return a.Lat }}
+	AddressLong       = AddressPart{"Long", "Longitude", func(a *quickbooks.PhysicalAddress) string { return a.Long }}
+)
+
+var allAddressParts = []AddressPart{
+	AddressLine1, AddressLine2, AddressLine3, AddressLine4, AddressLine5,
+	AddressCity, AddressState, AddressPostalCode, AddressCountry, AddressLat, AddressLong,
+}
+
+// AddressFields builds an address block with ids such as billingCity named "Billing City". Every
+// part is included when none are given.
+func AddressFields[T any](idPrefix, namePrefix string, get func(T) *quickbooks.PhysicalAddress, parts ...AddressPart) map[string]FieldDef[T] {
+	if len(parts) == 0 {
+		parts = allAddressParts
+	}
+	fields := make(map[string]FieldDef[T], len(parts))
+	for _, part := range parts {
+		fields[idPrefix+part.Id] = TextField(namePrefix+" "+part.Name, func(item T) string {
+			if addr := get(item); addr != nil {
+				return part.get(addr)
+			}
+			return ""
+		})
+	}
+	return fields
+}
+
+// Fields merges field blocks into one field map, later blocks replacing earlier fields.
+func Fields[T any](blocks ...map[string]FieldDef[T]) map[string]FieldDef[T] {
+	fields := make(map[string]FieldDef[T])
+	for _, block := range blocks {
+		for id, field := range block {
+			fields[id] = field
+		}
+	}
+	return fields
+}
+
+// DependentField reads a dependent item with a field built for the item alone.
+func DependentField[ST, T any](f FieldDef[T]) DependentFieldDef[ST, T] {
+	return DependentFieldDef[ST, T]{
+		Params: f.Params,
+		Convert: func(dd DependentData[ST, T]) (any, error) {
+			return f.Convert(StandardData[T]{Item: dd.Item})
+		},
+	}
+}
+
+// --- FieldDef[T] modifiers ---
+
+func (f FieldDef[T]) ReadOnly() FieldDef[T] {
+	f.Params.ReadOnly = true
+	return f
+}
+
+func (f FieldDef[T]) Ignored() FieldDef[T] {
+	f.Params.Ignore = true
+	return f
+}
+
+func (f FieldDef[T]) Describe(description string) FieldDef[T] {
+	f.Params.Description = description
+	return f
+}
+
+// Relation links the field to the id of targetType.
+func (f FieldDef[T]) Relation(cardinality fibery.CardinalityType, name, targetName, targetType string) FieldDef[T] {
+	f.Params.Relation = &fibery.Relation{
+		Cardinality:   cardinality,
+		Name:          name,
+		TargetName:    targetName,
+		TargetType:    targetType,
+		TargetFieldID: "id",
+	}
+	return f
+}
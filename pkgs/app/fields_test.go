@@ -0,0 +1,84 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+func convertField[T any](t *testing.T, f FieldDef[T], item T) any {
+	t.Helper()
+	value, err := f.Convert(StandardData[T]{Item: item})
+	if err != nil {
+		t.Fatalf("converting %s: %v", f.Params.Name, err)
+	}
+	return value
+}
+
+func TestFieldBuilders(t *testing.T) {
+	t.Parallel()
+	type record struct {
+		Type    string
+		Date    *quickbooks.Date
+		Addr    *quickbooks.PhysicalAddress
+		Parent  *quickbooks.ReferenceType
+		Website quickbooks.WebSiteAddress
+	}
+	full := record{
+		Type:   "NonInventory",
+		Date:   &quickbooks.Date{Time: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		Addr:   &quickbooks.PhysicalAddress{Line1: "1 Main St", CountrySubDivisionCode: "CA"},
+		Parent: &quickbooks.ReferenceType{Value: "7"},
+	}
+
+	kind := SelectField("Type", []EnumOption{{Value: "NonInventory", Name: "Non-Inventory"}},
+		func(r record) string { return r.Type })
+	if got := convertField(t, kind, full); got != "Non-Inventory" {
+		t.Errorf("expected mapped select value, got %v", got)
+	}
+	if got := convertField(t, kind, record{Type: "Group"}); got != "Group" {
+		t.Errorf("expected unmapped select value unchanged, got %v", got)
+	}
+	if opts := kind.Params.Options; len(opts) != 1 || opts[0]["name"] != "Non-Inventory" {
+		t.Errorf("unexpected select options %v", opts)
+	}
+
+	day := DayField("Date", func(r record) *quickbooks.Date { return r.Date })
+	if got := convertField(t, day, full); got != full.Date.Format(fibery.DateFormat) {
+		t.Errorf("unexpected day value %v", got)
+	}
+	if got := convertField(t, day, record{}); got != "" {
+		t.Errorf("expected missing date to be empty, got %v", got)
+	}
+
+	parent := ReferenceField("Parent", func(r record) *quickbooks.ReferenceType { return r.Parent }).
+		Relation(fibery.MTO, "Parent", "Children", "record")
+	if got := convertField(t, parent, full); got != "7" {
+		t.Errorf("unexpected reference value %v", got)
+	}
+	if got := convertField(t, parent, record{}); got != "" {
+		t.Errorf("expected missing reference to be empty, got %v", got)
+	}
+	if rel := parent.Params.Relation; rel == nil || rel.TargetType != "record" || rel.TargetFieldID != "id" {
+		t.Errorf("unexpected relation %+v", rel)
+	}
+
+	addr := AddressFields("billing", "Billing", func(r record) *quickbooks.PhysicalAddress { return r.Addr },
+		AddressLine1, AddressState)
+	if len(addr) != 2 || addr["billingState"].Params.Name != "Billing State" {
+		t.Fatalf("unexpected address fields %v", addr)
+	}
+	if got := convertField(t, addr["billingState"], full); got != "CA" {
+		t.Errorf("unexpected address value %v", got)
+	}
+	if got := convertField(t, addr["billingLine1"], record{}); got != "" {
+		t.Errorf("expected missing address to be empty, got %v", got)
+	}
+
+	site := WebsiteField("Website", func(r record) *quickbooks.WebSiteAddress { return &r.Website }).ReadOnly()
+	if !site.Params.ReadOnly || site.Params.SubType != fibery.URL {
+		t.Errorf("unexpected website params %+v", site.Params)
+	}
+}
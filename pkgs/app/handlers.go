@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -90,15 +91,23 @@ func (i *Integration) SyncSchemaHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// a schema is still emitted when the preferences can't be fetched, from the last preferences
+	// seen for the realm or, without any, unfiltered
+	prefs, err := i.Preferences(r.Context(), params.Account)
+	if err != nil {
+		prefs, _ = i.preferences.last(params.Account.RealmId)
+		slog.Warn(fmt.Sprintf("unable to get preferences for schema of realm %s, falling back: %s", params.Account.RealmId, err.Error()))
+	}
+
 	requestedSchemas := make(map[string]map[string]fibery.Field)
 
 	for _, typeId := range params.Types {
-		storedType, ok := i.types.Get(typeId)
-		if !ok {
-			RespondWithError(w, http.StatusBadRequest, fmt.Errorf("type %s not found in registered types", typeId))
+		schema, err := i.RealmSchema(typeId, prefs)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, err)
 			return
 		}
-		requestedSchemas[typeId] = storedType.Schema()
+		requestedSchemas[typeId] = schema
 	}
 
 	RespondWithJSON(w, http.StatusOK, requestedSchemas)
@@ -127,47 +136,18 @@ func (i *Integration) SyncDataHandler(w http.ResponseWriter, r *http.Request) {
 		req.Pagination.Page = 1
 	}
 
-	op, err := i.opManager.GetOrAddOperation(req, i)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("issue getting/creating operation: %w", err))
-		return
-	}
-
-	err = op.SubmitRequest(req)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, err)
-		return
-	}
-
-	key := ResponseChannelKey(req.RequestedType, req.Pagination.Page)
-
-	slog.Debug(fmt.Sprintf("request submitted & key created"))
-
-	ch, err := op.GetChannel(key)
+	resp, err := i.SyncPage(r.Context(), req)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, err)
-		return
-	}
-
-	slog.Debug("channel found")
-
-	select {
-	case resp, ok := <-ch:
-		if !ok {
-			RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("channel prematurely closed for key: %s", key))
+		if errors.Is(err, ErrOperationDone) {
+			RespondWithError(w, http.StatusGatewayTimeout, err)
 			return
 		}
-
-		if resp.Error != nil {
-			RespondWithError(w, http.StatusInternalServerError, resp.Error)
-			return
-		}
-
-		RespondWithJSON(w, http.StatusOK, resp.DataHandlerResponse)
-	case <-op.ctx.Done():
-		RespondWithError(w, http.StatusGatewayTimeout, fmt.Errorf("operation %s cancelled or timed out", req.OperationId))
+		HandleRequestError(w, http.StatusInternalServerError, "", err)
 		return
 	}
+
+	i.metrics.SyncResponse(req.RequestedType, req.Pagination.Page, resp)
+	RespondWithJSON(w, http.StatusOK, resp)
 }
 
 func (Integration) WebhookInitHandler(w http.ResponseWriter, r *http.Request) {
@@ -263,30 +243,16 @@ func (i *Integration) WebhookTransformHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	wg, err := buildWebhookGroup(req, i, time.Duration(5*time.Second))
-	if err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Errorf("error building webhookGroup: %w", err))
-		return
-	}
-
-	err = wg.fetchAll(r.Context())
+	resp, err := i.TransformWebhook(r.Context(), req)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("error fetching webhook data: %w", err))
-		return
-	}
-
-	items, err := wg.process()
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("error processing webhookGroup data: %w", err))
+		if errors.Is(err, ErrInvalidWebhook) {
+			RespondWithError(w, http.StatusBadRequest, err)
+			return
+		}
+		HandleRequestError(w, http.StatusInternalServerError, "", err)
 		return
 	}
 
-	resp := fibery.WebhookTransformResponse{
-		Data: make(fibery.WebhookData, len(items)),
-	}
-
-	resp.Data = items
-
 	RespondWithJSON(w, http.StatusOK, resp)
 }
 
@@ -374,7 +340,59 @@ func (Integration) LogoHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write(svgData)
 }
 
+func (Integration) SyncDatalistHandler(w http.ResponseWriter, r *http.Request) {
+	type requestBody struct {
+		Types   []string              `json:"types"`
+		Account QuickBooksAccountInfo `json:"account"`
+		Field   string                `json:"field"`
+	}
+
+	type datalistItem struct {
+		Title string `json:"title"`
+		Value string `json:"value"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	req := requestBody{}
+	err := decoder.Decode(&req)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, fmt.Errorf("unable to decode request parameters: %w", err))
+		return
+	}
+
+	if req.Field != ReportPeriodsFilterId {
+		RespondWithError(w, http.StatusBadRequest, fmt.Errorf("no datalist for field %s", req.Field))
+		return
+	}
+
+	items := make([]datalistItem, 0, len(ReportPeriods))
+	for _, period := range ReportPeriods {
+		items = append(items, datalistItem{Title: period, Value: period})
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
 func (Integration) SyncFilterValidateHandler(w http.ResponseWriter, r *http.Request) {
+	type requestBody struct {
+		Types   []string              `json:"types"`
+		Filter  map[string]any        `json:"filter"`
+		Account QuickBooksAccountInfo `json:"account"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	req := requestBody{}
+	err := decoder.Decode(&req)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, fmt.Errorf("unable to decode request parameters: %w", err))
+		return
+	}
+
+	if _, err := ExchangeRateDays(req.Filter); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	RespondWithJSON(w, http.StatusOK, nil)
 }
 
@@ -445,5 +463,4 @@ func (i *Integration) SyncResourceHandler(w http.ResponseWriter, r *http.Request
 		RespondWithError(w, http.StatusBadRequest, fmt.Errorf("resouce type: %s is not implemented", req.Params.Type))
 		return
 	}
-	RespondWithJSON(w, http.StatusOK, nil)
 }
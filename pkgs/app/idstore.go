@@ -40,6 +40,51 @@ func (s *IdStore) CleanupExpired() {
 	}
 }
 
+type IdCacheInfo struct {
+	RealmId    string    `json:"realmId"`
+	Entries    int       `json:"entries"`
+	Expiration time.Time `json:"expiration"`
+}
+
+func (s *IdStore) Realms() []IdCacheInfo {
+	s.Lock()
+	caches := make(map[string]*IdCache, len(s.idCaches))
+	for realmId, cache := range s.idCaches {
+		caches[realmId] = cache
+	}
+	s.Unlock()
+
+	realms := make([]IdCacheInfo, 0, len(caches))
+	for realmId, cache := range caches {
+		cache.RLock()
+		realms = append(realms, IdCacheInfo{
+			RealmId:    realmId,
+			Entries:    len(cache.ids),
+			Expiration: cache.expiration,
+		})
+		cache.RUnlock()
+	}
+	return realms
+}
+
+func (s *IdStore) Evict(realmId string) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	_, ok := s.idCaches[realmId]
+	delete(s.idCaches, realmId)
+	return ok
+}
+
+// Has reports whether realmId has an unexpired id cache.
+func (s *IdStore) Has(realmId string) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	cache, ok := s.idCaches[realmId]
+	return ok && time.Now().Before(cache.expiration)
+}
+
 func (s *IdStore) GetOrCreateIdCache(realmId string) (*IdCache, bool) {
 	s.Lock()
 	defer s.Unlock()
@@ -0,0 +1,220 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+)
+
+// FieldMapping customises one field of a type for a deployment. Options renames select options,
+// keyed by the option name the app emits.
+type FieldMapping struct {
+	Name    string            `json:"name,omitempty"`
+	Hidden  bool              `json:"hidden,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// TypeMapping customises a type's fields. Currency replaces the realm's home currency in the
+// format of the type's money fields.
+type TypeMapping struct {
+	Currency string                  `json:"currency,omitempty"`
+	Fields   map[string]FieldMapping `json:"fields,omitempty"`
+}
+
+// MappingRegistry maps type ids to the mapping applied to their schema and items.
+type MappingRegistry map[string]TypeMapping
+
+// FieldMappings is applied by every registered type. It is empty unless a mapping file is loaded
+// with UseFieldMappings.
+var FieldMappings = make(MappingRegistry)
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// LoadFieldMappings reads a JSON mapping file keyed by type id.
+func LoadFieldMappings(path string) (MappingRegistry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read field mappings: %w", err)
+	}
+	var mappings MappingRegistry
+	if err := json.Unmarshal(raw, &mappings); err != nil {
+		return nil, fmt.Errorf("unable to decode field mappings: %w", err)
+	}
+	return mappings, nil
+}
+
+// UseFieldMappings loads the mapping file at path, validates it against the registered types
+// and applies it, replacing any mappings applied before. It is not safe to call while syncing.
+func UseFieldMappings(path string) error {
+	mappings, err := LoadFieldMappings(path)
+	if err != nil {
+		return err
+	}
+
+	previous := FieldMappings
+	FieldMappings = make(MappingRegistry)
+	if err := Types.ValidateMappings(mappings); err != nil {
+		FieldMappings = previous
+		return fmt.Errorf("invalid field mappings in %s: %w", path, err)
+	}
+	FieldMappings = mappings
+	return nil
+}
+
+// ValidateMappings checks mappings against the schemas of the registered types, which must not
+// have mappings applied yet. Fields Fibery needs to identify items cannot be hidden and only
+// existing select options can be renamed.
+func (tr TypeRegistry) ValidateMappings(mappings MappingRegistry) error {
+	typeIds := make([]string, 0, len(mappings))
+	for typeId := range mappings {
+		typeIds = append(typeIds, typeId)
+	}
+	sort.Strings(typeIds)
+
+	var problems []string
+	for _, typeId := range typeIds {
+		t, ok := tr[typeId]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: type is not registered", typeId))
+			continue
+		}
+		mapping := mappings[typeId]
+		schema := t.Schema()
+
+		if mapping.Currency != "" && !currencyCode.MatchString(mapping.Currency) {
+			problems = append(problems, fmt.Sprintf("%s: currency %q is not an ISO 4217 code", typeId, mapping.Currency))
+		}
+
+		fieldIds := make([]string, 0, len(mapping.Fields))
+		for fieldId := range mapping.Fields {
+			fieldIds = append(fieldIds, fieldId)
+		}
+		sort.Strings(fieldIds)
+
+		for _, fieldId := range fieldIds {
+			fm := mapping.Fields[fieldId]
+			field, ok := schema[fieldId]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s: field does not exist", typeId, fieldId))
+				continue
+			}
+
+			if fm.Hidden && (fieldId == "id" || fieldId == "__syncAction" || field.SubType == fibery.Title) {
+				problems = append(problems, fmt.Sprintf("%s.%s: field cannot be hidden", typeId, fieldId))
+			}
+
+			if len(fm.Options) == 0 {
+				continue
+			}
+			if field.SubType != fibery.SingleSelect && field.SubType != fibery.MultiSelect {
+				problems = append(problems, fmt.Sprintf("%s.%s: options set on a field that is not a select", typeId, fieldId))
+				continue
+			}
+			names := make(map[string]bool, len(field.Options))
+			for _, option := range field.Options {
+				if name, ok := option["name"].(string); ok {
+					names[name] = true
+				}
+			}
+			optionNames := make([]string, 0, len(fm.Options))
+			for name := range fm.Options {
+				optionNames = append(optionNames, name)
+			}
+			sort.Strings(optionNames)
+			for _, name := range optionNames {
+				if !names[name] {
+					problems = append(problems, fmt.Sprintf("%s.%s: option %q does not exist", typeId, fieldId, name))
+				}
+				if fm.Options[name] == "" {
+					problems = append(problems, fmt.Sprintf("%s.%s: option %q renamed to an empty name", typeId, fieldId, name))
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &SchemaError{Problems: problems}
+	}
+	return nil
+}
+
+// Schema returns a copy of the type's schema with its mapping applied. Hidden fields are left
+// out of the schema, which also drops them from synced items.
+func (mr MappingRegistry) Schema(typeId string, schema map[string]fibery.Field) map[string]fibery.Field {
+	mapping, ok := mr[typeId]
+	if !ok {
+		return schema
+	}
+
+	out := make(map[string]fibery.Field, len(schema))
+	for fieldId, field := range schema {
+		fm, ok := mapping.Fields[fieldId]
+		if !ok {
+			out[fieldId] = field
+			continue
+		}
+		if fm.Hidden {
+			continue
+		}
+		if fm.Name != "" {
+			field.Name = fm.Name
+		}
+		if len(fm.Options) > 0 {
+			options := make([]map[string]any, 0, len(field.Options))
+			for _, option := range field.Options {
+				renamed := make(map[string]any, len(option))
+				for k, v := range option {
+					renamed[k] = v
+				}
+				if name, ok := option["name"].(string); ok && fm.Options[name] != "" {
+					renamed["name"] = fm.Options[name]
+				}
+				options = append(options, renamed)
+			}
+			field.Options = options
+		}
+		out[fieldId] = field
+	}
+	return out
+}
+
+// Item renames the values of the type's select fields in place to match the mapped options.
+func (mr MappingRegistry) Item(typeId string, item map[string]any) map[string]any {
+	mapping, ok := mr[typeId]
+	if !ok {
+		return item
+	}
+	for fieldId, fm := range mapping.Fields {
+		if len(fm.Options) == 0 {
+			continue
+		}
+		switch value := item[fieldId].(type) {
+		case string:
+			if name, ok := fm.Options[value]; ok {
+				item[fieldId] = name
+			}
+		case []string:
+			renamed := make([]string, len(value))
+			for i, v := range value {
+				renamed[i] = v
+				if name, ok := fm.Options[v]; ok {
+					renamed[i] = name
+				}
+			}
+			item[fieldId] = renamed
+		}
+	}
+	return item
+}
+
+// Currency returns the currency the type's money fields are formatted in.
+func (mr MappingRegistry) Currency(typeId, homeCurrency string) string {
+	if currency := mr[typeId].Currency; currency != "" {
+		return currency
+	}
+	return homeCurrency
+}
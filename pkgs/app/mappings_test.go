@@ -0,0 +1,137 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+)
+
+func mappingTestSchema() map[string]fibery.Field {
+	return map[string]fibery.Field{
+		"id":           {Name: "Id", Type: fibery.Id},
+		"__syncAction": {Name: "Sync Action", Type: fibery.Text},
+		"name":         {Name: "Name", Type: fibery.Text, SubType: fibery.Title},
+		"sku":          {Name: "SKU", Type: fibery.Text},
+		"unitPrice":    {Name: "Unit Price", Type: fibery.Number, Format: MoneyFormat()},
+		"type":         {Name: "Type", Type: fibery.Text, SubType: fibery.SingleSelect, Options: SelectOptions("Service", "Inventory")},
+		"tags":         {Name: "Tags", Type: fibery.TextArray, SubType: fibery.MultiSelect, Options: SelectOptions("New", "Sale")},
+	}
+}
+
+func TestMappingRegistryApply(t *testing.T) {
+	t.Parallel()
+	mappings := MappingRegistry{"item": {
+		Currency: "EUR",
+		Fields: map[string]FieldMapping{
+			"sku":       {Hidden: true},
+			"unitPrice": {Name: "Sale Price"},
+			"type":      {Options: map[string]string{"Service": "Labour"}},
+			"tags":      {Options: map[string]string{"Sale": "On Sale"}},
+		},
+	}}
+	registered := mappingTestSchema()
+
+	schema := mappings.Schema("item", registered)
+	if _, ok := schema["sku"]; ok {
+		t.Error("expected hidden fields to be left out of the schema")
+	}
+	if schema["unitPrice"].Name != "Sale Price" || schema["name"].Name != "Name" {
+		t.Errorf("expected only mapped fields to be renamed, got %q and %q", schema["unitPrice"].Name, schema["name"].Name)
+	}
+	if got := schema["type"].Options; got[0]["name"] != "Labour" || got[1]["name"] != "Inventory" {
+		t.Errorf("expected renamed select options, got %v", got)
+	}
+	if registered["type"].Options[0]["name"] != "Service" || registered["unitPrice"].Name != "Unit Price" {
+		t.Error("expected the registered schema to be left unchanged")
+	}
+	if got := mappings.Schema("bill", registered); len(got) != len(registered) {
+		t.Errorf("expected unmapped types to keep their schema, got %v", got)
+	}
+
+	item := mappings.Item("item", map[string]any{"type": "Service", "tags": []string{"New", "Sale"}})
+	if item["type"] != "Labour" || strings.Join(item["tags"].([]string), ",") != "New,On Sale" {
+		t.Errorf("expected renamed select values, got %v", item)
+	}
+	if item := mappings.Item("item", map[string]any{"type": "Inventory"}); item["type"] != "Inventory" {
+		t.Errorf("expected unmapped options to be left alone, got %v", item["type"])
+	}
+
+	if got := mappings.Currency("item", "USD"); got != "EUR" {
+		t.Errorf("expected the mapped currency, got %q", got)
+	}
+	if got := mappings.Currency("bill", "USD"); got != "USD" {
+		t.Errorf("expected the home currency for unmapped types, got %q", got)
+	}
+}
+
+func TestValidateMappings(t *testing.T) {
+	t.Parallel()
+	registry := TypeRegistry{}
+	registry.Register(NewStaticType("item", "Item", mappingTestSchema(), nil))
+
+	for name, tc := range map[string]struct {
+		mappings MappingRegistry
+		want     []string
+	}{
+		"valid": {
+			mappings: MappingRegistry{"item": {Currency: "EUR", Fields: map[string]FieldMapping{
+				"sku":  {Hidden: true},
+				"type": {Name: "Kind", Options: map[string]string{"Service": "Labour"}},
+			}}},
+		},
+		"unknown type and field": {
+			mappings: MappingRegistry{"widget": {}, "item": {Fields: map[string]FieldMapping{"nope": {}}}},
+			want: []string{
+				"item.nope: field does not exist",
+				"widget: type is not registered",
+			},
+		},
+		"identifying fields hidden": {
+			mappings: MappingRegistry{"item": {Fields: map[string]FieldMapping{
+				"id":           {Hidden: true},
+				"__syncAction": {Hidden: true},
+				"name":         {Hidden: true},
+			}}},
+			want: []string{
+				"item.__syncAction: field cannot be hidden",
+				"item.id: field cannot be hidden",
+				"item.name: field cannot be hidden",
+			},
+		},
+		"invalid currency": {
+			mappings: MappingRegistry{"item": {Currency: "eur"}},
+			want:     []string{`item: currency "eur" is not an ISO 4217 code`},
+		},
+		"invalid options": {
+			mappings: MappingRegistry{"item": {Fields: map[string]FieldMapping{
+				"sku":  {Options: map[string]string{"A": "B"}},
+				"type": {Options: map[string]string{"Bundle": "Kit", "Service": ""}},
+			}}},
+			want: []string{
+				"item.sku: options set on a field that is not a select",
+				`item.type: option "Bundle" does not exist`,
+				`item.type: option "Service" renamed to an empty name`,
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := registry.ValidateMappings(tc.mappings)
+			if len(tc.want) == 0 {
+				if err != nil {
+					t.Fatalf("expected valid mappings, got %v", err)
+				}
+				return
+			}
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("expected SchemaError, got %v", err)
+			}
+			if strings.Join(schemaErr.Problems, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("unexpected problems:\n%v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+)
+
+const metricsNamespace = "fibery_qbo"
+
+// Metrics methods are nil-safe so types built outside New (tests, tools) can skip instrumentation.
+type Metrics struct {
+	registry          *prometheus.Registry
+	syncOperations    *prometheus.CounterVec
+	syncItems         *prometheus.CounterVec
+	apiRequests       *prometheus.CounterVec
+	apiDuration       *prometheus.HistogramVec
+	apiFaults         *prometheus.CounterVec
+	rateLimitHits     *prometheus.CounterVec
+	rateLimitWait     prometheus.Histogram
+	webhookEvents     *prometheus.CounterVec
+	activeOperations  prometheus.GaugeFunc
+	idCacheCollector  *idCacheCollector
+	rateLimitRejected prometheus.Counter
+}
+
+type idCacheCollector struct {
+	store *IdStore
+	desc  *prometheus.Desc
+}
+
+func (c *idCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *idCacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, realm := range c.store.Realms() {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(realm.Entries), realm.RealmId)
+	}
+}
+
+func NewMetrics(opManager *OperationManager, idStore *IdStore) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		syncOperations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "sync_operations_total",
+			Help:      "Sync requests started per Fibery type and synchronization mode.",
+		}, []string{"type", "mode"}),
+		syncItems: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "sync_items_total",
+			Help:      "Items returned to Fibery per type.",
+		}, []string{"type"}),
+		apiRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "quickbooks_requests_total",
+			Help:      "QuickBooks API calls per method and entity.",
+		}, []string{"method", "entity"}),
+		apiDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "quickbooks_request_duration_seconds",
+			Help:      "QuickBooks API call latency per method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		apiFaults: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "quickbooks_faults_total",
+			Help:      "QuickBooks API errors and batch item faults per method and entity.",
+		}, []string{"method", "entity"}),
+		rateLimitHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "quickbooks_rate_limit_hits_total",
+			Help:      "QuickBooks 429 responses per method.",
+		}, []string{"method"}),
+		rateLimitRejected: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "rate_limit_rejected_total",
+			Help:      "Requests rejected by the per-realm governor because the wait exceeded the limit. Per-realm counts are served by /admin/ratelimits.",
+		}),
+		rateLimitWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "rate_limit_wait_seconds",
+			Help:      "Time spent waiting for a per-realm QuickBooks request slot.",
+			Buckets:   []float64{0.001, 0.01, 0.1, 0.5, 1, 2.5, 5, 10, 30},
+		}),
+		webhookEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "webhook_events_total",
+			Help:      "QuickBooks webhook entity events per entity, operation and outcome.",
+		}, []string{"entity", "operation", "status"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.syncOperations,
+		m.syncItems,
+		m.apiRequests,
+		m.apiDuration,
+		m.apiFaults,
+		m.rateLimitHits,
+		m.rateLimitRejected,
+		m.rateLimitWait,
+		m.webhookEvents,
+	)
+
+	if opManager != nil {
+		m.activeOperations = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "active_operations",
+			Help:      "Sync operations currently held by the OperationManager.",
+		}, func() float64 {
+			return float64(opManager.Len())
+		})
+		m.registry.MustRegister(m.activeOperations)
+	}
+
+	if idStore != nil {
+		m.idCacheCollector = &idCacheCollector{
+			store: idStore,
+			desc: prometheus.NewDesc(
+				prometheus.BuildFQName(metricsNamespace, "", "id_cache_entries"),
+				"Source entries held in the IdStore cache per realm.",
+				[]string{"realm"}, nil,
+			),
+		}
+		m.registry.MustRegister(m.idCacheCollector)
+	}
+
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) SyncResponse(typeId string, page int, resp fibery.DataHandlerResponse) {
+	if m == nil {
+		return
+	}
+	if page <= 1 {
+		m.syncOperations.WithLabelValues(typeId, string(resp.SynchronizationType)).Inc()
+	}
+	m.syncItems.WithLabelValues(typeId).Add(float64(len(resp.Items)))
+}
+
+func (m *Metrics) QuickBooksRequest(method, entity string) {
+	if m == nil {
+		return
+	}
+	m.apiRequests.WithLabelValues(method, entity).Inc()
+}
+
+func (m *Metrics) QuickBooksDuration(method string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.apiDuration.WithLabelValues(method).Observe(d.Seconds())
+}
+
+func (m *Metrics) QuickBooksFault(method, entity string) {
+	if m == nil {
+		return
+	}
+	m.apiFaults.WithLabelValues(method, entity).Inc()
+}
+
+func (m *Metrics) RateLimitHit(method string) {
+	if m == nil {
+		return
+	}
+	m.rateLimitHits.WithLabelValues(method).Inc()
+}
+
+func (m *Metrics) RateLimitWait(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.rateLimitWait.Observe(d.Seconds())
+}
+
+func (m *Metrics) RateLimitRejected() {
+	if m == nil {
+		return
+	}
+	m.rateLimitRejected.Inc()
+}
+
+func (m *Metrics) WebhookEvent(entity, operation string, processed bool) {
+	if m == nil {
+		return
+	}
+	status := "dropped"
+	if processed {
+		status = "processed"
+	}
+	m.webhookEvents.WithLabelValues(entity, operation, status).Inc()
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	t.Parallel()
+	idStore := NewIdStore(time.Minute)
+	idCache, _ := idStore.GetOrCreateIdCache("realm")
+	idCache.AddId(IdKey{EntityType: "Bill", EntityId: "1"}, "billItemLine", "1-1")
+
+	m := NewMetrics(NewOperationManager(time.Minute), idStore)
+	m.SyncResponse("bill", 1, fibery.DataHandlerResponse{
+		Items:               []map[string]any{{"id": "1"}, {"id": "2"}},
+		SynchronizationType: fibery.Full,
+	})
+	m.WebhookEvent("Bill", "Update", true)
+	m.WebhookEvent("Budget", "Create", false)
+	m.RateLimitRejected()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	for _, want := range []string{
+		`fibery_qbo_sync_operations_total{mode="full",type="bill"} 1`,
+		`fibery_qbo_sync_items_total{type="bill"} 2`,
+		`fibery_qbo_webhook_events_total{entity="Bill",operation="Update",status="processed"} 1`,
+		`fibery_qbo_webhook_events_total{entity="Budget",operation="Create",status="dropped"} 1`,
+		`fibery_qbo_rate_limit_rejected_total 1`,
+		`fibery_qbo_active_operations 0`,
+		`fibery_qbo_id_cache_entries{realm="realm"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+}
+
+func TestMetricsNilSafe(t *testing.T) {
+	t.Parallel()
+	var m *Metrics
+	m.SyncResponse("bill", 1, fibery.DataHandlerResponse{})
+	m.QuickBooksRequest("batch", "Bill")
+	m.QuickBooksDuration("batch", time.Second)
+	m.QuickBooksFault("batch", "Bill")
+	m.RateLimitHit("batch")
+	m.RateLimitWait(time.Second)
+	m.RateLimitRejected()
+	m.WebhookEvent("Bill", "Update", true)
+}
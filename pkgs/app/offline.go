@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+)
+
+var (
+	ErrOperationDone  = errors.New("operation cancelled or timed out")
+	ErrInvalidWebhook = errors.New("invalid webhook request")
+)
+
+// Schemas returns the Fibery schema of every registered type.
+func (tr TypeRegistry) Schemas() map[string]map[string]fibery.Field {
+	schemas := make(map[string]map[string]fibery.Field, len(tr))
+	for typeId, t := range tr {
+		schemas[typeId] = t.Schema()
+	}
+	return schemas
+}
+
+// TypeIds returns the ids of every registered type in sorted order.
+func (tr TypeRegistry) TypeIds() []string {
+	typeIds := make([]string, 0, len(tr))
+	for typeId := range tr {
+		typeIds = append(typeIds, typeId)
+	}
+	sort.Strings(typeIds)
+	return typeIds
+}
+
+// SyncPage submits one page request to its operation and waits for the page to be dispatched.
+func (i *Integration) SyncPage(ctx context.Context, req SyncRequest) (fibery.DataHandlerResponse, error) {
+	op, err := i.opManager.GetOrAddOperation(ctx, req, i)
+	if err != nil {
+		return fibery.DataHandlerResponse{}, fmt.Errorf("issue getting/creating operation: %w", err)
+	}
+
+	err = op.SubmitRequest(ctx, req)
+	if err != nil {
+		return fibery.DataHandlerResponse{}, err
+	}
+
+	key := ResponseChannelKey(req.RequestedType, req.Pagination.Page)
+
+	ch, err := op.GetChannel(key)
+	if err != nil {
+		return fibery.DataHandlerResponse{}, err
+	}
+
+	select {
+	case resp, ok := <-ch:
+		op.ReleaseChannel(key)
+		if !ok {
+			return fibery.DataHandlerResponse{}, fmt.Errorf("channel prematurely closed for key: %s", key)
+		}
+		if resp.Error != nil {
+			return fibery.DataHandlerResponse{}, resp.Error
+		}
+		resp.Items = schemaItems(resp.Items, req.Schema[req.RequestedType])
+		return resp.DataHandlerResponse, nil
+	case <-op.ctx.Done():
+		return fibery.DataHandlerResponse{}, fmt.Errorf("operation %s: %w", req.OperationId, ErrOperationDone)
+	case <-ctx.Done():
+		// the page stays registered for Fibery's retry of the request
+		return fibery.DataHandlerResponse{}, ctx.Err()
+	}
+}
+
+// SyncAll runs a whole synchronization of req.Types outside of Fibery, requesting the pages of
+// every type concurrently under one operation the way Fibery does. Each page's items are
+// passed to write, which is never called concurrently. A delta sync of a realm without an id
+// cache seeds the cache with a full sync first, so only changed and removed items are written.
+func (i *Integration) SyncAll(ctx context.Context, req SyncRequest, write func(typeId string, items []map[string]any) error) error {
+	if req.OperationId == "" {
+		req.OperationId = fmt.Sprintf("offline-%d", time.Now().UnixNano())
+	}
+	if req.Schema == nil {
+		prefs, err := i.Preferences(ctx, req.Account)
+		if err != nil {
+			return err
+		}
+		req.Schema = make(map[string]map[string]fibery.Field, len(req.Types))
+		for _, typeId := range req.Types {
+			schema, err := i.RealmSchema(typeId, prefs)
+			if err != nil {
+				return err
+			}
+			req.Schema[typeId] = schema
+		}
+	}
+
+	if !req.LastSyncronizedAt.IsZero() && !i.idStore.Has(req.Account.RealmId) {
+		// change data capture only runs against a filled id cache, so a cold realm is synced in full
+		// first and those items are discarded
+		seed := req
+		seed.OperationId = req.OperationId + "-seed"
+		seed.LastSyncronizedAt = time.Time{}
+		err := i.SyncAll(ctx, seed, func(string, []map[string]any) error { return nil })
+		if err != nil {
+			return fmt.Errorf("unable to seed id cache: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs = make([]error, len(req.Types))
+	)
+	for idx, typeId := range req.Types {
+		wg.Add(1)
+		go func(idx int, typeId string) {
+			defer wg.Done()
+			typeReq := req
+			typeReq.RequestedType = typeId
+			for page := 1; ; page++ {
+				typeReq.Pagination = fibery.NextPageConfig{Page: page}
+				resp, err := i.SyncPage(ctx, typeReq)
+				if err != nil {
+					errs[idx] = fmt.Errorf("%s page %d: %w", typeId, page, err)
+					cancel()
+					return
+				}
+
+				mu.Lock()
+				err = write(typeId, resp.Items)
+				mu.Unlock()
+				if err != nil {
+					errs[idx] = fmt.Errorf("unable to write %s page %d: %w", typeId, page, err)
+					cancel()
+					return
+				}
+
+				if !resp.Pagination.HasNext {
+					return
+				}
+			}
+		}(idx, typeId)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// TransformWebhook fetches and transforms the entities referenced by a QuickBooks webhook.
+func (i *Integration) TransformWebhook(ctx context.Context, req WebhookRequest) (fibery.WebhookTransformResponse, error) {
+	wg, err := buildWebhookGroup(req, i, time.Duration(5*time.Second))
+	if err != nil {
+		return fibery.WebhookTransformResponse{}, fmt.Errorf("%w: error building webhookGroup: %w", ErrInvalidWebhook, err)
+	}
+
+	err = wg.fetchAll(ctx)
+	if err != nil {
+		return fibery.WebhookTransformResponse{}, fmt.Errorf("error fetching webhook data: %w", err)
+	}
+
+	items, err := wg.process(ctx)
+	if err != nil {
+		return fibery.WebhookTransformResponse{}, fmt.Errorf("error processing webhookGroup data: %w", err)
+	}
+
+	prefs, err := i.Preferences(ctx, req.Account)
+	if err != nil {
+		return fibery.WebhookTransformResponse{}, err
+	}
+	for typeId, typeItems := range items {
+		schema, err := i.RealmSchema(typeId, prefs)
+		if err != nil {
+			return fibery.WebhookTransformResponse{}, err
+		}
+		items[typeId] = schemaItems(typeItems, schema)
+	}
+
+	return fibery.WebhookTransformResponse{Data: items}, nil
+}
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+// Feature is a QuickBooks company setting that decides whether optional fields are emitted.
+type Feature string
+
+const (
+	MultiCurrency     Feature = "multiCurrency"
+	ClassTracking     Feature = "classTracking"
+	InventoryTracking Feature = "inventoryTracking"
+	LocationTracking  Feature = "locationTracking"
+)
+
+func (f Feature) Enabled(prefs *quickbooks.Preferences) bool {
+	switch f {
+	case MultiCurrency:
+		return prefs.CurrencyPrefs.MultiCurrencyEnabled
+	case ClassTracking:
+		return prefs.AccountingInfoPrefs.ClassTrackingPerTxn || prefs.AccountingInfoPrefs.ClassTrackingPerTxnLine
+	case InventoryTracking:
+		return prefs.ProductAndServicesPrefs.QuantityOnHand
+	case LocationTracking:
+		return prefs.AccountingInfoPrefs.TrackDepartments
+	default:
+		return true
+	}
+}
+
+// FeatureRegistry maps type ids to the fields that are only emitted when a feature is enabled.
+type FeatureRegistry map[string]map[string]Feature
+
+func (fr FeatureRegistry) Require(feature Feature, typeId string, fieldIds ...string) {
+	fields, ok := fr[typeId]
+	if !ok {
+		fields = make(map[string]Feature, len(fieldIds))
+		fr[typeId] = fields
+	}
+	for _, fieldId := range fieldIds {
+		fields[fieldId] = feature
+	}
+}
+
+// Filter returns a copy of the type's schema without the fields of disabled features.
+func (fr FeatureRegistry) Filter(typeId string, schema map[string]fibery.Field, prefs *quickbooks.Preferences) map[string]fibery.Field {
+	out := make(map[string]fibery.Field, len(schema))
+	for fieldId, field := range schema {
+		if feature, ok := fr[typeId][fieldId]; ok && !feature.Enabled(prefs) {
+			continue
+		}
+		out[fieldId] = field
+	}
+	return out
+}
+
+var FeatureFields = make(FeatureRegistry)
+
+// FeatureTypeRegistry maps type ids to the feature the whole type depends on, such as exchange
+// rates on multi-currency. QuickBooks faults queries of a disabled feature's entities, so these
+// types sync no items on realms without it.
+type FeatureTypeRegistry map[string]Feature
+
+func (fr FeatureTypeRegistry) Require(feature Feature, typeIds ...string) {
+	for _, typeId := range typeIds {
+		fr[typeId] = feature
+	}
+}
+
+// Enabled reports whether the type's feature is turned on, which types without one always are.
+func (fr FeatureTypeRegistry) Enabled(typeId string, prefs *quickbooks.Preferences) bool {
+	feature, ok := fr[typeId]
+	return !ok || feature.Enabled(prefs)
+}
+
+var FeatureTypes = make(FeatureTypeRegistry)
+
+// preferencesStore caches each realm's company preferences for the id cache ttl.
+type preferencesStore struct {
+	sync.Mutex
+	prefs map[string]cachedPreferences
+	ttl   time.Duration
+}
+
+type cachedPreferences struct {
+	prefs      *quickbooks.Preferences
+	expiration time.Time
+}
+
+func newPreferencesStore(ttl time.Duration) *preferencesStore {
+	return &preferencesStore{
+		prefs: make(map[string]cachedPreferences),
+		ttl:   ttl,
+	}
+}
+
+func (s *preferencesStore) get(realmId string) (*quickbooks.Preferences, bool) {
+	s.Lock()
+	defer s.Unlock()
+	cached, ok := s.prefs[realmId]
+	if !ok || time.Now().After(cached.expiration) {
+		return nil, false
+	}
+	return cached.prefs, true
+}
+
+// last returns the realm's most recently fetched preferences, even once they have expired.
+func (s *preferencesStore) last(realmId string) (*quickbooks.Preferences, bool) {
+	s.Lock()
+	defer s.Unlock()
+	cached, ok := s.prefs[realmId]
+	return cached.prefs, ok
+}
+
+func (s *preferencesStore) set(realmId string, prefs *quickbooks.Preferences) {
+	s.Lock()
+	defer s.Unlock()
+	s.prefs[realmId] = cachedPreferences{prefs: prefs, expiration: time.Now().Add(s.ttl)}
+}
+
+// Preferences returns the realm's company preferences, fetching them when they are not cached.
+func (i *Integration) Preferences(ctx context.Context, account QuickBooksAccountInfo) (*quickbooks.Preferences, error) {
+	if prefs, ok := i.preferences.get(account.RealmId); ok {
+		return prefs, nil
+	}
+
+	prefs, err := i.client.FindPreferences(quickbooks.RequestParameters{
+		Ctx:     ctx,
+		RealmId: account.RealmId,
+		Token:   &account.BearerToken,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get company preferences: %w", err)
+	}
+
+	i.preferences.set(account.RealmId, prefs)
+	return prefs, nil
+}
+
+// HomeCurrency returns the currency the realm keeps its books in.
+func HomeCurrency(prefs *quickbooks.Preferences) string {
+	if prefs.CurrencyPrefs.HomeCurrency.Value == "" {
+		return DefaultCurrency
+	}
+	return prefs.CurrencyPrefs.HomeCurrency.Value
+}
+
+// RealmSchema is a type's schema as emitted for a realm: fields of disabled features are left
+// out and money fields are formatted in the home currency, or the type's mapped currency.
+// Foreign amounts are only formatted so on realms without multi-currency, where every amount is
+// in the home currency. Without preferences every field is emitted, as if all features were on.
+func (i *Integration) RealmSchema(typeId string, prefs *quickbooks.Preferences) (map[string]fibery.Field, error) {
+	t, ok := i.types.Get(typeId)
+	if !ok {
+		return nil, fmt.Errorf("type %s not found in registered types", typeId)
+	}
+	if prefs == nil {
+		return WithCurrency(t.Schema(), FieldMappings.Currency(typeId, DefaultCurrency), ForeignAmounts[typeId]), nil
+	}
+	var foreign map[string]struct{}
+	if MultiCurrency.Enabled(prefs) {
+		foreign = ForeignAmounts[typeId]
+	}
+	return WithCurrency(FeatureFields.Filter(typeId, t.Schema(), prefs), FieldMappings.Currency(typeId, HomeCurrency(prefs)), foreign), nil
+}
+
+// schemaItems drops item fields that are not part of the schema Fibery was given.
+func schemaItems(items []map[string]any, schema map[string]fibery.Field) []map[string]any {
+	if schema == nil {
+		return items
+	}
+	for _, item := range items {
+		for fieldId := range item {
+			if _, ok := schema[fieldId]; !ok {
+				delete(item, fieldId)
+			}
+		}
+	}
+	return items
+}
@@ -0,0 +1,403 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/tommyhedley/quickbooks-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+type RateLimitWaitError struct {
+	RealmId string
+	Wait    time.Duration
+	MaxWait time.Duration
+}
+
+func (e *RateLimitWaitError) Error() string {
+	return fmt.Sprintf("realm %s would wait %v for a quickbooks request slot, exceeding the %v limit", e.RealmId, e.Wait.Round(time.Millisecond), e.MaxWait)
+}
+
+// waitOutRejections repeats call for as long as the RateGovernor rejects it, waiting out each
+// rejection. Operations fetch in the background rather than within a Fibery request, so a
+// rejection there must not fail the pages Fibery is waiting on.
+func waitOutRejections(ctx context.Context, call func() error) error {
+	for {
+		err := call()
+		var waitErr *RateLimitWaitError
+		if !errors.As(err, &waitErr) {
+			return err
+		}
+
+		timer := time.NewTimer(waitErr.Wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+type RealmLimitStats struct {
+	RealmId   string
+	Requests  int64
+	Waits     int64
+	Rejected  int64
+	InFlight  int
+	TotalWait time.Duration
+	MaxWait   time.Duration
+	LastUsed  time.Time
+}
+
+type realmLimiter struct {
+	limiter *rate.Limiter
+	sem     chan struct{}
+	stats   RealmLimitStats
+}
+
+type RateGovernor struct {
+	sync.Mutex
+	realms      map[string]*realmLimiter
+	limit       rate.Limit
+	burst       int
+	concurrency int
+	maxWait     time.Duration
+	idleTTL     time.Duration
+	metrics     *Metrics
+}
+
+func NewRateGovernor(requestsPerMinute, concurrency int, maxWait, idleTTL time.Duration) *RateGovernor {
+	return &RateGovernor{
+		realms:      make(map[string]*realmLimiter),
+		limit:       rate.Limit(float64(requestsPerMinute) / 60),
+		burst:       concurrency,
+		concurrency: concurrency,
+		maxWait:     maxWait,
+		idleTTL:     idleTTL,
+	}
+}
+
+func (g *RateGovernor) realm(realmId string) *realmLimiter {
+	g.Lock()
+	defer g.Unlock()
+
+	rl, ok := g.realms[realmId]
+	if !ok {
+		rl = &realmLimiter{
+			limiter: rate.NewLimiter(g.limit, g.burst),
+			sem:     make(chan struct{}, g.concurrency),
+			stats:   RealmLimitStats{RealmId: realmId},
+		}
+		g.realms[realmId] = rl
+	}
+	rl.stats.LastUsed = time.Now()
+
+	return rl
+}
+
+func (g *RateGovernor) record(rl *realmLimiter, wait time.Duration, rejected bool) {
+	g.Lock()
+	defer g.Unlock()
+
+	rl.stats.Requests++
+	if rejected {
+		rl.stats.Rejected++
+		g.metrics.RateLimitRejected()
+		return
+	}
+
+	g.metrics.RateLimitWait(wait)
+
+	if wait > 0 {
+		rl.stats.Waits++
+		rl.stats.TotalWait += wait
+		if wait > rl.stats.MaxWait {
+			rl.stats.MaxWait = wait
+		}
+	}
+	rl.stats.InFlight++
+}
+
+func (g *RateGovernor) release(rl *realmLimiter) {
+	g.Lock()
+	rl.stats.InFlight--
+	rl.stats.LastUsed = time.Now()
+	g.Unlock()
+
+	<-rl.sem
+}
+
+// Acquire blocks until the realm has both a free concurrency slot and a rate token.
+// If either would take longer than maxWait, it fails fast with a RateLimitWaitError
+// so the caller can ask Fibery to retry instead of running past its request timeout.
+func (g *RateGovernor) Acquire(ctx context.Context, realmId string) (func(), error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	rl := g.realm(realmId)
+	start := time.Now()
+
+	var deadline <-chan time.Time
+	if g.maxWait > 0 {
+		timer := time.NewTimer(g.maxWait)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	select {
+	case rl.sem <- struct{}{}:
+	case <-deadline:
+		g.record(rl, 0, true)
+		return nil, &RateLimitWaitError{RealmId: realmId, Wait: time.Since(start), MaxWait: g.maxWait}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	reservation := rl.limiter.Reserve()
+	if !reservation.OK() {
+		<-rl.sem
+		g.record(rl, 0, true)
+		return nil, &RateLimitWaitError{RealmId: realmId, Wait: g.maxWait, MaxWait: g.maxWait}
+	}
+
+	delay := reservation.Delay()
+	if g.maxWait > 0 && time.Since(start)+delay > g.maxWait {
+		reservation.Cancel()
+		<-rl.sem
+		g.record(rl, 0, true)
+		return nil, &RateLimitWaitError{RealmId: realmId, Wait: time.Since(start) + delay, MaxWait: g.maxWait}
+	}
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			reservation.Cancel()
+			<-rl.sem
+			return nil, ctx.Err()
+		}
+	}
+
+	g.record(rl, time.Since(start), false)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { g.release(rl) })
+	}, nil
+}
+
+func (g *RateGovernor) Stats() []RealmLimitStats {
+	g.Lock()
+	defer g.Unlock()
+
+	stats := make([]RealmLimitStats, 0, len(g.realms))
+	for _, rl := range g.realms {
+		stats = append(stats, rl.stats)
+	}
+	return stats
+}
+
+func (g *RateGovernor) CleanupIdle() {
+	g.Lock()
+	defer g.Unlock()
+
+	now := time.Now()
+	for realmId, rl := range g.realms {
+		if rl.stats.InFlight == 0 && now.Sub(rl.stats.LastUsed) > g.idleTTL {
+			delete(g.realms, realmId)
+		}
+	}
+}
+
+// Client wraps every realm-scoped quickbooks.Client call with the RateGovernor and records metrics.
+// The quickbooks.Client is not embedded, so a call without a wrapper here does not compile.
+type Client struct {
+	qb       *quickbooks.Client
+	governor *RateGovernor
+	metrics  *Metrics
+}
+
+func NewClient(client *quickbooks.Client, governor *RateGovernor, metrics *Metrics) *Client {
+	return &Client{
+		qb:       client,
+		governor: governor,
+		metrics:  metrics,
+	}
+}
+
+// OAuth calls are not realm-scoped and pass straight through.
+
+func (c *Client) FindAuthorizationUrl(scope, state, redirectUri string) (string, error) {
+	return c.qb.FindAuthorizationUrl(scope, state, redirectUri)
+}
+
+func (c *Client) RetrieveBearerToken(authorizationCode, redirectUri string) (*quickbooks.BearerToken, error) {
+	return c.qb.RetrieveBearerToken(authorizationCode, redirectUri)
+}
+
+func (c *Client) RefreshToken(refreshToken string) (*quickbooks.BearerToken, error) {
+	return c.qb.RefreshToken(refreshToken)
+}
+
+// startSpan covers the governor wait and the call itself, so time spent queued for a slot shows up in traces.
+func (c *Client) startSpan(params *quickbooks.RequestParameters, method string, attrs ...attribute.KeyValue) trace.Span {
+	attrs = append(attrs, attrRealmId.String(params.RealmId))
+	ctx, span := startSpan(params.Ctx, "quickbooks."+method, trace.SpanKindInternal, attrs...)
+	params.Ctx = ctx
+	return span
+}
+
+func (c *Client) observe(method string, start time.Time, err error) {
+	c.metrics.QuickBooksDuration(method, time.Since(start))
+	if err == nil {
+		return
+	}
+
+	var rateLimitErr *quickbooks.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		c.metrics.RateLimitHit(method)
+	}
+	c.metrics.QuickBooksFault(method, "")
+}
+
+func (c *Client) BatchRequest(params quickbooks.RequestParameters, req []quickbooks.BatchItemRequest) (resp []quickbooks.BatchItemResponse, err error) {
+	span := c.startSpan(&params, "batch", attribute.Int("qbo.batch_items", len(req)))
+	defer func() { endSpan(span, err) }()
+
+	release, err := c.governor.Acquire(params.Ctx, params.RealmId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	for _, item := range req {
+		entityType, _, _, err := DecodeQueryBID(item.BID)
+		if err != nil {
+			entityType = "unknown"
+		}
+		c.metrics.QuickBooksRequest("batch", entityType)
+	}
+
+	start := time.Now()
+	resp, err = c.qb.BatchRequest(params, req)
+	c.observe("batch", start, err)
+
+	for _, item := range resp {
+		if len(item.Fault.Faults) > 0 {
+			entityType, _, _, err := DecodeQueryBID(item.BID)
+			if err != nil {
+				entityType = "unknown"
+			}
+			c.metrics.QuickBooksFault("batch", entityType)
+		}
+	}
+
+	return resp, err
+}
+
+func (c *Client) ChangeDataCapture(params quickbooks.RequestParameters, entities []string, changedSince time.Time) (resp quickbooks.ChangeDataCapture, err error) {
+	span := c.startSpan(&params, "cdc", attribute.StringSlice("qbo.entities", entities))
+	defer func() { endSpan(span, err) }()
+
+	release, err := c.governor.Acquire(params.Ctx, params.RealmId)
+	if err != nil {
+		return quickbooks.ChangeDataCapture{}, err
+	}
+	defer release()
+
+	for _, entity := range entities {
+		c.metrics.QuickBooksRequest("cdc", entity)
+	}
+
+	start := time.Now()
+	resp, err = c.qb.ChangeDataCapture(params, entities, changedSince)
+	c.observe("cdc", start, err)
+
+	return resp, err
+}
+
+func (c *Client) FindCompanyInfo(params quickbooks.RequestParameters) (info *quickbooks.CompanyInfo, err error) {
+	span := c.startSpan(&params, "read", attribute.String("qbo.entity", "CompanyInfo"))
+	defer func() { endSpan(span, err) }()
+
+	release, err := c.governor.Acquire(params.Ctx, params.RealmId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	c.metrics.QuickBooksRequest("read", "CompanyInfo")
+
+	start := time.Now()
+	info, err = c.qb.FindCompanyInfo(params)
+	c.observe("read", start, err)
+
+	return info, err
+}
+
+func (c *Client) FindPreferences(params quickbooks.RequestParameters) (prefs *quickbooks.Preferences, err error) {
+	span := c.startSpan(&params, "read", attribute.String("qbo.entity", "Preferences"))
+	defer func() { endSpan(span, err) }()
+
+	release, err := c.governor.Acquire(params.Ctx, params.RealmId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	c.metrics.QuickBooksRequest("read", "Preferences")
+
+	start := time.Now()
+	prefs, err = c.qb.FindPreferences(params)
+	c.observe("read", start, err)
+
+	return prefs, err
+}
+
+func (c *Client) FindReport(params quickbooks.RequestParameters, name string, query url.Values) (report *quickbooks.Report, err error) {
+	span := c.startSpan(&params, "report", attribute.String("qbo.entity", name))
+	defer func() { endSpan(span, err) }()
+
+	release, err := c.governor.Acquire(params.Ctx, params.RealmId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	c.metrics.QuickBooksRequest("report", name)
+
+	start := time.Now()
+	report, err = c.qb.FindReport(params, name, query)
+	c.observe("report", start, err)
+
+	return report, err
+}
+
+func (c *Client) GetAttachableDownloadURL(params quickbooks.RequestParameters, id string) (downloadURL *url.URL, err error) {
+	span := c.startSpan(&params, "download", attribute.String("qbo.entity", "Attachable"))
+	defer func() { endSpan(span, err) }()
+
+	release, err := c.governor.Acquire(params.Ctx, params.RealmId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	c.metrics.QuickBooksRequest("download", "Attachable")
+
+	start := time.Now()
+	downloadURL, err = c.qb.GetAttachableDownloadURL(params, id)
+	c.observe("download", start, err)
+
+	return downloadURL, err
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateGovernorRejectsLongWait(t *testing.T) {
+	t.Parallel()
+	// 1 request per minute with a burst of 1: the second request would wait ~60s
+	g := NewRateGovernor(1, 1, 50*time.Millisecond, time.Minute)
+
+	release, err := g.Acquire(context.Background(), "realm")
+	if err != nil {
+		t.Fatalf("unexpected error on first acquire: %v", err)
+	}
+	release()
+
+	_, err = g.Acquire(context.Background(), "realm")
+	var waitErr *RateLimitWaitError
+	if !errors.As(err, &waitErr) {
+		t.Fatalf("expected RateLimitWaitError, got %v", err)
+	}
+
+	if _, err := g.Acquire(context.Background(), "other"); err != nil {
+		t.Errorf("expected separate realm to be unaffected, got %v", err)
+	}
+
+	for _, s := range g.Stats() {
+		if s.RealmId == "realm" && s.Rejected != 1 {
+			t.Errorf("expected 1 rejected request for realm, got %d", s.Rejected)
+		}
+	}
+}
+
+func TestRateGovernorConcurrencyLimit(t *testing.T) {
+	t.Parallel()
+	g := NewRateGovernor(500, 2, 200*time.Millisecond, time.Minute)
+
+	first, err := g.Acquire(context.Background(), "realm")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := g.Acquire(context.Background(), "realm")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var waitErr *RateLimitWaitError
+	if _, err := g.Acquire(context.Background(), "realm"); !errors.As(err, &waitErr) {
+		t.Fatalf("expected RateLimitWaitError while slots are full, got %v", err)
+	}
+
+	first()
+	first()
+	second()
+
+	release, err := g.Acquire(context.Background(), "realm")
+	if err != nil {
+		t.Fatalf("expected slot after release, got %v", err)
+	}
+	release()
+}
+
+func TestRateGovernorContextCancel(t *testing.T) {
+	t.Parallel()
+	g := NewRateGovernor(1, 1, time.Minute, time.Minute)
+
+	release, err := g.Acquire(context.Background(), "realm")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := g.Acquire(ctx, "realm"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context deadline error, got %v", err)
+	}
+}
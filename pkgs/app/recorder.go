@@ -0,0 +1,530 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+const redacted = "REDACTED"
+
+// redactedKeys are removed from recorded form values, JSON bodies and query strings.
+var redactedKeys = map[string]struct{}{
+	"access_token":  {},
+	"refresh_token": {},
+	"id_token":      {},
+	"code":          {},
+	"client_secret": {},
+}
+
+// DefaultPIIKeys are the QuickBooks response fields holding personal or company details. Every
+// string within them is hashed in recorded responses. A dotted key such as "VendorRef.name"
+// only covers that child, leaving the reference's id intact. Report rows are the exception:
+// "ColData" only hashes the row label in the first column, such as a customer name on an aging
+// report, and keeps the amounts.
+var DefaultPIIKeys = []string{
+	"DisplayName", "GivenName", "MiddleName", "FamilyName", "FullyQualifiedName", "Title", "Suffix",
+	"CompanyName", "LegalName", "PrintOnCheckName", "Notes", "AcctNum", "TaxIdentifier", "SSN", "BirthDate",
+	"PrimaryEmailAddr", "Email", "PrimaryPhone", "AlternatePhone", "Mobile", "Fax", "WebAddr",
+	"BillAddr", "ShipAddr", "OtherAddr", "PrimaryAddr", "CompanyAddr", "CustomerCommunicationAddr", "LegalAddr",
+	"PrivateNote", "Line.Description", "TempDownloadUri",
+	"CustomerRef.name", "VendorRef.name", "EntityRef.name", "EmployeeRef.name", "ParentRef.name",
+	reportColumnsKey,
+}
+
+// reportColumnsKey holds the columns of a report row, led by the row label.
+const reportColumnsKey = "ColData"
+
+// changingQueryKeys are left out of recorded query strings as they differ on every run, such as
+// the change data capture window.
+var changingQueryKeys = []string{"changedSince"}
+
+var realmPath = regexp.MustCompile(`/v3/company/([^/]+)/`)
+
+// Interaction is one recorded QuickBooks request and its response. Headers are not recorded
+// so that bearer tokens and basic auth credentials never reach the fixture files.
+type Interaction struct {
+	Method       string    `json:"method"`
+	Host         string    `json:"host"`
+	Path         string    `json:"path"`
+	Query        string    `json:"query,omitempty"`
+	RequestBody  string    `json:"requestBody,omitempty"`
+	Status       int       `json:"status"`
+	ContentType  string    `json:"contentType,omitempty"`
+	ResponseBody string    `json:"responseBody"`
+	RecordedAt   time.Time `json:"recordedAt"`
+}
+
+func (i Interaction) key() string {
+	return i.Method + " " + i.Host + i.Path + "?" + i.Query + "\n" + i.RequestBody
+}
+
+func isBatch(path string) bool {
+	return strings.HasSuffix(path, "/batch")
+}
+
+// recordingFile returns the fixture file for a request, one per realm.
+func recordingFile(dir, path string) string {
+	name := "global"
+	if m := realmPath.FindStringSubmatch(path); m != nil {
+		name = m[1]
+	}
+	return filepath.Join(dir, name+".jsonl")
+}
+
+func redactValues(values url.Values) {
+	for key := range values {
+		if _, ok := redactedKeys[key]; ok {
+			values[key] = []string{redacted}
+		}
+	}
+}
+
+// canonicalQuery redacts secrets, drops values that change between runs and sorts the comma
+// separated CDC entity list, which the app builds from map iteration and so varies between runs.
+func canonicalQuery(raw string) string {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return raw
+	}
+	redactValues(values)
+	for _, key := range changingQueryKeys {
+		values.Del(key)
+	}
+	if entities := values.Get("entities"); entities != "" {
+		parts := strings.Split(entities, ",")
+		sort.Strings(parts)
+		values.Set("entities", strings.Join(parts, ","))
+	}
+	return values.Encode()
+}
+
+// piiKeys splits PII keys into those hashed wherever they appear and dotted keys that only
+// apply below their parent.
+type piiKeys struct {
+	keys   map[string]struct{}
+	nested map[string][]string
+}
+
+func newPIIKeys(keys []string) piiKeys {
+	pii := piiKeys{keys: make(map[string]struct{}), nested: make(map[string][]string)}
+	for _, key := range keys {
+		if parent, child, ok := strings.Cut(key, "."); ok {
+			pii.nested[parent] = append(pii.nested[parent], child)
+			continue
+		}
+		pii.keys[key] = struct{}{}
+	}
+	return pii
+}
+
+// below returns the keys that apply to the children of key.
+func (p piiKeys) below(key string) piiKeys {
+	children, ok := p.nested[key]
+	if !ok {
+		return p
+	}
+	below := newPIIKeys(children)
+	for k := range p.keys {
+		below.keys[k] = struct{}{}
+	}
+	for k, v := range p.nested {
+		below.nested[k] = append(below.nested[k], v...)
+	}
+	return below
+}
+
+// hashValue stands in for a recorded value. It is stable, so that equal values still match in
+// replayed responses.
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return redacted + ":" + hex.EncodeToString(sum[:6])
+}
+
+// hashPII replaces every string in v with its hashValue.
+func hashPII(v any) any {
+	switch val := v.(type) {
+	case string:
+		return hashValue(val)
+	case map[string]any:
+		for key, child := range val {
+			val[key] = hashPII(child)
+		}
+	case []any:
+		for i, child := range val {
+			val[i] = hashPII(child)
+		}
+	}
+	return v
+}
+
+// hashRowLabel hashes the label column of a report row, leaving its amounts to be replayed.
+func hashRowLabel(v any) any {
+	columns, ok := v.([]any)
+	if !ok || len(columns) == 0 {
+		return v
+	}
+	if label, ok := columns[0].(map[string]any); ok {
+		if value, ok := label["value"].(string); ok && value != "" {
+			label["value"] = hashValue(value)
+		}
+	}
+	return v
+}
+
+func redactJSON(v any, pii piiKeys) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for key, child := range val {
+			if _, ok := redactedKeys[key]; ok {
+				val[key] = redacted
+				continue
+			}
+			if _, ok := pii.keys[key]; ok {
+				if key == reportColumnsKey {
+					val[key] = hashRowLabel(child)
+					continue
+				}
+				val[key] = hashPII(child)
+				continue
+			}
+			val[key] = redactJSON(child, pii.below(key))
+		}
+	case []any:
+		for i, child := range val {
+			val[i] = redactJSON(child, pii)
+		}
+	}
+	return v
+}
+
+// redactBody strips secrets and pii from JSON and form encoded bodies, leaving anything else
+// as is.
+func redactBody(contentType string, body []byte, pii piiKeys) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(string(body))
+		if err == nil {
+			redactValues(values)
+			return values.Encode()
+		}
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err == nil {
+		if b, err := json.Marshal(redactJSON(v, pii)); err == nil {
+			return string(b)
+		}
+	}
+	return string(body)
+}
+
+func readBody(body io.ReadCloser) ([]byte, error) {
+	if body == nil {
+		return nil, nil
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// RecordingTransport writes every QuickBooks request and response it forwards to redacted
+// JSON lines fixture files in dir, one file per realm. Secrets are always redacted, and the
+// pii keys it is built with are hashed in responses.
+type RecordingTransport struct {
+	base http.RoundTripper
+	dir  string
+	pii  piiKeys
+	mu   sync.Mutex
+}
+
+// NewRecordingTransport records to dir, hashing piiKeys in responses. Pass DefaultPIIKeys
+// unless the fixtures are allowed to hold customer data.
+func NewRecordingTransport(base http.RoundTripper, dir string, piiKeys []string) (*RecordingTransport, error) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create recording directory: %w", err)
+	}
+	return &RecordingTransport{base: base, dir: dir, pii: newPIIKeys(piiKeys)}, nil
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read request body: %w", err)
+	}
+	if req.Body != nil {
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := readBody(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Method:       req.Method,
+		Host:         req.URL.Host,
+		Path:         req.URL.Path,
+		Query:        canonicalQuery(req.URL.RawQuery),
+		RequestBody:  redactBody(req.Header.Get("Content-Type"), reqBody, piiKeys{}),
+		Status:       resp.StatusCode,
+		ContentType:  resp.Header.Get("Content-Type"),
+		ResponseBody: redactBody(resp.Header.Get("Content-Type"), respBody, t.pii),
+		RecordedAt:   time.Now().UTC(),
+	}
+	if err := t.write(interaction); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *RecordingTransport) write(interaction Interaction) error {
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return fmt.Errorf("unable to encode interaction: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f, err := os.OpenFile(recordingFile(t.dir, interaction.Path), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to open recording file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("unable to write interaction: %w", err)
+	}
+	return nil
+}
+
+// batchItem is a single query inside a recorded batch request or response.
+type batchItem map[string]json.RawMessage
+
+func (b batchItem) id() string {
+	var bid string
+	json.Unmarshal(b["bId"], &bid)
+	return bid
+}
+
+func (b batchItem) key() string {
+	var query string
+	json.Unmarshal(b["Query"], &query)
+	return b.id() + "\n" + query
+}
+
+// ReplayTransport serves recorded interactions back without contacting QuickBooks. Repeated
+// requests are answered in recorded order, and the last response is reused once they run out.
+// Batch requests are answered item by item, since the grouping of queries into batches
+// depends on timing.
+type ReplayTransport struct {
+	mu           sync.Mutex
+	interactions map[string][]Interaction
+	batchItems   map[string][]json.RawMessage
+}
+
+func NewReplayTransport(dir string) (*ReplayTransport, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list recordings: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recordings found in %s", dir)
+	}
+
+	t := &ReplayTransport{
+		interactions: make(map[string][]Interaction),
+		batchItems:   make(map[string][]json.RawMessage),
+	}
+	for _, file := range files {
+		if err := t.load(file); err != nil {
+			return nil, fmt.Errorf("unable to load %s: %w", file, err)
+		}
+	}
+	return t, nil
+}
+
+func (t *ReplayTransport) load(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return fmt.Errorf("invalid interaction: %w", err)
+		}
+		if isBatch(interaction.Path) && interaction.Status == http.StatusOK {
+			if err := t.indexBatch(interaction); err != nil {
+				return err
+			}
+			continue
+		}
+		key := interaction.key()
+		t.interactions[key] = append(t.interactions[key], interaction)
+	}
+	return scanner.Err()
+}
+
+func (t *ReplayTransport) indexBatch(interaction Interaction) error {
+	var req struct{ BatchItemRequest []batchItem }
+	if err := json.Unmarshal([]byte(interaction.RequestBody), &req); err != nil {
+		return fmt.Errorf("invalid batch request: %w", err)
+	}
+	var resp struct{ BatchItemResponse []batchItem }
+	if err := json.Unmarshal([]byte(interaction.ResponseBody), &resp); err != nil {
+		return fmt.Errorf("invalid batch response: %w", err)
+	}
+
+	responses := make(map[string]json.RawMessage, len(resp.BatchItemResponse))
+	for _, item := range resp.BatchItemResponse {
+		raw, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		responses[item.id()] = raw
+	}
+	for _, item := range req.BatchItemRequest {
+		if raw, ok := responses[item.id()]; ok {
+			key := interaction.Host + interaction.Path + "\n" + item.key()
+			t.batchItems[key] = append(t.batchItems[key], raw)
+		}
+	}
+	return nil
+}
+
+func nextRecorded[T any](queues map[string][]T, key string) (T, bool) {
+	queue, ok := queues[key]
+	if !ok || len(queue) == 0 {
+		var zero T
+		return zero, false
+	}
+	if len(queue) > 1 {
+		queues[key] = queue[1:]
+	}
+	return queue[0], true
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read request body: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if isBatch(req.URL.Path) {
+		if resp, ok, err := t.replayBatch(req, reqBody); ok || err != nil {
+			return resp, err
+		}
+	}
+
+	lookup := Interaction{
+		Method:      req.Method,
+		Host:        req.URL.Host,
+		Path:        req.URL.Path,
+		Query:       canonicalQuery(req.URL.RawQuery),
+		RequestBody: redactBody(req.Header.Get("Content-Type"), reqBody, piiKeys{}),
+	}
+	interaction, ok := nextRecorded(t.interactions, lookup.key())
+	if !ok {
+		return nil, fmt.Errorf("no recorded interaction for %s %s%s", req.Method, req.URL.Host, req.URL.Path)
+	}
+	return replayResponse(req, interaction.Status, interaction.ContentType, interaction.ResponseBody), nil
+}
+
+func (t *ReplayTransport) replayBatch(req *http.Request, body []byte) (*http.Response, bool, error) {
+	var batch struct{ BatchItemRequest []batchItem }
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return nil, false, nil
+	}
+
+	items := make([]json.RawMessage, 0, len(batch.BatchItemRequest))
+	for _, item := range batch.BatchItemRequest {
+		raw, ok := nextRecorded(t.batchItems, req.URL.Host+req.URL.Path+"\n"+item.key())
+		if !ok {
+			// fall back to a whole recorded request, such as a rate limited batch
+			return nil, false, nil
+		}
+		items = append(items, raw)
+	}
+
+	out, err := json.Marshal(map[string]any{
+		"BatchItemResponse": items,
+		"time":              time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, true, fmt.Errorf("unable to encode batch response: %w", err)
+	}
+	return replayResponse(req, http.StatusOK, "application/json", string(out)), true, nil
+}
+
+func replayResponse(req *http.Request, status int, contentType, body string) *http.Response {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// discover loads the QuickBooks discovery document through client, so that it is recorded
+// and replayed alongside the rest of the QuickBooks traffic.
+func discover(client *http.Client, discoveryURL string) (*quickbooks.DiscoveryAPI, error) {
+	resp, err := client.Get(discoveryURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery returned status %d", resp.StatusCode)
+	}
+	var discovery quickbooks.DiscoveryAPI
+	if err := json.NewDecoder(resp.Body).Decode(&discovery); err != nil {
+		return nil, fmt.Errorf("unable to decode discovery document: %w", err)
+	}
+	return &discovery, nil
+}
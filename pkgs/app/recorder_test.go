@@ -0,0 +1,170 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/qbosim"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+func newRecorderClient(t *testing.T, transport http.RoundTripper, discoveryURL, endpoint string) *quickbooks.Client {
+	t.Helper()
+	httpClient := &http.Client{Transport: transport}
+	discovery, err := discover(httpClient, discoveryURL)
+	if err != nil {
+		t.Fatalf("discovery failed: %v", err)
+	}
+	client, err := quickbooks.NewClient(quickbooks.ClientRequest{
+		Client:       httpClient,
+		DiscoveryAPI: discovery,
+		ClientId:     qbosim.ClientId,
+		ClientSecret: qbosim.ClientSecret,
+		Endpoint:     endpoint,
+	})
+	if err != nil {
+		t.Fatalf("unable to build client: %v", err)
+	}
+	return client
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	t.Parallel()
+	fixture := qbosim.SandboxFixture()
+	sim := qbosim.New(fixture)
+	dir := t.TempDir()
+	discoveryURL, endpoint := sim.DiscoveryURL(), sim.URL
+
+	recorder, err := NewRecordingTransport(nil, dir, DefaultPIIKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newRecorderClient(t, recorder, discoveryURL, endpoint)
+
+	token, err := client.RetrieveBearerToken(sim.AuthorizationCode(fixture.RealmId), "http://localhost/callback")
+	if err != nil {
+		t.Fatalf("token exchange failed: %v", err)
+	}
+	params := quickbooks.RequestParameters{Ctx: context.Background(), RealmId: fixture.RealmId, Token: token}
+
+	since := time.Now().Add(-time.Minute)
+	if err := sim.Upsert(fixture.RealmId, "Vendor", map[string]any{"Id": "2", "DisplayName": "Books by Bessie Inc"}); err != nil {
+		t.Fatal(err)
+	}
+
+	vendorQuery := quickbooks.BatchItemRequest{BID: "Vendor:1", Query: "Select * From Vendor ORDERBY Id STARTPOSITION 1 MAXRESULTS 10"}
+	billQuery := quickbooks.BatchItemRequest{BID: "Bill:1", Query: "Select * From Bill ORDERBY Id STARTPOSITION 1 MAXRESULTS 10"}
+	if _, err := client.BatchRequest(params, []quickbooks.BatchItemRequest{vendorQuery, billQuery}); err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	if _, err := client.ChangeDataCapture(params, []string{"Vendor", "Bill"}, since); err != nil {
+		t.Fatalf("cdc failed: %v", err)
+	}
+	if _, err := client.RefreshToken(token.RefreshToken); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	sim.Close()
+
+	realmFile, err := os.ReadFile(filepath.Join(dir, fixture.RealmId+".jsonl"))
+	if err != nil {
+		t.Fatalf("expected a recording for the realm: %v", err)
+	}
+	globalFile, err := os.ReadFile(filepath.Join(dir, "global.jsonl"))
+	if err != nil {
+		t.Fatalf("expected a recording for oauth traffic: %v", err)
+	}
+	for _, secret := range []string{token.AccessToken, token.RefreshToken, qbosim.ClientSecret, "Books by Bessie Inc"} {
+		if strings.Contains(string(realmFile), secret) || strings.Contains(string(globalFile), secret) {
+			t.Errorf("recording contains %q", secret)
+		}
+	}
+	if strings.Contains(string(realmFile), "changedSince") {
+		t.Error("expected the cdc window to be left out of the recording")
+	}
+
+	replay, err := NewReplayTransport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = newRecorderClient(t, replay, discoveryURL, endpoint)
+
+	replayToken, err := client.RetrieveBearerToken("any code", "http://localhost/callback")
+	if err != nil || replayToken.AccessToken != redacted {
+		t.Fatalf("unexpected replayed token %+v: %v", replayToken, err)
+	}
+	params.Token = replayToken
+
+	batch, err := client.BatchRequest(params, []quickbooks.BatchItemRequest{billQuery, vendorQuery})
+	if err != nil {
+		t.Fatalf("replayed batch failed: %v", err)
+	}
+	if len(batch) != 2 || len(batch[0].QueryResponse.Bill) != 3 || len(batch[1].QueryResponse.Vendor) != 5 {
+		t.Errorf("unexpected replayed batch: %+v", batch)
+	}
+
+	// a replayed sync runs later than the recorded one, so its cdc window differs
+	cdc, err := client.ChangeDataCapture(params, []string{"Bill", "Vendor"}, time.Now())
+	if err != nil {
+		t.Fatalf("replayed cdc failed: %v", err)
+	}
+	vendors := quickbooks.CDCQueryExtractor(&cdc, func(r quickbooks.CDCQueryResponse) []quickbooks.Vendor { return r.Vendor })
+	if len(vendors) != 1 || vendors[0].DisplayName != hashValue("Books by Bessie Inc") {
+		t.Errorf("unexpected replayed cdc vendors: %+v", vendors)
+	}
+
+	if _, err := client.FindCompanyInfo(params); err == nil {
+		t.Error("expected unrecorded request to fail")
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	t.Parallel()
+	body := []byte(`{
+		"Vendor": {"Id": "3", "DisplayName": "Brosnahan Insurance", "PrimaryEmailAddr": {"Address": "ins@example.com"}, "TaxIdentifier": "12-3456789"},
+		"Bill": {"Id": "26", "VendorRef": {"value": "3", "name": "Brosnahan Insurance"}, "TotalAmt": 50},
+		"Item": {"Id": "11", "Name": "Pump", "Description": "Garden pump"},
+		"Invoice": {"Id": "130", "PrivateNote": "Call Amy first", "Line": [{"Description": "Weekly gardening", "Amount": 275}]},
+		"Attachable": {"Id": "5000", "TempDownloadUri": "https://example.com/receipt.pdf?signature=abc"},
+		"Rows": {"Row": [{"ColData": [{"value": "Cool Cars", "id": "3"}, {"value": "694.00"}]}]},
+		"access_token": "secret"
+	}`)
+
+	for name, tc := range map[string]struct {
+		pii     []string
+		want    []string
+		notWant []string
+	}{
+		"default pii keys": {
+			pii: DefaultPIIKeys,
+			want: []string{
+				`"value":"3"`, `"Name":"Pump"`, `"TotalAmt":50`, hashValue("Brosnahan Insurance"),
+				`"Description":"Garden pump"`, `"Amount":275`, `"id":"3"`, `"value":"694.00"`, hashValue("Cool Cars"),
+			},
+			notWant: []string{"Brosnahan", "ins@example.com", "12-3456789", "secret", "Call Amy", "Weekly gardening", "signature=abc", "Cool Cars"},
+		},
+		"pii kept": {
+			want:    []string{"Brosnahan Insurance", "ins@example.com", "12-3456789", "Weekly gardening", "Cool Cars"},
+			notWant: []string{"secret"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got := redactBody("application/json", body, newPIIKeys(tc.pii))
+			for _, want := range tc.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected %s in %s", want, got)
+				}
+			}
+			for _, notWant := range tc.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("expected %s to be redacted from %s", notWant, got)
+				}
+			}
+		})
+	}
+}
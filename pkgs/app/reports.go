@@ -0,0 +1,289 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+// ReportPeriodsFilterId is the sync filter holding the QuickBooks date macros reports are run for.
+const ReportPeriodsFilterId = "reportPeriods"
+
+// DefaultReportPeriod is used when no report periods are selected on the sync filter.
+const DefaultReportPeriod = "This Fiscal Year-to-date"
+
+// ReportPeriods are the QuickBooks date macros offered on the report periods filter.
+var ReportPeriods = []string{
+	"Today",
+	"This Week",
+	"This Week-to-date",
+	"Last Week",
+	"This Month",
+	"This Month-to-date",
+	"Last Month",
+	"This Quarter",
+	"This Quarter-to-date",
+	"Last Quarter",
+	"This Year",
+	"This Year-to-date",
+	"Last Year",
+	"This Fiscal Year",
+	"This Fiscal Year-to-date",
+	"Last Fiscal Year",
+}
+
+// ReportType is read from the QuickBooks Reports API instead of entity queries. A report is run
+// once per period and each of its rows becomes one item.
+type ReportType interface {
+	fibery.Type
+	Report() string
+	Periods(filter map[string]any) []string
+	Query(period string) url.Values
+	ProcessReports(reports []ReportPeriod) ([]map[string]any, error)
+}
+
+// ReportPeriod is a report as run for one period.
+type ReportPeriod struct {
+	Period string
+	Report *quickbooks.Report
+}
+
+// ReportRow is a single report row flattened out of its section tree. Path holds the labels of
+// the enclosing sections followed by the row's own label; a section summary shares its
+// section's path.
+type ReportRow struct {
+	Header quickbooks.ReportHeader
+	Period string
+	Path   []string
+	Label  string
+	Type   string
+	Group  string
+	RefId  string
+	Values map[string]string
+}
+
+const (
+	ReportDataRow    = "Data"
+	ReportSummaryRow = "Summary"
+)
+
+// Amount parses a money column of the row, treating blank cells as zero.
+func (r ReportRow) Amount(column string) (float64, error) {
+	value := r.Values[column]
+	if value == "" {
+		return 0, nil
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s amount %q: %w", column, value, err)
+	}
+	return amount, nil
+}
+
+type ReportFieldDef struct {
+	Params  fibery.Field
+	Convert func(ReportRow) (any, error)
+}
+
+type ReportTypeDef struct {
+	ReportName string
+	FiberyId   string
+	FiberyName string
+	AsOf       bool
+	Fields     map[string]ReportFieldDef
+}
+
+// NewReportType builds a report type. AsOf reports, such as aging reports, describe the books
+// as of today and are not run for the filter's periods.
+func NewReportType(
+	reportName, fiberyId, fiberyName string,
+	asOf bool,
+	addlFields map[string]ReportFieldDef,
+) *ReportTypeDef {
+	fields := make(map[string]ReportFieldDef, len(addlFields)+1)
+	for k, v := range addlFields {
+		fields[k] = v
+	}
+	fields["id"] = ReportFieldDef{
+		Params: fibery.Field{
+			Name: "Id",
+			Type: fibery.Id,
+		},
+		Convert: func(row ReportRow) (any, error) {
+			return strings.Join(append([]string{reportName, row.Period}, row.Path...), ":"), nil
+		},
+	}
+
+	return &ReportTypeDef{
+		ReportName: reportName,
+		FiberyId:   fiberyId,
+		FiberyName: fiberyName,
+		AsOf:       asOf,
+		Fields:     fields,
+	}
+}
+
+// --- ReportTypeDef methods ---
+
+func (t *ReportTypeDef) Id() string {
+	return t.FiberyId
+}
+
+func (t *ReportTypeDef) Name() string {
+	return t.FiberyName
+}
+
+func (t *ReportTypeDef) Schema() map[string]fibery.Field {
+	schema := make(map[string]fibery.Field, len(t.Fields))
+	for id, field := range t.Fields {
+		schema[id] = field.Params
+	}
+	return FieldMappings.Schema(t.FiberyId, schema)
+}
+
+func (t *ReportTypeDef) Report() string {
+	return t.ReportName
+}
+
+func (t *ReportTypeDef) Periods(filter map[string]any) []string {
+	if t.AsOf {
+		return []string{"Today"}
+	}
+
+	var periods []string
+	if selected, ok := filter[ReportPeriodsFilterId].([]any); ok {
+		for _, period := range selected {
+			if p, ok := period.(string); ok && p != "" {
+				periods = append(periods, p)
+			}
+		}
+	}
+	if len(periods) == 0 {
+		return []string{DefaultReportPeriod}
+	}
+	return periods
+}
+
+func (t *ReportTypeDef) Query(period string) url.Values {
+	query := url.Values{}
+	if !t.AsOf {
+		query.Set("date_macro", period)
+		query.Set("summarize_column_by", "Total")
+	}
+	return query
+}
+
+func (t *ReportTypeDef) Convert(row ReportRow) (map[string]any, error) {
+	output := make(map[string]any, len(t.Fields))
+	for id, field := range t.Fields {
+		fieldValue, err := field.Convert(row)
+		if err != nil {
+			return nil, err
+		}
+		output[id] = fieldValue
+	}
+	return FieldMappings.Item(t.FiberyId, output), nil
+}
+
+func (t *ReportTypeDef) ProcessReports(reports []ReportPeriod) ([]map[string]any, error) {
+	var output []map[string]any
+	for _, rp := range reports {
+		for _, row := range FlattenReport(rp.Period, rp.Report) {
+			o, err := t.Convert(row)
+			if err != nil {
+				return nil, fmt.Errorf("error converting %s row %s: %w", t.ReportName, strings.Join(row.Path, "/"), err)
+			}
+			output = append(output, o)
+		}
+	}
+	return output, nil
+}
+
+// FlattenReport walks the report's sections depth first. Sections contribute their label to the
+// path of the rows inside them and emit their summary as a row of their own. Repeated labels
+// under the same parent are numbered so that every path is unique.
+func FlattenReport(period string, report *quickbooks.Report) []ReportRow {
+	if report == nil {
+		return nil
+	}
+
+	titles := make([]string, len(report.Columns.Column))
+	for i, column := range report.Columns.Column {
+		titles[i] = column.ColTitle
+	}
+
+	var rows []ReportRow
+	var walk func(path []string, reportRows quickbooks.ReportRows)
+	walk = func(path []string, reportRows quickbooks.ReportRows) {
+		seen := make(map[string]int, len(reportRows.Row))
+		label := func(cols []quickbooks.ReportColData, group string) string {
+			l := group
+			if len(cols) > 0 && cols[0].Value != "" {
+				l = cols[0].Value
+			}
+			seen[l]++
+			if seen[l] > 1 {
+				l = fmt.Sprintf("%s (%d)", l, seen[l])
+			}
+			return l
+		}
+		emit := func(rowPath []string, rowType, group string, cols []quickbooks.ReportColData) {
+			row := ReportRow{
+				Header: report.Header,
+				Period: period,
+				Path:   rowPath,
+				Type:   rowType,
+				Group:  group,
+				Values: make(map[string]string, len(cols)),
+			}
+			if len(rowPath) > 0 {
+				row.Label = rowPath[len(rowPath)-1]
+			}
+			if len(cols) > 0 {
+				row.RefId = cols[0].Id
+				if cols[0].Value != "" {
+					row.Label = cols[0].Value
+				}
+			}
+			for i, col := range cols {
+				if i < len(titles) {
+					row.Values[titles[i]] = col.Value
+				}
+			}
+			rows = append(rows, row)
+		}
+
+		for _, r := range reportRows.Row {
+			if r.Header == nil && r.Rows == nil && r.Summary == nil {
+				emit(appendPath(path, label(r.ColData, r.Group)), ReportDataRow, r.Group, r.ColData)
+				continue
+			}
+
+			var sectionPath []string
+			if r.Header != nil {
+				sectionPath = appendPath(path, label(r.Header.ColData, r.Group))
+			} else {
+				sectionPath = appendPath(path, label(nil, r.Group))
+			}
+			if r.Rows != nil {
+				walk(sectionPath, *r.Rows)
+			}
+			if r.Summary != nil {
+				emit(sectionPath, ReportSummaryRow, r.Group, r.Summary.ColData)
+			}
+		}
+	}
+	walk(nil, report.Rows)
+
+	return rows
+}
+
+func appendPath(path []string, label string) []string {
+	out := make([]string, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, label)
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+func reportCols(values ...string) []quickbooks.ReportColData {
+	cols := make([]quickbooks.ReportColData, len(values))
+	for i, v := range values {
+		cols[i].Value = v
+	}
+	return cols
+}
+
+func TestFlattenReport(t *testing.T) {
+	t.Parallel()
+	section := func(label, group string, rows ...quickbooks.ReportRow) quickbooks.ReportRow {
+		return quickbooks.ReportRow{
+			Group:   group,
+			Header:  &quickbooks.ReportRowData{ColData: reportCols(label, "")},
+			Rows:    &quickbooks.ReportRows{Row: rows},
+			Summary: &quickbooks.ReportRowData{ColData: reportCols("Total "+label, "10.00")},
+		}
+	}
+	data := func(label, amount string) quickbooks.ReportRow {
+		return quickbooks.ReportRow{ColData: reportCols(label, amount)}
+	}
+
+	for name, tc := range map[string]struct {
+		rows []quickbooks.ReportRow
+		want []string
+	}{
+		"nested sections": {
+			rows: []quickbooks.ReportRow{
+				section("Expenses", "Expenses", data("Job Materials", "4.00"), section("Utilities", "", data("Telephone", "6.00"))),
+			},
+			want: []string{
+				"Data Expenses/Job Materials 4.00",
+				"Data Expenses/Utilities/Telephone 6.00",
+				"Summary Expenses/Utilities 10.00",
+				"Summary Expenses 10.00",
+			},
+		},
+		"repeated data labels": {
+			rows: []quickbooks.ReportRow{data("Misc", "1.00"), data("Misc", "2.00"), data("Misc", "3.00")},
+			want: []string{"Data Misc 1.00", "Data Misc (2) 2.00", "Data Misc (3) 3.00"},
+		},
+		"repeated section labels": {
+			rows: []quickbooks.ReportRow{
+				section("Other", "", data("Misc", "1.00")),
+				section("Other", "", data("Misc", "2.00")),
+			},
+			want: []string{
+				"Data Other/Misc 1.00",
+				"Summary Other 10.00",
+				"Data Other (2)/Misc 2.00",
+				"Summary Other (2) 10.00",
+			},
+		},
+		"same label under different parents": {
+			rows: []quickbooks.ReportRow{
+				section("Income", "", data("Misc", "1.00")),
+				section("Expenses", "", data("Misc", "2.00")),
+			},
+			want: []string{
+				"Data Income/Misc 1.00",
+				"Summary Income 10.00",
+				"Data Expenses/Misc 2.00",
+				"Summary Expenses 10.00",
+			},
+		},
+		"unlabelled sections use their group": {
+			rows: []quickbooks.ReportRow{
+				{Group: "NetIncome", Summary: &quickbooks.ReportRowData{ColData: reportCols("Net Income", "5.00")}},
+				{Group: "NetIncome", Summary: &quickbooks.ReportRowData{ColData: reportCols("", "6.00")}},
+			},
+			want: []string{"Summary NetIncome 5.00", "Summary NetIncome (2) 6.00"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			report := &quickbooks.Report{
+				Columns: quickbooks.ReportColumns{Column: []quickbooks.ReportColumn{{ColTitle: ""}, {ColTitle: "Total"}}},
+				Rows:    quickbooks.ReportRows{Row: tc.rows},
+			}
+			rows := FlattenReport("This Month", report)
+
+			got := make([]string, len(rows))
+			paths := make(map[string]bool, len(rows))
+			for i, row := range rows {
+				path := strings.Join(row.Path, "/")
+				got[i] = row.Type + " " + path + " " + row.Values["Total"]
+				if paths[row.Type+path] {
+					t.Errorf("duplicate %s row path %q", row.Type, path)
+				}
+				paths[row.Type+path] = true
+				if row.Period != "This Month" {
+					t.Errorf("expected the row to carry its period, got %q", row.Period)
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("expected rows\n%s\ngot\n%s", strings.Join(tc.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+
+	if rows := FlattenReport("Today", nil); rows != nil {
+		t.Errorf("expected no rows for a missing report, got %v", rows)
+	}
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+)
+
+// SchemaError lists every problem found in the registered type schemas.
+type SchemaError struct {
+	Problems []string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%d schema problem(s):\n  %s", len(e.Problems), strings.Join(e.Problems, "\n  "))
+}
+
+// Validate checks that relations point at registered types and fields, that every type can be
+// identified and removed by Fibery, and that select fields have options.
+func (tr TypeRegistry) Validate() error {
+	typeIds := make([]string, 0, len(tr))
+	for typeId := range tr {
+		typeIds = append(typeIds, typeId)
+	}
+	sort.Strings(typeIds)
+
+	var problems []string
+	for _, typeId := range typeIds {
+		schema := tr[typeId].Schema()
+
+		for _, required := range []string{"id", "__syncAction"} {
+			if _, ok := schema[required]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing %s field", typeId, required))
+			}
+		}
+
+		fieldIds := make([]string, 0, len(schema))
+		for fieldId := range schema {
+			fieldIds = append(fieldIds, fieldId)
+		}
+		sort.Strings(fieldIds)
+
+		for _, fieldId := range fieldIds {
+			field := schema[fieldId]
+
+			if (field.SubType == fibery.SingleSelect || field.SubType == fibery.MultiSelect) && len(field.Options) == 0 {
+				problems = append(problems, fmt.Sprintf("%s.%s: %s field has no options", typeId, fieldId, field.SubType))
+			}
+
+			if field.Relation == nil {
+				continue
+			}
+
+			target, ok := tr[field.Relation.TargetType]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s: relation target type %q is not registered", typeId, fieldId, field.Relation.TargetType))
+				continue
+			}
+
+			if _, ok := target.Schema()[field.Relation.TargetFieldID]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s: relation target field %s.%s does not exist", typeId, fieldId, field.Relation.TargetType, field.Relation.TargetFieldID))
+			}
+		}
+	}
+
+	featureTypeIds := make([]string, 0, len(FeatureFields))
+	for typeId := range FeatureFields {
+		featureTypeIds = append(featureTypeIds, typeId)
+	}
+	sort.Strings(featureTypeIds)
+
+	for _, typeId := range featureTypeIds {
+		t, ok := tr[typeId]
+		if !ok {
+			continue
+		}
+		schema := t.Schema()
+
+		fieldIds := make([]string, 0, len(FeatureFields[typeId]))
+		for fieldId := range FeatureFields[typeId] {
+			fieldIds = append(fieldIds, fieldId)
+		}
+		sort.Strings(fieldIds)
+
+		for _, fieldId := range fieldIds {
+			if _, ok := schema[fieldId]; !ok && !FieldMappings[typeId].Fields[fieldId].Hidden {
+				problems = append(problems, fmt.Sprintf("%s.%s: %s feature field does not exist", typeId, fieldId, FeatureFields[typeId][fieldId]))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &SchemaError{Problems: problems}
+	}
+	return nil
+}
+
+// SelectOptions builds single and multi select field options from their names.
+func SelectOptions(names ...string) []map[string]any {
+	options := make([]map[string]any, 0, len(names))
+	for _, name := range names {
+		options = append(options, map[string]any{"name": name})
+	}
+	return options
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+)
+
+func TestTypeRegistryValidate(t *testing.T) {
+	t.Parallel()
+	base := map[string]fibery.Field{
+		"id":           {Name: "Id", Type: fibery.Id},
+		"__syncAction": {Name: "Sync Action", Type: fibery.Text},
+	}
+
+	valid := TypeRegistry{}
+	valid.Register(NewStaticType("parent", "Parent", base, nil))
+	valid.Register(NewStaticType("child", "Child", map[string]fibery.Field{
+		"id":           {Name: "Id", Type: fibery.Id},
+		"__syncAction": {Name: "Sync Action", Type: fibery.Text},
+		"status":       {Name: "Status", Type: fibery.Text, SubType: fibery.SingleSelect, Options: SelectOptions("Open", "Closed")},
+		"parentId":     {Name: "Parent", Type: fibery.Text, Relation: &fibery.Relation{TargetType: "parent", TargetFieldID: "id"}},
+	}, nil))
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected valid registry, got %v", err)
+	}
+
+	invalid := TypeRegistry{}
+	invalid.Register(NewStaticType("parent", "Parent", base, nil))
+	invalid.Register(NewStaticType("child", "Child", map[string]fibery.Field{
+		"id":       {Name: "Id", Type: fibery.Id},
+		"status":   {Name: "Status", Type: fibery.Text, SubType: fibery.MultiSelect},
+		"parentId": {Name: "Parent", Type: fibery.Text, Relation: &fibery.Relation{TargetType: "parent", TargetFieldID: "qboId"}},
+		"termId":   {Name: "Term", Type: fibery.Text, Relation: &fibery.Relation{TargetType: "SalesTerm", TargetFieldID: "id"}},
+	}, nil))
+
+	err := invalid.Validate()
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected SchemaError, got %v", err)
+	}
+	want := []string{
+		"child: missing __syncAction field",
+		"child.parentId: relation target field parent.qboId does not exist",
+		"child.status: multi-select field has no options",
+		`child.termId: relation target type "SalesTerm" is not registered`,
+	}
+	if strings.Join(schemaErr.Problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected problems:\n%v", err)
+	}
+}
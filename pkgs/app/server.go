@@ -37,7 +37,14 @@ func (lrw *loggingResponseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// WriteHeader drops any Content-Length set by the handler since it describes the uncompressed body.
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(code)
+}
+
 func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	w.Header().Del("Content-Length")
 	return w.Writer.Write(b)
 }
 
@@ -100,7 +107,7 @@ func gzipMiddleware(next http.Handler) http.Handler {
 			next.ServeHTTP(w, r)
 			return
 		}
-		if r.URL.Path == "/api/v1/synchronizer/webhooks/pre-process" {
+		if r.URL.Path == "/api/v1/synchronizer/webhooks/pre-process" || r.URL.Path == "/metrics" {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -120,8 +127,13 @@ func gzipMiddleware(next http.Handler) http.Handler {
 func NewHandler(i *Integration) http.Handler {
 	mux := http.NewServeMux()
 	fibery.RegisterFiberyRoutes(mux, i)
+	registerAdminRoutes(mux, i)
+	if i.metrics != nil {
+		mux.Handle("GET /metrics", i.metrics.Handler())
+	}
 	var handler http.Handler = mux
 
+	handler = tracingMiddleware(handler)
 	handler = loggingMiddleware()(handler)
 	handler = gzipMiddleware(handler)
 	return handler
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+// SnapshotType records the state of its source entities each time they are synced, such as the
+// quantity on hand of inventory items. The source is queried in full for snapshots even while its
+// own types sync with change data capture. Snapshots are sent as delta pages once Fibery has
+// synced the snapshot type before, so the snapshots of earlier syncs are kept.
+type SnapshotType interface {
+	fibery.Type
+	SourceType() string
+	ProcessSnapshot(batch *quickbooks.BatchItemResponse, taken time.Time, pageSize int) ([]map[string]any, bool, error)
+}
+
+type SnapshotTypeDef[T any] struct {
+	SourceTypeId        string
+	FiberyId            string
+	FiberyName          string
+	ItemId              func(T) string
+	ItemCheck           func(T) bool
+	Fields              map[string]FieldDef[T]
+	BatchQueryExtractor func(quickbooks.BatchQueryResponse) []T
+}
+
+// NewSnapshotType builds a snapshot type recording one item per source item that passes
+// itemCheck. Snapshot ids join the source id and the day the snapshot was taken, so a source item
+// synced more than once a day keeps a single snapshot for that day.
+func NewSnapshotType[T any](
+	sourceTypeId, fiberyId, fiberyName string,
+	itemId func(T) string,
+	itemCheck func(T) bool,
+	batchQueryExtractor func(quickbooks.BatchQueryResponse) []T,
+	addlFields map[string]FieldDef[T],
+) *SnapshotTypeDef[T] {
+	return &SnapshotTypeDef[T]{
+		SourceTypeId:        sourceTypeId,
+		FiberyId:            fiberyId,
+		FiberyName:          fiberyName,
+		ItemId:              itemId,
+		ItemCheck:           itemCheck,
+		Fields:              addlFields,
+		BatchQueryExtractor: batchQueryExtractor,
+	}
+}
+
+// --- SnapshotTypeDef[T] methods ---
+
+func (t *SnapshotTypeDef[T]) Id() string {
+	return t.FiberyId
+}
+
+func (t *SnapshotTypeDef[T]) Name() string {
+	return t.FiberyName
+}
+
+func (t *SnapshotTypeDef[T]) Schema() map[string]fibery.Field {
+	schema := make(map[string]fibery.Field, len(t.Fields)+2)
+	for id, field := range t.Fields {
+		schema[id] = field.Params
+	}
+	schema["id"] = fibery.Field{
+		Name: "Id",
+		Type: fibery.Id,
+	}
+	schema["snapshotDate"] = fibery.Field{
+		Name:    "Snapshot Date",
+		Type:    fibery.DateType,
+		SubType: fibery.Day,
+	}
+	return FieldMappings.Schema(t.FiberyId, schema)
+}
+
+func (t *SnapshotTypeDef[T]) SourceType() string {
+	return t.SourceTypeId
+}
+
+func (t *SnapshotTypeDef[T]) ProcessSnapshot(batch *quickbooks.BatchItemResponse, taken time.Time, pageSize int) ([]map[string]any, bool, error) {
+	input := quickbooks.BatchQueryExtractor(batch, t.BatchQueryExtractor)
+
+	more := len(input) == pageSize
+
+	taken = taken.UTC().Truncate(24 * time.Hour)
+	day := taken.Format(time.DateOnly)
+	output := make([]map[string]any, 0, len(input))
+	for _, i := range input {
+		if !t.ItemCheck(i) {
+			continue
+		}
+		o := make(map[string]any, len(t.Fields)+2)
+		for id, field := range t.Fields {
+			fieldValue, err := field.Convert(StandardData[T]{Item: i})
+			if err != nil {
+				return nil, more, fmt.Errorf("error converting snapshot of %s: %w", t.ItemId(i), err)
+			}
+			o[id] = fieldValue
+		}
+		o["id"] = t.ItemId(i) + ":" + day
+		o["snapshotDate"] = taken.Format(fibery.DateFormat)
+		output = append(output, FieldMappings.Item(t.FiberyId, o))
+	}
+	return output, more, nil
+}
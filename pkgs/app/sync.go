@@ -4,11 +4,15 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
 	"github.com/tommyhedley/quickbooks-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type SyncRequest struct {
@@ -36,6 +40,7 @@ type OperationDataHandlerResponse struct {
 
 type SourceGroup struct {
 	getAttachable bool
+	snapshot      bool
 	expectedUses  int
 	request       RequestType
 	batchPages    map[int]*quickbooks.BatchItemResponse
@@ -52,12 +57,21 @@ type Operation struct {
 	existingCache     bool
 	idCache           *IdCache
 	account           QuickBooksAccountInfo
-	lastSynced        time.Time
+	lastSynced        map[string]time.Time
+	changedSince      time.Time
+	created           time.Time
 	lastRequest       time.Time
 	requestTypes      map[string]fibery.Type
+	unsubmitted       int
+	submitted         map[string]struct{}
 	sourceGroups      map[string]*SourceGroup
 	changeDataCapture *quickbooks.ChangeDataCapture
+	filter            map[string]any
+	reports           map[string][]ReportPeriod
+	realms            map[string]OperationDataHandlerResponse
 	chans             map[string]chan OperationDataHandlerResponse
+	completed         map[string]struct{}
+	span              trace.Span
 	ctx               context.Context
 	cancel            context.CancelFunc
 }
@@ -75,11 +89,15 @@ func NewOperationManager(ttl time.Duration) *OperationManager {
 	}
 }
 
-func (om *OperationManager) GetOrAddOperation(req SyncRequest, i *Integration) (*Operation, error) {
+func (om *OperationManager) GetOrAddOperation(ctx context.Context, req SyncRequest, i *Integration) (*Operation, error) {
 	om.Lock()
 	defer om.Unlock()
 	if op, exists := om.Operations[req.OperationId]; exists {
-		if !op.lastRequest.IsZero() && time.Since(op.lastRequest) > om.ttl {
+		op.Lock()
+		lastRequest := op.lastRequest
+		op.Unlock()
+
+		if !lastRequest.IsZero() && time.Since(lastRequest) > om.ttl {
 			if op.cancel != nil {
 				op.cancel()
 			}
@@ -87,6 +105,7 @@ func (om *OperationManager) GetOrAddOperation(req SyncRequest, i *Integration) (
 			timeoutErr := fmt.Errorf("operation %s timed out after %v without new requests", req.OperationId, om.ttl)
 
 			op.propagateError(timeoutErr)
+			op.span.End()
 			delete(om.Operations, req.OperationId)
 			return nil, timeoutErr
 		}
@@ -97,13 +116,83 @@ func (om *OperationManager) GetOrAddOperation(req SyncRequest, i *Integration) (
 
 	slog.Debug(fmt.Sprintf("requested operation: %s does not exist", req.OperationId))
 
-	op := buildOperation(req, i)
+	op := buildOperation(ctx, req, i)
 
 	om.Operations[req.OperationId] = op
 
 	return op, nil
 }
 
+func (om *OperationManager) Len() int {
+	om.Lock()
+	defer om.Unlock()
+	return len(om.Operations)
+}
+
+type OperationInfo struct {
+	Id          string    `json:"id"`
+	RealmId     string    `json:"realmId"`
+	Types       []string  `json:"types"`
+	PendingKeys []string  `json:"pendingKeys"`
+	Created     time.Time `json:"created"`
+	LastRequest time.Time `json:"lastRequest"`
+	Age         string    `json:"age"`
+}
+
+func (om *OperationManager) List() []OperationInfo {
+	om.Lock()
+	ops := make([]*Operation, 0, len(om.Operations))
+	for _, op := range om.Operations {
+		ops = append(ops, op)
+	}
+	om.Unlock()
+
+	infos := make([]OperationInfo, 0, len(ops))
+	for _, op := range ops {
+		op.Lock()
+		info := OperationInfo{
+			Id:          op.id,
+			RealmId:     op.account.RealmId,
+			Types:       make([]string, 0, len(op.requestTypes)),
+			PendingKeys: make([]string, 0, len(op.chans)),
+			Created:     op.created,
+			LastRequest: op.lastRequest,
+			Age:         time.Since(op.created).Round(time.Second).String(),
+		}
+		for typeId := range op.requestTypes {
+			info.Types = append(info.Types, typeId)
+		}
+		for key := range op.chans {
+			info.PendingKeys = append(info.PendingKeys, key)
+		}
+		op.Unlock()
+
+		sort.Strings(info.Types)
+		sort.Strings(info.PendingKeys)
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(a, b int) bool {
+		return infos[a].Created.Before(infos[b].Created)
+	})
+	return infos
+}
+
+// CancelOperation fails any pending pages with err before removing the operation.
+func (om *OperationManager) CancelOperation(operationId string, err error) bool {
+	om.Lock()
+	op, exists := om.Operations[operationId]
+	om.Unlock()
+
+	if !exists {
+		return false
+	}
+
+	op.propagateError(err)
+	om.DeleteOperation(operationId)
+	return true
+}
+
 func (om *OperationManager) DeleteOperation(operationId string) {
 	om.Lock()
 	defer om.Unlock()
@@ -111,6 +200,7 @@ func (om *OperationManager) DeleteOperation(operationId string) {
 		if op.cancel != nil {
 			op.cancel()
 		}
+		op.span.End()
 	}
 	delete(om.Operations, operationId)
 }
@@ -126,6 +216,7 @@ func (om *OperationManager) CleanupExpired() {
 			if op.cancel != nil {
 				op.cancel()
 			}
+			op.span.End()
 
 			delete(om.Operations, opId)
 
@@ -134,9 +225,18 @@ func (om *OperationManager) CleanupExpired() {
 	}
 }
 
-func buildOperation(req SyncRequest, i *Integration) *Operation {
+// The operation outlives the request that created it, so its context derives from the integration
+// while its span continues the trace of the first SyncDataHandler call.
+func buildOperation(reqCtx context.Context, req SyncRequest, i *Integration) *Operation {
 	idCache, cacheExisted := i.idStore.GetOrCreateIdCache(req.Account.RealmId)
-	ctx, cancel := context.WithCancel(i.ctx)
+	ctx := trace.ContextWithSpanContext(i.ctx, trace.SpanContextFromContext(reqCtx))
+	ctx = WithCorrelationId(ctx, CorrelationId(reqCtx))
+	ctx, span := startSpan(ctx, "sync.operation", trace.SpanKindInternal,
+		attrRealmId.String(req.Account.RealmId),
+		attrOperationId.String(req.OperationId),
+		attrType.StringSlice(req.Types),
+	)
+	ctx, cancel := context.WithCancel(ctx)
 	op := &Operation{
 		id:            req.OperationId,
 		integration:   i,
@@ -144,9 +244,18 @@ func buildOperation(req SyncRequest, i *Integration) *Operation {
 		idCache:       idCache,
 		account:       req.Account,
 		requestTypes:  make(map[string]fibery.Type, len(req.Types)),
+		lastSynced:    make(map[string]time.Time, len(req.Types)),
+		unsubmitted:   len(req.Types),
+		submitted:     make(map[string]struct{}, len(req.Types)),
 		sourceGroups:  make(map[string]*SourceGroup, len(req.Types)),
+		filter:        req.Filter,
+		reports:       make(map[string][]ReportPeriod),
+		realms:        make(map[string]OperationDataHandlerResponse),
+		created:       time.Now(),
 		lastRequest:   time.Now(),
 		chans:         make(map[string]chan OperationDataHandlerResponse, len(req.Types)),
+		completed:     make(map[string]struct{}, len(req.Types)),
+		span:          span,
 		ctx:           ctx,
 		cancel:        cancel,
 	}
@@ -157,6 +266,8 @@ func buildOperation(req SyncRequest, i *Integration) *Operation {
 
 func (op *Operation) propagateError(err error) {
 	op.cancel()
+	op.span.RecordError(err)
+	op.span.SetStatus(codes.Error, err.Error())
 
 	op.cleanupOnce.Do(func() {
 		op.Lock()
@@ -176,7 +287,7 @@ func (op *Operation) addSourceGroup(
 	source string,
 	reqType RequestType,
 	getAttachable bool,
-) {
+) *SourceGroup {
 	grp, ok := op.sourceGroups[source]
 	if !ok {
 		grp = &SourceGroup{
@@ -199,6 +310,7 @@ func (op *Operation) addSourceGroup(
 	}
 
 	op.sourceGroups[source] = grp
+	return grp
 }
 
 func (op *Operation) processTypeEntry(
@@ -236,6 +348,20 @@ func (op *Operation) processTypeEntry(
 		source = t.SourceType()
 		reqMode = Normal
 
+	case SnapshotType:
+		// snapshots ride along with change data capture instead of forcing their source into a full sync
+		source = t.SourceType()
+		if op.existingCache && !req.LastSyncronizedAt.IsZero() {
+			reqMode = ChangeDataCapture
+		} else {
+			reqMode = Normal
+		}
+
+	case ReportType, RealmType:
+		// reports and realm types are fetched on their own by doReports and doRealms and share no
+		// source group
+		return nil
+
 	default:
 		return fmt.Errorf(
 			"registered type %s not a supported interface",
@@ -243,7 +369,15 @@ func (op *Operation) processTypeEntry(
 		)
 	}
 
-	op.addSourceGroup(source, reqMode, getAttach)
+	// change data capture covers every type read from it, so it starts at the earliest of them
+	if reqMode == ChangeDataCapture && (op.changedSince.IsZero() || req.LastSyncronizedAt.Before(op.changedSince)) {
+		op.changedSince = req.LastSyncronizedAt
+	}
+
+	grp := op.addSourceGroup(source, reqMode, getAttach)
+	if _, ok := regType.(SnapshotType); ok {
+		grp.snapshot = true
+	}
 	return nil
 }
 
@@ -251,48 +385,92 @@ func ResponseChannelKey(id string, page int) string {
 	return fmt.Sprintf("%s:%d", id, page)
 }
 
-func (op *Operation) SubmitRequest(req SyncRequest) error {
-	defer op.wg.Done()
+func (op *Operation) SubmitRequest(ctx context.Context, req SyncRequest) error {
+	_, span := startSpan(ctx, "SubmitRequest", trace.SpanKindInternal,
+		attrRealmId.String(op.account.RealmId),
+		attrOperationId.String(op.id),
+		attrType.String(req.RequestedType),
+		attrPage.Int(req.Pagination.Page),
+	)
+	err := op.submitRequest(req)
+	endSpan(span, err)
+	return err
+}
 
+func (op *Operation) submitRequest(req SyncRequest) error {
 	op.Lock()
 	op.lastRequest = time.Now()
 	op.Unlock()
 
 	if req.Pagination.Page == 0 || req.Pagination.Page == 1 {
+		op.Lock()
+		_, resubmitted := op.submitted[req.RequestedType]
+		if !resubmitted {
+			op.submitted[req.RequestedType] = struct{}{}
+			op.unsubmitted--
+		}
+		op.Unlock()
+
+		if !resubmitted {
+			// only first page requests are counted when the operation is built, so a retried
+			// first page must not count again
+			defer op.wg.Done()
+		}
+
 		regType, exists := op.integration.types.Get(req.RequestedType)
 		if !exists {
 			return fmt.Errorf("requestedType: %s not found", req.RequestedType)
 		}
 
-		op.Lock()
+		disabled := op.featureDisabled(req.RequestedType)
 
-		op.requestTypes[req.RequestedType] = regType
+		op.Lock()
 
 		channelKey := ResponseChannelKey(req.RequestedType, 1)
 		if _, ok := op.chans[channelKey]; !ok {
 			op.chans[channelKey] = make(chan OperationDataHandlerResponse, 1)
 		}
 
+		if disabled {
+			op.Unlock()
+			op.completeChannel(channelKey, OperationDataHandlerResponse{
+				DataHandlerResponse: fibery.DataHandlerResponse{
+					Items:               []map[string]any{},
+					SynchronizationType: fibery.Full,
+				},
+			})
+			return nil
+		}
+
 		attachableFieldId := op.integration.config.AttachableFieldId
 
+		// static types are answered right away and never join the batch fetch
 		switch t := regType.(type) {
 		case StaticType:
-			resp := OperationDataHandlerResponse{
+			op.Unlock()
+			op.completeChannel(channelKey, OperationDataHandlerResponse{
 				DataHandlerResponse: fibery.DataHandlerResponse{
 					Items:               t.GetData(),
 					SynchronizationType: fibery.Full,
 				},
-			}
+			})
+			return nil
+		}
 
-			respChan := op.chans[channelKey]
+		if resubmitted {
+			// the type's pages are already being fetched and wait on their channels
+			op.Unlock()
+			return nil
+		}
 
-			respChan <- resp
+		op.requestTypes[req.RequestedType] = regType
 
-			return nil
+		switch t := regType.(type) {
 
 		case UnionType:
 			schema, ok := req.Schema[req.RequestedType]
 			if !ok {
+				op.Unlock()
 				return fmt.Errorf("no schema for %s was provided on the request", req.RequestedType)
 			}
 
@@ -305,6 +483,7 @@ func (op *Operation) SubmitRequest(req SyncRequest) error {
 				}
 
 				if err := op.processTypeEntry(sourceType, innerReq, getAttach); err != nil {
+					op.Unlock()
 					return err
 				}
 			}
@@ -312,19 +491,19 @@ func (op *Operation) SubmitRequest(req SyncRequest) error {
 		default:
 			schema, ok := req.Schema[req.RequestedType]
 			if !ok {
+				op.Unlock()
 				return fmt.Errorf("no schema for %s was provided on the request", req.RequestedType)
 			}
 
 			getAttach := attachableField(schema, attachableFieldId)
 
 			if err := op.processTypeEntry(regType, req, getAttach); err != nil {
+				op.Unlock()
 				return err
 			}
 		}
 
-		if op.lastSynced.After(req.LastSyncronizedAt) {
-			op.lastSynced = req.LastSyncronizedAt
-		}
+		op.lastSynced[req.RequestedType] = req.LastSyncronizedAt
 
 		op.Unlock()
 		slog.Debug(fmt.Sprintf("type: %s submitted, waitgroup decremented", req.RequestedType))
@@ -338,6 +517,20 @@ func (op *Operation) SubmitRequest(req SyncRequest) error {
 	return nil
 }
 
+// featureDisabled reports whether typeId depends on a feature the realm has turned off. Without
+// preferences the type is synced and QuickBooks decides.
+func (op *Operation) featureDisabled(typeId string) bool {
+	if _, ok := FeatureTypes[typeId]; !ok {
+		return false
+	}
+	prefs, err := op.integration.Preferences(op.ctx, op.account)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("unable to check the feature of %s: %s", typeId, err.Error()))
+		return false
+	}
+	return !FeatureTypes.Enabled(typeId, prefs)
+}
+
 func (op *Operation) GetChannel(key string) (<-chan OperationDataHandlerResponse, error) {
 	select {
 	case <-op.ctx.Done():
@@ -356,24 +549,69 @@ func (op *Operation) GetChannel(key string) (<-chan OperationDataHandlerResponse
 	return ch, nil
 }
 
+// ReleaseChannel drops a channel once its response has been read, removing the operation after the last page.
+func (op *Operation) ReleaseChannel(key string) {
+	op.Lock()
+	delete(op.chans, key)
+	delete(op.completed, key)
+	op.Unlock()
+
+	op.TryCleanup()
+}
+
+// completeChannel delivers resp at most once per key. The channel stays registered until it is
+// released so that a page dispatched before Fibery requests it can still be collected.
 func (op *Operation) completeChannel(key string, resp OperationDataHandlerResponse) {
 	op.Lock()
 	ch, ok := op.chans[key]
-	if ok {
-		delete(op.chans, key)
+	_, done := op.completed[key]
+	if ok && !done {
+		op.completed[key] = struct{}{}
 	}
 	op.Unlock()
 
-	if !ok {
+	if !ok || done {
 		return
 	}
 	ch <- resp
 	close(ch)
 }
 
+func (op *Operation) completePage(span trace.Span, typeId, key string, resp OperationDataHandlerResponse) {
+	span.AddEvent("page dispatched", trace.WithAttributes(
+		attrType.String(typeId),
+		attribute.Int("fibery.items", len(resp.Items)),
+		attribute.Bool("fibery.has_next", resp.Pagination.HasNext),
+	))
+	if resp.Error != nil {
+		span.RecordError(resp.Error)
+	}
+	op.completeChannel(key, resp)
+}
+
+// pendingTypes copies the types still waiting on pages, as List and TryCleanup read requestTypes
+// while pages are dispatched.
+func (op *Operation) pendingTypes() map[string]fibery.Type {
+	op.Lock()
+	defer op.Unlock()
+	types := make(map[string]fibery.Type, len(op.requestTypes))
+	for typeId, regType := range op.requestTypes {
+		types[typeId] = regType
+	}
+	return types
+}
+
+// finishType removes a type once its last page has been dispatched.
+func (op *Operation) finishType(typeId string) {
+	op.Lock()
+	delete(op.requestTypes, typeId)
+	op.Unlock()
+}
+
 func (op *Operation) TryCleanup() {
 	op.Lock()
-	shouldDelete := len(op.requestTypes) == 0
+	// types answered on submit never join requestTypes, so wait for every type to be submitted
+	shouldDelete := op.unsubmitted <= 0 && len(op.requestTypes) == 0 && len(op.chans) == 0
 	opId := op.id
 	op.Unlock()
 
@@ -386,6 +624,7 @@ func (op *Operation) TryCleanup() {
 func (op *Operation) indexBatchQuery(batch []quickbooks.BatchItemResponse) (map[string]struct{}, error) {
 	moreAttachables := map[string]struct{}{}
 	op.Lock()
+	defer op.Unlock()
 	for _, resp := range batch {
 		faults := resp.Fault.Faults
 		if len(faults) > 0 {
@@ -423,13 +662,12 @@ func (op *Operation) indexBatchQuery(batch []quickbooks.BatchItemResponse) (map[
 			sourceGroup.batchPages[startPosition] = &resp
 		}
 	}
-	op.Unlock()
 
 	return moreAttachables, nil
 }
 
 func (op *Operation) doBatch(req []quickbooks.BatchItemRequest, params quickbooks.RequestParameters) {
-	client := op.integration.client
+	batcher := op.integration.batcher
 
 	page := 1
 
@@ -437,8 +675,22 @@ func (op *Operation) doBatch(req []quickbooks.BatchItemRequest, params quickbook
 
 	for {
 		slog.Debug("in loop")
-		batch, err := client.BatchRequest(params, req)
+		ctx, span := startSpan(params.Ctx, "doBatch", trace.SpanKindInternal,
+			attrRealmId.String(op.account.RealmId),
+			attrOperationId.String(op.id),
+			attrPage.Int(page),
+			attribute.Int("qbo.batch_items", len(req)),
+		)
+		pageParams := params
+		pageParams.Ctx = ctx
+
+		var batch []quickbooks.BatchItemResponse
+		err := waitOutRejections(ctx, func() (err error) {
+			batch, err = batcher.Do(pageParams, req)
+			return err
+		})
 		if err != nil {
+			endSpan(span, err)
 			slog.Error(fmt.Sprintf("error fetching inital batch: %s", err.Error()))
 			op.propagateError(fmt.Errorf("error fetching inital batch: %w", err))
 			return
@@ -447,6 +699,7 @@ func (op *Operation) doBatch(req []quickbooks.BatchItemRequest, params quickbook
 		slog.Debug("batch request complete")
 
 		nextAttachEntities, err := op.indexBatchQuery(batch)
+		endSpan(span, err)
 		if err != nil {
 			slog.Error(fmt.Sprintf("error indexing inital batch: %s", err.Error()))
 			op.propagateError(fmt.Errorf("error indexing inital batch: %w", err))
@@ -474,7 +727,20 @@ func (op *Operation) doBatch(req []quickbooks.BatchItemRequest, params quickbook
 func (op *Operation) doCDC(req []string, params quickbooks.RequestParameters) {
 	client := op.integration.client
 
-	cdc, err := client.ChangeDataCapture(params, req, op.lastSynced)
+	ctx, span := startSpan(params.Ctx, "doCDC", trace.SpanKindInternal,
+		attrRealmId.String(op.account.RealmId),
+		attrOperationId.String(op.id),
+		attribute.StringSlice("qbo.entities", req),
+		attribute.String("qbo.changed_since", op.changedSince.Format(time.RFC3339)),
+	)
+	params.Ctx = ctx
+
+	var cdc quickbooks.ChangeDataCapture
+	err := waitOutRejections(ctx, func() (err error) {
+		cdc, err = client.ChangeDataCapture(params, req, op.changedSince)
+		return err
+	})
+	endSpan(span, err)
 	if err != nil {
 		op.propagateError(fmt.Errorf("error fetching cdc: %w", err))
 	}
@@ -486,13 +752,90 @@ func (op *Operation) doCDC(req []string, params quickbooks.RequestParameters) {
 	slog.Debug("inital cdc complete")
 }
 
+// doReports runs each report type's report once per period, outside of the batch and cdc
+// requests.
+func (op *Operation) doReports(types []ReportType, params quickbooks.RequestParameters) {
+	client := op.integration.client
+
+	for _, t := range types {
+		for _, period := range t.Periods(op.filter) {
+			ctx, span := startSpan(params.Ctx, "doReport", trace.SpanKindInternal,
+				attrRealmId.String(op.account.RealmId),
+				attrOperationId.String(op.id),
+				attribute.String("qbo.report", t.Report()),
+				attribute.String("qbo.report_period", period),
+			)
+			reportParams := params
+			reportParams.Ctx = ctx
+
+			var report *quickbooks.Report
+			err := waitOutRejections(ctx, func() (err error) {
+				report, err = client.FindReport(reportParams, t.Report(), t.Query(period))
+				return err
+			})
+			endSpan(span, err)
+			if err != nil {
+				op.propagateError(fmt.Errorf("error fetching %s report for %s: %w", t.Report(), period, err))
+				return
+			}
+
+			op.Lock()
+			op.reports[t.Id()] = append(op.reports[t.Id()], ReportPeriod{Period: period, Report: report})
+			op.Unlock()
+		}
+	}
+	slog.Debug("inital reports complete")
+}
+
+// doRealms reads each realm type directly from QuickBooks. A failed realm type only fails its
+// own page, as the realm data is independent of the other types.
+func (op *Operation) doRealms(types []RealmType, params quickbooks.RequestParameters) {
+	client := op.integration.client
+
+	for _, t := range types {
+		ctx, span := startSpan(params.Ctx, "doRealm", trace.SpanKindInternal,
+			attrRealmId.String(op.account.RealmId),
+			attrOperationId.String(op.id),
+			attrType.String(t.Id()),
+		)
+		realmParams := params
+		realmParams.Ctx = ctx
+
+		var items []map[string]any
+		err := waitOutRejections(ctx, func() (err error) {
+			items, err = t.GetRealmData(client, realmParams, op.filter)
+			return err
+		})
+		endSpan(span, err)
+
+		op.Lock()
+		op.realms[t.Id()] = OperationDataHandlerResponse{
+			DataHandlerResponse: fibery.DataHandlerResponse{
+				Items:               items,
+				SynchronizationType: fibery.Full,
+			},
+			Error: err,
+		}
+		op.Unlock()
+	}
+	slog.Debug("inital realm data complete")
+}
+
 func (op *Operation) fetchAll() {
 	slog.Debug("fetch started")
 
+	ctx, span := startSpan(op.ctx, "fetchAll", trace.SpanKindInternal,
+		attrRealmId.String(op.account.RealmId),
+		attrOperationId.String(op.id),
+	)
+	defer span.End()
+
 	var (
 		initalFetch sync.WaitGroup
 		batchReq    []quickbooks.BatchItemRequest
 		cdcReq      []string
+		reportReq   []ReportType
+		realmReq    []RealmType
 	)
 
 	page := 1
@@ -506,16 +849,30 @@ func (op *Operation) fetchAll() {
 		switch group.request {
 		case ChangeDataCapture:
 			cdcReq = append(cdcReq, sourceType)
+			if group.snapshot {
+				// snapshots record every source item, not only the changed ones
+				req := batchQueryRequest(sourceType, nil, page, pageSize, false)
+				batchReq = append(batchReq, req)
+			}
 		case Normal:
 			req := batchQueryRequest(sourceType, nil, page, pageSize, false)
 			batchReq = append(batchReq, req)
 		}
 	}
 
-	time.Sleep(30 * time.Second)
+	for _, regType := range op.pendingTypes() {
+		switch t := regType.(type) {
+		case ReportType:
+			reportReq = append(reportReq, t)
+		case RealmType:
+			realmReq = append(realmReq, t)
+		}
+	}
+
+	time.Sleep(op.integration.config.FetchDelay)
 
 	params := quickbooks.RequestParameters{
-		Ctx:             op.ctx,
+		Ctx:             ctx,
 		RealmId:         op.account.RealmId,
 		Token:           &op.account.BearerToken,
 		WaitOnRateLimit: true,
@@ -539,16 +896,34 @@ func (op *Operation) fetchAll() {
 		}(batchReq)
 	}
 
+	if len(reportReq) > 0 {
+		slog.Debug("making report requests")
+		initalFetch.Add(1)
+		go func(req []ReportType) {
+			defer initalFetch.Done()
+			op.doReports(req, params)
+		}(reportReq)
+	}
+
+	if len(realmReq) > 0 {
+		slog.Debug("making realm requests")
+		initalFetch.Add(1)
+		go func(req []RealmType) {
+			defer initalFetch.Done()
+			op.doRealms(req, params)
+		}(realmReq)
+	}
+
 	initalFetch.Wait()
 
 	slog.Debug("inital fetch complete")
 
-	op.dispatchPages()
+	op.dispatchPages(ctx)
 }
 
-func (op *Operation) dispatchPages() {
+func (op *Operation) dispatchPages(ctx context.Context) {
 	params := quickbooks.RequestParameters{
-		Ctx:             op.ctx,
+		Ctx:             ctx,
 		RealmId:         op.account.RealmId,
 		Token:           &op.account.BearerToken,
 		WaitOnRateLimit: true,
@@ -556,12 +931,24 @@ func (op *Operation) dispatchPages() {
 	page := 1
 	pageSize := op.integration.config.QuickBooks.PageSize
 
-	for len(op.requestTypes) > 0 {
+	for {
+		types := op.pendingTypes()
+		if len(types) == 0 {
+			break
+		}
+
 		nextBatchSources := map[string]struct{}{}
+		_, pageSpan := startSpan(ctx, "dispatchPage", trace.SpanKindInternal,
+			attrRealmId.String(op.account.RealmId),
+			attrOperationId.String(op.id),
+			attrPage.Int(page),
+		)
 
-		for typeId, regType := range op.requestTypes {
+		for typeId, regType := range types {
 			key := ResponseChannelKey(typeId, page)
+			op.Lock()
 			_, ok := op.chans[key]
+			op.Unlock()
 			if !ok {
 				op.propagateError(fmt.Errorf("missing channel %s", key))
 				break
@@ -577,6 +964,8 @@ func (op *Operation) dispatchPages() {
 				src = t.Type()
 			case StandardDependentType:
 				src = t.SourceType()
+			case SnapshotType:
+				src = t.SourceType()
 			case UnionType:
 				request := ChangeDataCapture
 				batchResponses := make(map[string]*quickbooks.BatchItemResponse)
@@ -592,6 +981,10 @@ func (op *Operation) dispatchPages() {
 						request = Normal
 
 						batchData, ok := sg.batchPages[page]
+						if !ok && page > 1 {
+							// source ran out of pages before the other union sources
+							continue
+						}
 						if !ok {
 							op.propagateError(fmt.Errorf("no batch data for sourceGroup %s, page %d", sourceType.Type(), page))
 							break
@@ -621,7 +1014,7 @@ func (op *Operation) dispatchPages() {
 					}
 
 					resp.DataHandlerResponse.Pagination.HasNext = false
-					op.completeChannel(key, resp)
+					op.completePage(pageSpan, typeId, key, resp)
 
 					for _, sourceType := range t.Types() {
 						sg := op.sourceGroups[sourceType.Type()]
@@ -631,7 +1024,7 @@ func (op *Operation) dispatchPages() {
 						}
 					}
 
-					delete(op.requestTypes, t.Id())
+					op.finishType(t.Id())
 				case Normal:
 					items, moreSource, err := t.ProcessBatchQuery(batchResponses, pageSize)
 					if err != nil {
@@ -647,17 +1040,19 @@ func (op *Operation) dispatchPages() {
 
 					if len(moreSource) > 0 {
 						nextKey := ResponseChannelKey(t.Id(), page+1)
+						op.Lock()
 						op.chans[nextKey] = make(chan OperationDataHandlerResponse, 1)
+						op.Unlock()
 						for sourceType := range moreSource {
 							nextBatchSources[sourceType] = struct{}{}
 						}
 
 						resp.DataHandlerResponse.Pagination.HasNext = true
 						resp.DataHandlerResponse.Pagination.NextPageConfig.Page = page + 1
-						op.completeChannel(key, resp)
+						op.completePage(pageSpan, typeId, key, resp)
 					} else {
 						resp.DataHandlerResponse.Pagination.HasNext = false
-						op.completeChannel(key, resp)
+						op.completePage(pageSpan, typeId, key, resp)
 
 						for _, sourceType := range t.Types() {
 							sg := op.sourceGroups[sourceType.Type()]
@@ -667,10 +1062,34 @@ func (op *Operation) dispatchPages() {
 							}
 						}
 
-						delete(op.requestTypes, t.Id())
+						op.finishType(t.Id())
 					}
 				}
 				continue
+			case ReportType:
+				op.Lock()
+				reports := op.reports[typeId]
+				op.Unlock()
+
+				items, err := t.ProcessReports(reports)
+				op.completePage(pageSpan, typeId, key, OperationDataHandlerResponse{
+					Error: err,
+					DataHandlerResponse: fibery.DataHandlerResponse{
+						Items:               items,
+						SynchronizationType: fibery.Full,
+					},
+				})
+
+				op.finishType(typeId)
+				continue
+			case RealmType:
+				op.Lock()
+				resp := op.realms[typeId]
+				op.Unlock()
+
+				op.completePage(pageSpan, typeId, key, resp)
+				op.finishType(typeId)
+				continue
 			default:
 				op.propagateError(fmt.Errorf("unsupported type %T", regType))
 				break
@@ -688,7 +1107,13 @@ func (op *Operation) dispatchPages() {
 				more bool
 			)
 
-			switch grp.request {
+			request := grp.request
+			if _, ok := regType.(SnapshotType); ok {
+				// snapshots page through the batch query of their source whatever its request
+				request = Normal
+			}
+
+			switch request {
 			case ChangeDataCapture:
 				switch t := regType.(type) {
 				case CDCType:
@@ -753,6 +1178,24 @@ func (op *Operation) dispatchPages() {
 						Pagination:          fibery.Pagination{HasNext: more},
 					}
 
+				case SnapshotType:
+					var items []map[string]any
+					items, more, err = t.ProcessSnapshot(
+						batchResp,
+						op.created,
+						pageSize,
+					)
+					syncType := fibery.Full
+					if !op.lastSynced[typeId].IsZero() {
+						// keep the snapshots of earlier syncs
+						syncType = fibery.Delta
+					}
+					resp = fibery.DataHandlerResponse{
+						Items:               items,
+						SynchronizationType: syncType,
+						Pagination:          fibery.Pagination{HasNext: more},
+					}
+
 				default:
 					op.propagateError(fmt.Errorf("type %T not Batch-capable", regType))
 					break
@@ -761,24 +1204,26 @@ func (op *Operation) dispatchPages() {
 				if more {
 					resp.Pagination.NextPageConfig.Page = page + 1
 					nextKey := ResponseChannelKey(typeId, page+1)
+					op.Lock()
 					op.chans[nextKey] = make(chan OperationDataHandlerResponse, 1)
+					op.Unlock()
 					nextBatchSources[src] = struct{}{}
 				}
 			}
 
-			op.completeChannel(key, OperationDataHandlerResponse{Error: err, DataHandlerResponse: resp})
+			op.completePage(pageSpan, typeId, key, OperationDataHandlerResponse{Error: err, DataHandlerResponse: resp})
 
-			grp.expectedUses--
-			if grp.expectedUses == 0 {
-				delete(op.sourceGroups, src)
-			}
-
-			if grp.request == ChangeDataCapture || !more {
-				delete(op.requestTypes, typeId)
+			if request == ChangeDataCapture || !more {
+				grp.expectedUses--
+				if grp.expectedUses == 0 {
+					delete(op.sourceGroups, src)
+				}
+				op.finishType(typeId)
 			}
 		}
 
 		slog.Debug("inital dispatch")
+		pageSpan.End()
 
 		if len(nextBatchSources) == 0 {
 			break
@@ -791,12 +1236,28 @@ func (op *Operation) dispatchPages() {
 				batchQueryRequest(src, nil, page, pageSize, false),
 			)
 		}
-		resps, err := op.integration.client.BatchRequest(params, batchReqs)
+
+		fetchCtx, fetchSpan := startSpan(ctx, "fetchPage", trace.SpanKindInternal,
+			attrRealmId.String(op.account.RealmId),
+			attrOperationId.String(op.id),
+			attrPage.Int(page),
+			attribute.Int("qbo.batch_items", len(batchReqs)),
+		)
+		params.Ctx = fetchCtx
+
+		var resps []quickbooks.BatchItemResponse
+		err := waitOutRejections(fetchCtx, func() (err error) {
+			resps, err = op.integration.batcher.Do(params, batchReqs)
+			return err
+		})
 		if err != nil {
+			endSpan(fetchSpan, err)
 			op.propagateError(fmt.Errorf("batch page %d failed: %w", page, err))
 			break
 		}
-		if _, err := op.indexBatchQuery(resps); err != nil {
+		_, err = op.indexBatchQuery(resps)
+		endSpan(fetchSpan, err)
+		if err != nil {
 			op.propagateError(fmt.Errorf("indexing page %d: %w", page, err))
 			break
 		}
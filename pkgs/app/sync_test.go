@@ -0,0 +1,179 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+)
+
+// testReportType is a report type whose pages are dispatched without any QuickBooks data.
+type testReportType struct {
+	id string
+}
+
+func (t testReportType) Id() string                             { return t.id }
+func (t testReportType) Name() string                           { return t.id }
+func (t testReportType) Schema() map[string]fibery.Field        { return nil }
+func (t testReportType) Report() string                         { return t.id }
+func (t testReportType) Periods(filter map[string]any) []string { return nil }
+func (t testReportType) Query(period string) url.Values         { return nil }
+
+func (t testReportType) ProcessReports(reports []ReportPeriod) ([]map[string]any, error) {
+	return nil, nil
+}
+
+// whileDispatchBlocked dispatches a single report type and runs during while dispatch is blocked
+// between completing the type's last page and finishing the type: the page's channel is already
+// full, so its send waits until the test drains it. during runs on its own goroutine and is not
+// synchronized with the drain, so running with -race flags any access to the operation's types
+// made without its lock.
+func whileDispatchBlocked(t *testing.T, op *Operation, during func()) {
+	t.Helper()
+	key := ResponseChannelKey("report", 1)
+	page := make(chan OperationDataHandlerResponse, 1)
+	page <- OperationDataHandlerResponse{}
+	op.requestTypes["report"] = testReportType{id: "report"}
+	op.chans[key] = page
+
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		op.dispatchPages(op.ctx)
+	}()
+
+	pageCompleted := func() bool {
+		op.Lock()
+		defer op.Unlock()
+		_, ok := op.completed[key]
+		return ok
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for !pageCompleted() {
+			runtime.Gosched()
+		}
+		during()
+	}()
+
+	for !pageCompleted() {
+		runtime.Gosched()
+	}
+	time.Sleep(50 * time.Millisecond)
+	<-page
+	<-page
+	<-dispatched
+	wg.Wait()
+}
+
+func TestListDuringDispatch(t *testing.T) {
+	t.Parallel()
+	i, _ := newAdminTestIntegration(t)
+
+	req := SyncRequest{
+		OperationId: "op-list",
+		Account:     QuickBooksAccountInfo{RealmId: "realm-1"},
+	}
+	op, err := i.opManager.GetOrAddOperation(context.Background(), req, i)
+	if err != nil {
+		t.Fatalf("unexpected error creating operation: %v", err)
+	}
+
+	whileDispatchBlocked(t, op, func() {
+		if ops := i.opManager.List(); len(ops) != 1 {
+			t.Errorf("expected the operation to be listed, got %+v", ops)
+		}
+	})
+
+	if ops := i.opManager.List(); len(ops) != 1 || len(ops[0].Types) != 0 {
+		t.Errorf("expected every type to be dispatched, got %+v", ops)
+	}
+}
+
+func TestTryCleanupDuringDispatch(t *testing.T) {
+	t.Parallel()
+	i, _ := newAdminTestIntegration(t)
+
+	req := SyncRequest{
+		OperationId: "op-cleanup",
+		Account:     QuickBooksAccountInfo{RealmId: "realm-1"},
+	}
+	op, err := i.opManager.GetOrAddOperation(context.Background(), req, i)
+	if err != nil {
+		t.Fatalf("unexpected error creating operation: %v", err)
+	}
+
+	whileDispatchBlocked(t, op, op.TryCleanup)
+	if i.opManager.Len() != 1 {
+		t.Fatal("expected the operation to be kept until its last page is released")
+	}
+
+	op.ReleaseChannel(ResponseChannelKey("report", 1))
+	if i.opManager.Len() != 0 {
+		t.Error("expected the operation to be removed once its last page was released")
+	}
+}
+
+func TestOperationLastSynced(t *testing.T) {
+	t.Parallel()
+	earlier := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+
+	// each type keeps its own lastSynchronizedAt, so a full sync of one type leaves the others delta
+	for name, tc := range map[string]struct {
+		submitted []time.Time
+	}{
+		"full then delta":    {submitted: []time.Time{{}, later}},
+		"delta then full":    {submitted: []time.Time{later, {}}},
+		"later then earlier": {submitted: []time.Time{later, earlier}},
+		"earlier then later": {submitted: []time.Time{earlier, later}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			i, _ := newAdminTestIntegration(t)
+			i.types = TypeRegistry{}
+
+			req := SyncRequest{
+				OperationId: "op-" + name,
+				Account:     QuickBooksAccountInfo{RealmId: "realm-1"},
+				Schema:      map[string]map[string]fibery.Field{},
+			}
+			for n := range tc.submitted {
+				typeId := fmt.Sprintf("report%d", n)
+				i.types.Register(testReportType{id: typeId})
+				req.Types = append(req.Types, typeId)
+				req.Schema[typeId] = map[string]fibery.Field{}
+			}
+			op, err := i.opManager.GetOrAddOperation(context.Background(), req, i)
+			if err != nil {
+				t.Fatalf("unexpected error creating operation: %v", err)
+			}
+
+			for n, lastSynced := range tc.submitted {
+				typeReq := req
+				typeReq.RequestedType = req.Types[n]
+				typeReq.LastSyncronizedAt = lastSynced
+				typeReq.Pagination.Page = 1
+				if err := op.submitRequest(typeReq); err != nil {
+					t.Fatalf("unable to submit %s: %v", typeReq.RequestedType, err)
+				}
+			}
+
+			op.Lock()
+			defer op.Unlock()
+			for n, want := range tc.submitted {
+				if got := op.lastSynced[req.Types[n]]; !got.Equal(want) {
+					t.Errorf("expected %s last synced %v, got %v", req.Types[n], want, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingPropagatesCorrelationId(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(t.Context()) })
+
+	var outbound http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outbound = r.Header.Clone()
+	}))
+	defer upstream.Close()
+
+	client := &http.Client{Transport: NewTracingTransport(nil)}
+	handler := tracingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, upstream.URL+"/v3/company/1/batch", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("unexpected upstream error: %v", err)
+			return
+		}
+		resp.Body.Close()
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/synchronizer/data", nil)
+	req.Header.Set(CorrelationIdHeader, "corr-123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := outbound.Get(CorrelationIdHeader); got != "corr-123" {
+		t.Errorf("expected outbound correlation id corr-123, got %q", got)
+	}
+	if outbound.Get("Traceparent") == "" {
+		t.Error("expected outbound traceparent header")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	for _, span := range spans {
+		found := false
+		for _, attr := range span.Attributes {
+			if attr.Key == "correlation_id" && attr.Value.AsString() == "corr-123" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("span %s missing correlation_id attribute", span.Name)
+		}
+	}
+	if spans[0].Parent.SpanID() != spans[1].SpanContext.SpanID() {
+		t.Error("expected outbound span to be a child of the request span")
+	}
+}
@@ -79,6 +79,7 @@ type UnionType interface {
 	Webhook() bool
 	ProcessBatchQuery(batches map[string]*quickbooks.BatchItemResponse, pageSize int) ([]map[string]any, map[string]struct{}, error)
 	ProcessCDCQuery(cdc *quickbooks.ChangeDataCapture, pageSize int) ([]map[string]any, error)
+	ProcessWebhookUpdates(batches map[string]*quickbooks.BatchItemResponse, pageSize int) ([]map[string]any, map[string]struct{}, error)
 	ProcessWebhookDeletions(deletedSources map[string][]string) ([]map[string]any, error)
 }
 
@@ -87,6 +88,13 @@ type StaticType interface {
 	GetData() []map[string]any
 }
 
+// RealmType describes the realm itself, such as its company info or exchange rates, read
+// directly from QuickBooks instead of through a batch query. It is always synced in full.
+type RealmType interface {
+	fibery.Type
+	GetRealmData(client *Client, params quickbooks.RequestParameters, filter map[string]any) ([]map[string]any, error)
+}
+
 type StandardData[T any] struct {
 	Item        T
 	Attachables map[string][]quickbooks.Attachable
@@ -116,6 +124,7 @@ type StandardTypeDef[T any] struct {
 	TypeId              string
 	FiberyId            string
 	FiberyName          string
+	ItemCheck           func(T) bool
 	Fields              map[string]FieldDef[T]
 	BatchItemExtractor  func(quickbooks.BatchItemResponse) T
 	BatchQueryExtractor func(quickbooks.BatchQueryResponse) []T
@@ -185,6 +194,20 @@ type StaticTypeDef struct {
 	Data       []map[string]any
 }
 
+type RealmTypeDef[T any] struct {
+	FiberyId   string
+	FiberyName string
+	Fields     map[string]FieldDef[T]
+	Fetch      func(*Client, quickbooks.RequestParameters) (T, error)
+}
+
+type RealmListTypeDef[T any] struct {
+	FiberyId   string
+	FiberyName string
+	Fields     map[string]FieldDef[T]
+	Fetch      func(*Client, quickbooks.RequestParameters, map[string]any) ([]T, error)
+}
+
 func NewStandardType[T any](
 	typeId, fiberyId, fiberyName string,
 	itemId func(T) string,
@@ -450,6 +473,64 @@ func NewStaticType(
 	}
 }
 
+func NewRealmType[T any](
+	fiberyId, fiberyName string,
+	itemId func(T) string,
+	fetch func(*Client, quickbooks.RequestParameters) (T, error),
+	addlFields map[string]FieldDef[T],
+) *RealmTypeDef[T] {
+	fields := make(map[string]FieldDef[T], len(addlFields)+1)
+	for k, v := range addlFields {
+		fields[k] = v
+	}
+	fields["id"] = FieldDef[T]{
+		Params: fibery.Field{
+			Name: "Id",
+			Type: fibery.Id,
+		},
+		Convert: func(sd StandardData[T]) (any, error) {
+			return itemId(sd.Item), nil
+		},
+	}
+
+	return &RealmTypeDef[T]{
+		FiberyId:   fiberyId,
+		FiberyName: fiberyName,
+		Fields:     fields,
+		Fetch:      fetch,
+	}
+}
+
+// NewRealmListType builds a realm type with one item per value fetch returns. Fetch is handed
+// the sync filter so it can decide what to read.
+func NewRealmListType[T any](
+	fiberyId, fiberyName string,
+	itemId func(T) string,
+	fetch func(*Client, quickbooks.RequestParameters, map[string]any) ([]T, error),
+	addlFields map[string]FieldDef[T],
+) *RealmListTypeDef[T] {
+	fields := make(map[string]FieldDef[T], len(addlFields)+1)
+	for k, v := range addlFields {
+		fields[k] = v
+	}
+	fields["id"] = FieldDef[T]{
+		Params: fibery.Field{
+			Name: "Id",
+			Type: fibery.Id,
+		},
+		Convert: func(sd StandardData[T]) (any, error) {
+			return itemId(sd.Item), nil
+		},
+	}
+
+	return &RealmListTypeDef[T]{
+		FiberyId:   fiberyId,
+		FiberyName: fiberyName,
+		Fields:     fields,
+		Fetch:      fetch,
+	}
+}
+
 // --- StandardTypeDef[T] methods ---
 
 func (t *StandardTypeDef[T]) Id() string {
@@ -465,7 +546,7 @@ func (t *StandardTypeDef[T]) Schema() map[string]fibery.Field {
 	for id, field := range t.Fields {
 		schema[id] = field.Params
 	}
-	return schema
+	return FieldMappings.Schema(t.FiberyId, schema)
 }
 
 func (t *StandardTypeDef[T]) Type() string {
@@ -486,7 +567,7 @@ func (t *StandardTypeDef[T]) Convert(data StandardData[T]) (map[string]any, erro
 		}
 		output[id] = fieldValue
 	}
-	return output, nil
+	return FieldMappings.Item(t.FiberyId, output), nil
 }
 
 func (t *StandardTypeDef[T]) extractBatchQuery(batch *quickbooks.BatchItemResponse) []T {
@@ -501,6 +582,11 @@ func (t *StandardTypeDef[T]) ProcessBatchQuery(batch *quickbooks.BatchItemRespon
 	output := make([]map[string]any, 0, len(input))
 
 	for _, i := range input {
+		// a nil check keeps every item
+		if t.ItemCheck != nil && !t.ItemCheck(i) {
+			continue
+		}
+
 		data := StandardData[T]{
 			Item:        i,
 			Attachables: attachables,
@@ -540,7 +626,7 @@ func (t *CDCTypeDef[T]) Convert(data StandardData[T]) (map[string]any, error) {
 		}
 		output[id] = fieldValue
 	}
-	return output, nil
+	return FieldMappings.Item(t.FiberyId, output), nil
 }
 
 // func (t *CDCTypeDef[T]) extractBatchQuery(batch *quickbooks.BatchItemResponse) []T
@@ -691,7 +777,7 @@ func (t *DependentTypeDef[ST, T]) Schema() map[string]fibery.Field {
 	for id, field := range t.Fields {
 		schema[id] = field.Params
 	}
-	return schema
+	return FieldMappings.Schema(t.FiberyId, schema)
 }
 
 func (t *DependentTypeDef[ST, T]) SourceType() string {
@@ -707,7 +793,7 @@ func (t *DependentTypeDef[ST, T]) Convert(data DependentData[ST, T]) (map[string
 		}
 		output[id] = fieldValue
 	}
-	return output, nil
+	return FieldMappings.Item(t.FiberyId, output), nil
 }
 
 func (t *DependentTypeDef[ST, T]) extractBatchQuery(batch *quickbooks.BatchItemResponse) []ST {
@@ -1009,7 +1095,7 @@ func (t *UnionTypeDef) Schema() map[string]fibery.Field {
 	for id, field := range t.Fields {
 		schema[id] = field.Params
 	}
-	return schema
+	return FieldMappings.Schema(t.FiberyId, schema)
 }
 
 func (t *UnionTypeDef) Convert(typeId string, item map[string]any) (map[string]any, error) {
@@ -1021,7 +1107,7 @@ func (t *UnionTypeDef) Convert(typeId string, item map[string]any) (map[string]a
 		}
 		output[id] = fieldValue
 	}
-	return output, nil
+	return FieldMappings.Item(t.FiberyId, output), nil
 }
 
 func (t *UnionTypeDef) Types() []StandardType {
@@ -1102,6 +1188,12 @@ func (t *UnionTypeDef) ProcessCDCQuery(cdc *quickbooks.ChangeDataCapture, pageSi
 	return output, nil
 }
 
+// ProcessWebhookUpdates converts the updated source items. Each source item is a single union
+// item, so an update never leaves one to remove.
+func (t *UnionTypeDef) ProcessWebhookUpdates(batches map[string]*quickbooks.BatchItemResponse, pageSize int) ([]map[string]any, map[string]struct{}, error) {
+	return t.ProcessBatchQuery(batches, pageSize)
+}
+
 func (t *UnionTypeDef) ProcessWebhookDeletions(deletedSources map[string][]string) ([]map[string]any, error) {
 	if field, ok := t.Fields["id"]; ok {
 		output := make([]map[string]any, 0)
@@ -1135,9 +1227,96 @@ func (t *StaticTypeDef) Name() string {
 }
 
 func (t *StaticTypeDef) Schema() map[string]fibery.Field {
-	return t.Fields
+	return FieldMappings.Schema(t.FiberyId, t.Fields)
 }
 
 func (t *StaticTypeDef) GetData() []map[string]any {
-	return t.Data
+	if _, ok := FieldMappings[t.FiberyId]; !ok {
+		return t.Data
+	}
+	data := make([]map[string]any, 0, len(t.Data))
+	for _, item := range t.Data {
+		mapped := make(map[string]any, len(item))
+		for k, v := range item {
+			mapped[k] = v
+		}
+		data = append(data, FieldMappings.Item(t.FiberyId, mapped))
+	}
+	return data
+}
+
+// --- RealmTypeDef[T] methods ---
+
+func (t *RealmTypeDef[T]) Id() string {
+	return t.FiberyId
+}
+
+func (t *RealmTypeDef[T]) Name() string {
+	return t.FiberyName
+}
+
+func (t *RealmTypeDef[T]) Schema() map[string]fibery.Field {
+	schema := make(map[string]fibery.Field, len(t.Fields))
+	for id, field := range t.Fields {
+		schema[id] = field.Params
+	}
+	return FieldMappings.Schema(t.FiberyId, schema)
+}
+
+func (t *RealmTypeDef[T]) GetRealmData(client *Client, params quickbooks.RequestParameters, _ map[string]any) ([]map[string]any, error) {
+	item, err := t.Fetch(client, params)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %w", t.FiberyId, err)
+	}
+
+	output := make(map[string]any, len(t.Fields))
+	for id, field := range t.Fields {
+		fieldValue, err := field.Convert(StandardData[T]{Item: item})
+		if err != nil {
+			return nil, fmt.Errorf("error converting input data: %w", err)
+		}
+		output[id] = fieldValue
+	}
+
+	return []map[string]any{FieldMappings.Item(t.FiberyId, output)}, nil
+}
+
+// --- RealmListTypeDef[T] methods ---
+
+func (t *RealmListTypeDef[T]) Id() string {
+	return t.FiberyId
+}
+
+func (t *RealmListTypeDef[T]) Name() string {
+	return t.FiberyName
+}
+
+func (t *RealmListTypeDef[T]) Schema() map[string]fibery.Field {
+	schema := make(map[string]fibery.Field, len(t.Fields))
+	for id, field := range t.Fields {
+		schema[id] = field.Params
+	}
+	return FieldMappings.Schema(t.FiberyId, schema)
+}
+
+func (t *RealmListTypeDef[T]) GetRealmData(client *Client, params quickbooks.RequestParameters, filter map[string]any) ([]map[string]any, error) {
+	items, err := t.Fetch(client, params, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %w", t.FiberyId, err)
+	}
+
+	output := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		o := make(map[string]any, len(t.Fields))
+		for id, field := range t.Fields {
+			fieldValue, err := field.Convert(StandardData[T]{Item: item})
+			if err != nil {
+				return nil, fmt.Errorf("error converting input data: %w", err)
+			}
+			o[id] = fieldValue
+		}
+		output = append(output, FieldMappings.Item(t.FiberyId, o))
+	}
+
+	return output, nil
 }
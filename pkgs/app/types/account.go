@@ -1,7 +1,7 @@
 package types
 
 import (
-	"fmt"
+	"encoding/json"
 	"regexp"
 
 	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
@@ -9,6 +9,94 @@ import (
 	"github.com/tommyhedley/quickbooks-go"
 )
 
+var accountTypes = []string{
+	"Bank",
+	"Other Current Asset",
+	"Fixed Asset",
+	"Other Asset",
+	"Accounts Receivable",
+	"Equity",
+	"Expense",
+	"Other Expense",
+	"Cost of Goods Sold",
+	"Accounts Payable",
+	"Credit Card",
+	"Long Term Liability",
+	"Other Current Liability",
+	"Income",
+	"Other Income",
+}
+
+var accountSubTypes = []string{
+	// Bank
+	"CashOnHand", "Checking", "MoneyMarket", "RentsHeldInTrust", "Savings", "TrustAccounts",
+	// Other Current Asset
+	"AllowanceForBadDebts", "DevelopmentCosts", "EmployeeCashAdvances", "OtherCurrentAssets", "Inventory",
+	"Investment_MortgageRealEstateLoans", "Investment_Other", "Investment_TaxExemptSecurities",
+	"Investment_USGovernmentObligations", "LoansToOfficers", "LoansToOthers", "LoansToStockholders",
+	"PrepaidExpenses", "Retainage", "UndepositedFunds",
+	// Fixed Asset
+	"AccumulatedDepletion", "AccumulatedDepreciation", "DepletableAssets", "FixedAssetComputers",
+	"FixedAssetCopiers", "FixedAssetFurniture", "FixedAssetPhone", "FixedAssetPhotoVideo",
+	"FixedAssetSoftware", "FixedAssetOtherToolsEquipment", "FurnitureAndFixtures", "Land",
+	"LeaseholdImprovements", "OtherFixedAssets", "AccumulatedAmortization", "Buildings",
+	"IntangibleAssets", "MachineryAndEquipment", "Vehicles",
+	// Other Asset
+	"LeaseBuyout", "OtherLongTermAssets", "SecurityDeposits", "AccumulatedAmortizationOfOtherAssets",
+	"Goodwill", "Licenses", "OrganizationalCosts",
+	// Accounts Receivable
+	"AccountsReceivable",
+	// Equity
+	"OpeningBalanceEquity", "PartnersEquity", "RetainedEarnings", "AccumulatedAdjustment", "OwnersEquity",
+	"PaidInCapitalOrSurplus", "PartnerContributions", "PartnerDistributions", "PreferredStock",
+	"CommonStock", "TreasuryStock", "EstimatedTaxes", "Healthcare", "PersonalIncome", "PersonalExpense",
+	// Income
+	"NonProfitIncome", "OtherPrimaryIncome", "SalesOfProductIncome", "ServiceFeeIncome",
+	"DiscountsRefundsGiven", "UnappliedCashPaymentIncome",
+	// Other Income
+	"DividendIncome", "InterestEarned", "OtherInvestmentIncome", "OtherMiscellaneousIncome",
+	"TaxExemptInterest",
+	// Expense
+	"AdvertisingPromotional", "BadDebts", "BankCharges", "CharitableContributions", "CommissionsAndFees",
+	"Entertainment", "EntertainmentMeals", "EquipmentRental", "FinanceCosts", "GlobalTaxExpense",
+	"Insurance", "InterestPaid", "LegalProfessionalFees", "OfficeExpenses",
+	"OfficeGeneralAdministrativeExpenses", "OtherBusinessExpenses", "OtherMiscellaneousServiceCost",
+	"PromotionalMeals", "RentOrLeaseOfBuildings", "RepairMaintenance", "ShippingFreightDelivery",
+	"SuppliesMaterials", "Travel", "TravelMeals", "Utilities", "Auto", "CostOfLabor", "DuesSubscriptions",
+	"PayrollExpenses", "TaxesPaid", "UnappliedCashBillPaymentExpense",
+	// Other Expense
+	"Depreciation", "ExchangeGainOrLoss", "OtherMiscellaneousExpense", "PenaltiesSettlements",
+	"Amortization",
+	// Cost of Goods Sold
+	"EquipmentRentalCos", "OtherCostsOfServiceCos", "ShippingFreightDeliveryCos", "SuppliesMaterialsCogs",
+	"CostOfLaborCos",
+	// Accounts Payable
+	"AccountsPayable",
+	// Credit Card
+	"CreditCard",
+	// Other Current Liability
+	"DirectDepositPayable", "LineOfCredit", "LoanPayable", "GlobalTaxPayable", "GlobalTaxSuspense",
+	"OtherCurrentLiabilities", "PayrollClearing", "PayrollTaxPayable", "PrepaidExpensesPayable",
+	"RentsInTrustLiability", "TrustAccountsLiabilities", "FederalIncomeTaxPayable", "InsurancePayable",
+	"SalesTaxPayable", "StateLocalIncomeTaxPayable",
+	// Long Term Liability
+	"NotesPayable", "OtherLongTermLiabilities", "ShareholderNotesPayable",
+}
+
+var subTypeWords = regexp.MustCompile(`([a-z])([A-Z])`)
+
+func accountSubTypeName(subType string) string {
+	return subTypeWords.ReplaceAllString(subType, `$1 $2`)
+}
+
+func accountSubTypeOptions() []app.EnumOption {
+	names := make([]string, 0, len(accountSubTypes))
+	for _, subType := range accountSubTypes {
+		names = append(names, accountSubTypeName(subType))
+	}
+	return app.EnumOptions(names...)
+}
+
 var account = app.NewDualType(
 	"Account",
 	"account",
@@ -34,193 +122,30 @@ var account = app.NewDualType(
 		return cr.Account
 	},
 	map[string]app.FieldDef[quickbooks.Account]{
-		"qboId": {
-			Params: fibery.Field{
-				Name:     "QBO Id",
-				Type:     fibery.Text,
-				ReadOnly: true,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Account]) (any, error) {
-				return sd.Item.Id, nil
-			},
-		},
-		"name": {
-			Params: fibery.Field{
-				Name: "Base Name",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Account]) (any, error) {
-				return sd.Item.Name, nil
-			},
-		},
-		"fullyQualifiedName": {
-			Params: fibery.Field{
-				Name:    "Full Name",
-				Type:    fibery.Text,
-				SubType: fibery.Title,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Account]) (any, error) {
-				return sd.Item.FullyQualifiedName, nil
-			},
-		},
-		"syncToken": {
-			Params: fibery.Field{
-				Name:     "Sync Token",
-				Type:     fibery.Text,
-				ReadOnly: true,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Account]) (any, error) {
-				return sd.Item.SyncToken, nil
-			},
-		},
-		"__syncAction": {
-			Params: fibery.Field{
-				Type: fibery.Text,
-				Name: "Sync Action",
-			},
-			Convert: func(sd app.StandardData[quickbooks.Account]) (any, error) {
-				return fibery.SET, nil
-			},
-		},
-		"active": {
-			Params: fibery.Field{
-				Name:    "Active",
-				Type:    fibery.Text,
-				SubType: fibery.Boolean,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Account]) (any, error) {
-				return sd.Item.Active, nil
-			},
-		},
-		"description": {
-			Params: fibery.Field{
-				Name:    "Description",
-				Type:    fibery.Text,
-				SubType: fibery.MD,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Account]) (any, error) {
-				return sd.Item.Description, nil
-			},
-		},
-		"acctNum": {
-			Params: fibery.Field{
-				Name: "Account Number",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Account]) (any, error) {
-				return sd.Item.AcctNum, nil
-			},
-		},
-		"currentBalance": {
-			Params: fibery.Field{
-				Name: "Balance",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Money",
-					"currencyCode":         "USD",
-					"hasThousandSeperator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Account]) (any, error) {
-				return sd.Item.CurrentBalance, nil
-			},
-		},
-		"currentBalanceWithSubAccounts": {
-			Params: fibery.Field{
-				Name: "Balance With Sub-Accounts",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Money",
-					"currencyCode":         "USD",
-					"hasThousandSeperator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Account]) (any, error) {
-				return sd.Item.CurrentBalanceWithSubAccounts, nil
-			},
-		},
-		"classification": {
-			Params: fibery.Field{
-				Name:     "Classification",
-				Type:     fibery.Text,
-				SubType:  fibery.SingleSelect,
-				ReadOnly: true,
-				Options: []map[string]any{
-					{
-						"name": "Asset",
-					},
-					{
-						"name": "Equity",
-					},
-					{
-						"name": "Expense",
-					},
-					{
-						"name": "Liability",
-					},
-					{
-						"name": "Revenue",
-					},
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Account]) (any, error) {
-				return sd.Item.Classification, nil
-			},
-		},
-		"accountType": {
-			Params: fibery.Field{
-				Name:     "Account Type",
-				Type:     fibery.Text,
-				SubType:  fibery.SingleSelect,
-				ReadOnly: true,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Account]) (any, error) {
-				return sd.Item.AccountType, nil
-			},
-		},
-		"accountSubType": {
-			Params: fibery.Field{
-				Name:     "Account Sub-Type",
-				Type:     fibery.Text,
-				SubType:  fibery.SingleSelect,
-				ReadOnly: true,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Account]) (any, error) {
-				reg, err := regexp.Compile(`([a-z])([A-Z])`)
-				if err != nil {
-					return nil, fmt.Errorf("error creating regex: %w", err)
-				}
-
-				subtype := reg.ReplaceAllString(sd.Item.AccountSubType, `$1 $2`)
-				return subtype, nil
-			},
-		},
-		"parentAccountId": {
-			Params: fibery.Field{
-				Name: "Parent Account ID",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Parent Account",
-					TargetName:    "Sub-Accounts",
-					TargetType:    "account",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Account]) (any, error) {
-				var parentAccountId string
-				if sd.Item.ParentRef != nil {
-					parentAccountId = sd.Item.ParentRef.Value
-				}
-				return parentAccountId, nil
-			},
-		},
+		"qboId":                         app.TextField("QBO Id", func(a quickbooks.Account) string { return a.Id }).ReadOnly(),
+		"name":                          app.TextField("Base Name", func(a quickbooks.Account) string { return a.Name }),
+		"fullyQualifiedName":            app.TitleField("Full Name", func(a quickbooks.Account) string { return a.FullyQualifiedName }),
+		"syncToken":                     app.TextField("Sync Token", func(a quickbooks.Account) string { return a.SyncToken }).ReadOnly(),
+		"__syncAction":                  app.SyncActionField[quickbooks.Account](),
+		"active":                        app.BoolField("Active", func(a quickbooks.Account) bool { return a.Active }),
+		"description":                   app.MarkdownField("Description", func(a quickbooks.Account) string { return a.Description }),
+		"acctNum":                       app.TextField("Account Number", func(a quickbooks.Account) string { return a.AcctNum }),
+		"currentBalance":                app.MoneyField("Balance", func(a quickbooks.Account) json.Number { return a.CurrentBalance }),
+		"currentBalanceWithSubAccounts": app.MoneyField("Balance With Sub-Accounts", func(a quickbooks.Account) json.Number { return a.CurrentBalanceWithSubAccounts }),
+		"currency":                      app.ReferenceField("Currency", func(a quickbooks.Account) *quickbooks.ReferenceType { return &a.CurrencyRef }).Describe("ISO code of the currency the account is kept in"),
+		"classification": app.SelectField("Classification", app.EnumOptions("Asset", "Equity", "Expense", "Liability", "Revenue"),
+			func(a quickbooks.Account) string { return a.Classification }).ReadOnly(),
+		"accountType": app.SelectField("Account Type", app.EnumOptions(accountTypes...),
+			func(a quickbooks.Account) string { return a.AccountType }).ReadOnly(),
+		"accountSubType": app.SelectField("Account Sub-Type", accountSubTypeOptions(),
+			func(a quickbooks.Account) string { return accountSubTypeName(a.AccountSubType) }).ReadOnly(),
+		"parentAccountId": app.ReferenceField("Parent Account ID", func(a quickbooks.Account) *quickbooks.ReferenceType { return a.ParentRef }).
+			Relation(fibery.MTO, "Parent Account", "Sub-Accounts", "account"),
 	},
 	nil,
 )
 
 func init() {
 	app.Types.Register(account)
+	app.FeatureFields.Require(app.MultiCurrency, "account", "currency")
 }
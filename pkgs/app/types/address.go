@@ -0,0 +1,203 @@
+package types
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+var addressKinds = []string{"Billing", "Shipping", "Primary"}
+
+// entityAddress is one address of a customer, vendor or employee.
+type entityAddress struct {
+	Kind string
+	Addr *quickbooks.PhysicalAddress
+}
+
+func (a entityAddress) empty() bool {
+	return a.Addr == nil || *a.Addr == (quickbooks.PhysicalAddress{Id: a.Addr.Id})
+}
+
+// formatAddress joins the parts of an address into a single line, such as
+// "123 Main St, Bayshore, CA 94326, USA".
+func formatAddress(a *quickbooks.PhysicalAddress) string {
+	region := strings.TrimSpace(a.CountrySubDivisionCode + " " + a.PostalCode)
+	var parts []string
+	for _, part := range []string{a.Line1, a.Line2, a.Line3, a.Line4, a.Line5, a.City, region, a.Country} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// coordinate parses a latitude or longitude, which QuickBooks sends as text and sets to
+// "INVALID" when it cannot geocode the address.
+func coordinate(value string) any {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil
+	}
+	return f
+}
+
+func addressItemId(typeId, sourceId, kind string) string {
+	return typeId + ":" + sourceId + ":" + strings.ToLower(kind)
+}
+
+func addressFields[ST any](relationId, relationName, targetType string, sourceId func(ST) string, sourceName func(ST) string) map[string]app.DependentFieldDef[ST, entityAddress] {
+	text := func(name string, get func(*quickbooks.PhysicalAddress) string) app.DependentFieldDef[ST, entityAddress] {
+		return app.DependentField[ST](app.TextField(name, func(a entityAddress) string { return get(a.Addr) }))
+	}
+	number := func(name string, get func(*quickbooks.PhysicalAddress) string) app.DependentFieldDef[ST, entityAddress] {
+		return app.DependentField[ST](app.NumberField(name, map[string]any{"format": "Number", "precision": 6},
+			func(a entityAddress) any { return coordinate(get(a.Addr)) }))
+	}
+
+	return map[string]app.DependentFieldDef[ST, entityAddress]{
+		"id": {
+			Params: fibery.Field{
+				Name: "Id",
+				Type: fibery.Id,
+			},
+			Convert: func(dd app.DependentData[ST, entityAddress]) (any, error) {
+				return addressItemId(targetType, sourceId(dd.SourceItem), dd.Item.Kind), nil
+			},
+		},
+		"name": {
+			Params: fibery.Field{
+				Name:    "Name",
+				Type:    fibery.Text,
+				SubType: fibery.Title,
+			},
+			Convert: func(dd app.DependentData[ST, entityAddress]) (any, error) {
+				return sourceName(dd.SourceItem) + " (" + dd.Item.Kind + ")", nil
+			},
+		},
+		"__syncAction": app.DependentField[ST](app.SyncActionField[entityAddress]()),
+		"kind": app.DependentField[ST](app.SelectField("Kind", app.EnumOptions(addressKinds...),
+			func(a entityAddress) string { return a.Kind }).ReadOnly()),
+		"formatted":  app.DependentField[ST](app.TextField("Address", func(a entityAddress) string { return formatAddress(a.Addr) })),
+		"line1":      text("Line 1", func(a *quickbooks.PhysicalAddress) string { return a.Line1 }),
+		"line2":      text("Line 2", func(a *quickbooks.PhysicalAddress) string { return a.Line2 }),
+		"line3":      text("Line 3", func(a *quickbooks.PhysicalAddress) string { return a.Line3 }),
+		"line4":      text("Line 4", func(a *quickbooks.PhysicalAddress) string { return a.Line4 }),
+		"line5":      text("Line 5", func(a *quickbooks.PhysicalAddress) string { return a.Line5 }),
+		"city":       text("City", func(a *quickbooks.PhysicalAddress) string { return a.City }),
+		"state":      text("State", func(a *quickbooks.PhysicalAddress) string { return a.CountrySubDivisionCode }),
+		"postalCode": text("Postal Code", func(a *quickbooks.PhysicalAddress) string { return a.PostalCode }),
+		"country":    text("Country", func(a *quickbooks.PhysicalAddress) string { return a.Country }),
+		"latitude":   number("Latitude", func(a *quickbooks.PhysicalAddress) string { return a.Lat }),
+		"longitude":  number("Longitude", func(a *quickbooks.PhysicalAddress) string { return a.Long }),
+		relationId: {
+			Params: fibery.Field{
+				Name: relationName + " ID",
+				Type: fibery.Text,
+				Relation: &fibery.Relation{
+					Cardinality:   fibery.MTO,
+					Name:          relationName,
+					TargetName:    "Addresses",
+					TargetType:    targetType,
+					TargetFieldID: "id",
+				},
+			},
+			Convert: func(dd app.DependentData[ST, entityAddress]) (any, error) {
+				return sourceId(dd.SourceItem), nil
+			},
+		},
+	}
+}
+
+// addressSource yields one address item per non-empty address of a source entity.
+func addressSource[ST any](
+	sourceType app.StandardType,
+	relationId, relationName string,
+	sourceId, sourceStatus, sourceName func(ST) string,
+	addresses func(ST) []entityAddress,
+	kinds []string,
+	batchQueryExtractor func(quickbooks.BatchQueryResponse) []ST,
+	cdcQueryExtractor func(quickbooks.CDCQueryResponse) []ST,
+) *app.DependentUnionSourceDef[ST, entityAddress] {
+	typeId := sourceType.Id()
+	return app.NewDependentUnionSource(
+		sourceType,
+		sourceId,
+		sourceStatus,
+		func(s ST, a entityAddress) string {
+			return addressItemId(typeId, sourceId(s), a.Kind)
+		},
+		func(id string) []string {
+			ids := make([]string, 0, len(kinds))
+			for _, kind := range kinds {
+				ids = append(ids, addressItemId(typeId, id, kind))
+			}
+			return ids
+		},
+		func(s ST) []entityAddress {
+			var output []entityAddress
+			for _, a := range addresses(s) {
+				if !a.empty() {
+					output = append(output, a)
+				}
+			}
+			return output
+		},
+		batchQueryExtractor,
+		cdcQueryExtractor,
+		addressFields(relationId, relationName, typeId, sourceId, sourceName),
+	)
+}
+
+var address = app.NewDependentUnionType(
+	"address",
+	"Address",
+	addressSource(
+		customer,
+		"customerId",
+		"Customer",
+		func(c quickbooks.Customer) string { return c.Id },
+		func(c quickbooks.Customer) string { return c.Status },
+		func(c quickbooks.Customer) string { return c.DisplayName },
+		func(c quickbooks.Customer) []entityAddress {
+			return []entityAddress{{"Billing", c.BillAddr}, {"Shipping", c.ShipAddr}}
+		},
+		[]string{"Billing", "Shipping"},
+		func(bqr quickbooks.BatchQueryResponse) []quickbooks.Customer { return bqr.Customer },
+		func(cr quickbooks.CDCQueryResponse) []quickbooks.Customer { return cr.Customer },
+	),
+	addressSource(
+		vendor,
+		"vendorId",
+		"Vendor",
+		func(v quickbooks.Vendor) string { return v.Id },
+		func(v quickbooks.Vendor) string { return v.Status },
+		func(v quickbooks.Vendor) string { return v.DisplayName },
+		func(v quickbooks.Vendor) []entityAddress {
+			return []entityAddress{{"Billing", v.BillAddr}}
+		},
+		[]string{"Billing"},
+		func(bqr quickbooks.BatchQueryResponse) []quickbooks.Vendor { return bqr.Vendor },
+		func(cr quickbooks.CDCQueryResponse) []quickbooks.Vendor { return cr.Vendor },
+	),
+	addressSource(
+		employee,
+		"employeeId",
+		"Employee",
+		func(e quickbooks.Employee) string { return e.Id },
+		func(e quickbooks.Employee) string { return e.Status },
+		func(e quickbooks.Employee) string { return e.DisplayName },
+		func(e quickbooks.Employee) []entityAddress {
+			return []entityAddress{{"Primary", &e.PrimaryAddr}}
+		},
+		[]string{"Primary"},
+		func(bqr quickbooks.BatchQueryResponse) []quickbooks.Employee { return bqr.Employee },
+		func(cr quickbooks.CDCQueryResponse) []quickbooks.Employee { return cr.Employee },
+	),
+)
+
+func init() {
+	app.Types.Register(address)
+}
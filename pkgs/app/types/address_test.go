@@ -0,0 +1,83 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+func TestFormatAddress(t *testing.T) {
+	for _, tc := range []struct {
+		addr quickbooks.PhysicalAddress
+		want string
+	}{
+		{quickbooks.PhysicalAddress{Line1: "4581 Finch St.", City: "Bayshore", CountrySubDivisionCode: "CA", PostalCode: "94326", Country: "USA"}, "4581 Finch St., Bayshore, CA 94326, USA"},
+		{quickbooks.PhysicalAddress{Line1: " 12 Wren Ave. ", Line3: "Unit 4", PostalCode: "94326"}, "12 Wren Ave., Unit 4, 94326"},
+		{quickbooks.PhysicalAddress{CountrySubDivisionCode: "CA"}, "CA"},
+		{quickbooks.PhysicalAddress{}, ""},
+	} {
+		if got := formatAddress(&tc.addr); got != tc.want {
+			t.Errorf("formatAddress(%+v) = %q; want %q", tc.addr, got, tc.want)
+		}
+	}
+}
+
+func TestAddressBatchQuery(t *testing.T) {
+	batches := map[string]*quickbooks.BatchItemResponse{
+		"Customer": {QueryResponse: quickbooks.BatchQueryResponse{Customer: []quickbooks.Customer{{
+			Id:          "1",
+			DisplayName: "Amy's Bird Sanctuary",
+			BillAddr:    &quickbooks.PhysicalAddress{Id: "2", Line1: "4581 Finch St.", Lat: "INVALID", Long: "INVALID"},
+			ShipAddr:    &quickbooks.PhysicalAddress{Id: "3", City: "Bayshore", Lat: "37.7021521", Long: "-122.4227416"},
+		}, {
+			Id:          "2",
+			DisplayName: "Bill's Windsurf Shop",
+			BillAddr:    &quickbooks.PhysicalAddress{Id: "4"},
+		}}}},
+		"Vendor": {QueryResponse: quickbooks.BatchQueryResponse{Vendor: []quickbooks.Vendor{{
+			Id:          "2",
+			DisplayName: "Books by Bessie",
+			BillAddr:    &quickbooks.PhysicalAddress{Line1: "15 Main St."},
+		}}}},
+	}
+
+	items, more, err := address.ProcessBatchQuery(batches, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(more) != 0 {
+		t.Errorf("expected no more pages, got %v", more)
+	}
+	byId := make(map[string]map[string]any, len(items))
+	for _, item := range items {
+		byId[item["id"].(string)] = item
+	}
+	if len(byId) != 3 {
+		t.Fatalf("expected an item per non-empty address, got %v", byId)
+	}
+
+	for _, tc := range []struct {
+		id, kind, name, customerId, vendorId string
+		latitude, longitude                  any
+	}{
+		{"customer:1:billing", "Billing", "Amy's Bird Sanctuary (Billing)", "1", "", nil, nil},
+		{"customer:1:shipping", "Shipping", "Amy's Bird Sanctuary (Shipping)", "1", "", 37.7021521, -122.4227416},
+		{"vendor:2:billing", "Billing", "Books by Bessie (Billing)", "", "2", nil, nil},
+	} {
+		item := byId[tc.id]
+		if item["kind"] != tc.kind || item["name"] != tc.name || item["latitude"] != tc.latitude || item["longitude"] != tc.longitude {
+			t.Errorf("unexpected address %s: %v", tc.id, item)
+		}
+		// items from every source carry every relation, left empty for the other sources
+		customerId, vendorId := item["customerId"], item["vendorId"]
+		if tc.customerId == "" && customerId != nil || tc.customerId != "" && customerId != tc.customerId {
+			t.Errorf("unexpected customer of %s: %v", tc.id, customerId)
+		}
+		if tc.vendorId == "" && vendorId != nil || tc.vendorId != "" && vendorId != tc.vendorId {
+			t.Errorf("unexpected vendor of %s: %v", tc.id, vendorId)
+		}
+		if _, ok := item["employeeId"]; !ok {
+			t.Errorf("expected %s to carry every field of the type", tc.id)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package types
+
+import (
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+var attachableCategories = []string{
+	"ContactPhoto",
+	"Document",
+	"Image",
+	"Receipt",
+	"Signature",
+	"Sound",
+	"Other",
+}
+
+// attachableRefTypes are the referenced entities given a relation, keyed by field id.
+var attachableRefTypes = []struct {
+	fieldId, name, entityType, targetType string
+}{
+	{"billIds", "Bills", "Bill", "bill"},
+	{"vendorIds", "Vendors", "Vendor", "vendor"},
+	{"customerIds", "Customers", "Customer", "customer"},
+	{"employeeIds", "Employees", "Employee", "employee"},
+	{"itemIds", "Items", "Item", "item"},
+}
+
+func attachableRefIds(a quickbooks.Attachable, entityType string) []string {
+	var ids []string
+	for _, ref := range a.AttachableRef {
+		if ref.EntityRef.Type == entityType {
+			ids = append(ids, ref.EntityRef.Value)
+		}
+	}
+	return ids
+}
+
+func attachableFields() map[string]app.FieldDef[quickbooks.Attachable] {
+	fields := map[string]app.FieldDef[quickbooks.Attachable]{
+		"qboId": app.TextField("QBO ID", func(a quickbooks.Attachable) string { return a.Id }).ReadOnly(),
+		"name": {
+			Params: fibery.Field{
+				Name:    "Name",
+				Type:    fibery.Text,
+				SubType: fibery.Title,
+			},
+			Convert: func(sd app.StandardData[quickbooks.Attachable]) (any, error) {
+				// note-only attachables have no file name
+				if sd.Item.FileName == "" {
+					return sd.Item.Note, nil
+				}
+				return sd.Item.FileName, nil
+			},
+		},
+		"syncToken":    app.TextField("Sync Token", func(a quickbooks.Attachable) string { return a.SyncToken }).ReadOnly(),
+		"__syncAction": app.SyncActionField[quickbooks.Attachable](),
+		"fileName":     app.TextField("File Name", func(a quickbooks.Attachable) string { return a.FileName }),
+		"noteOnly":     app.BoolField("Note Only", func(a quickbooks.Attachable) bool { return a.FileName == "" }).ReadOnly(),
+		"file": {
+			Params: fibery.Field{
+				Name:    "File",
+				Type:    fibery.TextArray,
+				SubType: fibery.File,
+			},
+			Convert: func(sd app.StandardData[quickbooks.Attachable]) (any, error) {
+				if sd.Item.FileName == "" {
+					return nil, nil
+				}
+				return []string{app.AttachableURL(sd.Item)}, nil
+			},
+		},
+		"size": {
+			Params: fibery.Field{
+				Name:    "Size",
+				Type:    fibery.Number,
+				SubType: fibery.Integer,
+			},
+			Convert: func(sd app.StandardData[quickbooks.Attachable]) (any, error) {
+				if sd.Item.Size == "" {
+					return nil, nil
+				}
+				return sd.Item.Size.Int64()
+			},
+		},
+		"contentType": app.TextField("Content Type", func(a quickbooks.Attachable) string { return string(a.ContentType) }),
+		"note":        app.MarkdownField("Note", func(a quickbooks.Attachable) string { return a.Note }),
+		"category": app.SelectField("Category", app.EnumOptions(attachableCategories...),
+			func(a quickbooks.Attachable) string { return string(a.Category) }).ReadOnly(),
+		"tag": app.TextField("Tag", func(a quickbooks.Attachable) string { return a.Tag }),
+	}
+
+	for _, ref := range attachableRefTypes {
+		entityType := ref.entityType
+		fields[ref.fieldId] = app.Field(fibery.Field{Name: ref.name + " Ids", Type: fibery.TextArray},
+			func(a quickbooks.Attachable) []string { return attachableRefIds(a, entityType) }).
+			Relation(fibery.MTM, ref.name, "Attachables", ref.targetType)
+	}
+
+	return fields
+}
+
+var attachable = app.NewDualType(
+	"Attachable",
+	"attachable",
+	"Attachable",
+	func(a quickbooks.Attachable) string {
+		return a.Id
+	},
+	func(a quickbooks.Attachable) string {
+		return a.Status
+	},
+	func(id string) quickbooks.Attachable {
+		return quickbooks.Attachable{
+			Id: id,
+		}
+	},
+	func(bir quickbooks.BatchItemResponse) quickbooks.Attachable {
+		return bir.Attachable
+	},
+	func(bqr quickbooks.BatchQueryResponse) []quickbooks.Attachable {
+		return bqr.Attachable
+	},
+	func(cr quickbooks.CDCQueryResponse) []quickbooks.Attachable {
+		return cr.Attachable
+	},
+	attachableFields(),
+	nil,
+)
+
+func init() {
+	app.Types.Register(attachable)
+}
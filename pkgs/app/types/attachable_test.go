@@ -0,0 +1,65 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+func TestAttachableConvert(t *testing.T) {
+	ref := func(entityType, id string) quickbooks.AttachableRef {
+		return quickbooks.AttachableRef{EntityRef: quickbooks.ReferenceType{Type: entityType, Value: id}}
+	}
+
+	for name, tc := range map[string]struct {
+		attachable quickbooks.Attachable
+		want       map[string]any
+	}{
+		"file": {
+			attachable: quickbooks.Attachable{
+				Id: "1", FileName: "receipt.pdf", Size: "24", ContentType: "application/pdf", Category: "Receipt",
+				AttachableRef: []quickbooks.AttachableRef{ref("Bill", "26"), ref("Bill", "27")},
+			},
+			want: map[string]any{
+				"name": "receipt.pdf", "noteOnly": false, "file": []string{app.AttachableURL(quickbooks.Attachable{Id: "1"})},
+				"size": int64(24), "contentType": "application/pdf", "category": "Receipt",
+				"billIds": []string{"26", "27"}, "vendorIds": []string(nil),
+			},
+		},
+		"note only": {
+			attachable: quickbooks.Attachable{
+				Id: "2", Note: "Call before delivering", Category: "Other",
+				AttachableRef: []quickbooks.AttachableRef{ref("Customer", "1"), ref("Vendor", "4")},
+			},
+			want: map[string]any{
+				"name": "Call before delivering", "noteOnly": true, "file": nil, "size": nil,
+				"customerIds": []string{"1"}, "vendorIds": []string{"4"}, "billIds": []string(nil),
+			},
+		},
+		"unknown entity references": {
+			attachable: quickbooks.Attachable{Id: "3", Note: "Estimate", AttachableRef: []quickbooks.AttachableRef{ref("Estimate", "8")}},
+			want: map[string]any{
+				"billIds": []string(nil), "vendorIds": []string(nil), "customerIds": []string(nil),
+				"employeeIds": []string(nil), "itemIds": []string(nil),
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			item, err := attachable.Convert(app.StandardData[quickbooks.Attachable]{Item: tc.attachable})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for field, want := range tc.want {
+				if got := item[field]; !reflect.DeepEqual(got, want) {
+					t.Errorf("expected %s to be %#v, got %#v", field, want, got)
+				}
+			}
+		})
+	}
+
+	if _, err := attachable.Convert(app.StandardData[quickbooks.Attachable]{Item: quickbooks.Attachable{Id: "4", FileName: "a.png", Size: "big"}}); err == nil {
+		t.Error("expected an invalid size to fail")
+	}
+}
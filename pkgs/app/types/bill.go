@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
@@ -33,16 +34,7 @@ var bill = app.NewDualType(
 		return cr.Bill
 	},
 	map[string]app.FieldDef[quickbooks.Bill]{
-		"qboId": {
-			Params: fibery.Field{
-				Name:     "QBO Id",
-				Type:     fibery.Text,
-				ReadOnly: true,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Bill]) (any, error) {
-				return sd.Item.Id, nil
-			},
-		},
+		"qboId": app.TextField("QBO Id", func(b quickbooks.Bill) string { return b.Id }).ReadOnly(),
 		"name": {
 			Params: fibery.Field{
 				Name:    "Name",
@@ -56,154 +48,28 @@ var bill = app.NewDualType(
 				return sd.Item.VendorRef.Name + " – " + sd.Item.PrivateNote, nil
 			},
 		},
-		"syncToken": {
-			Params: fibery.Field{
-				Name:     "Sync Token",
-				Type:     fibery.Text,
-				ReadOnly: true,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Bill]) (any, error) {
-				return sd.Item.SyncToken, nil
-			},
-		},
-		"__syncAction": {
-			Params: fibery.Field{
-				Name: "Sync Action",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Bill]) (any, error) {
-				return fibery.SET, nil
-			},
-		},
-		"docNumber": {
-			Params: fibery.Field{
-				Name: "Bill Number",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Bill]) (any, error) {
-				return sd.Item.DocNumber, nil
-			},
-		},
-		"txnDate": {
-			Params: fibery.Field{
-				Name:    "Bill Date",
-				Type:    fibery.DateType,
-				SubType: fibery.Day,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Bill]) (any, error) {
-				if sd.Item.TxnDate.IsZero() {
-					return "", nil
-				}
-				return sd.Item.TxnDate.Format(fibery.DateFormat), nil
-			},
-		},
-		"dueDate": {
-			Params: fibery.Field{
-				Name:    "Due Date",
-				Type:    fibery.DateType,
-				SubType: fibery.Day,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Bill]) (any, error) {
-				if sd.Item.DueDate.IsZero() {
-					return "", nil
-				}
-				return sd.Item.DueDate.Format(fibery.DateFormat), nil
-			},
-		},
-		"privateNote": {
-			Params: fibery.Field{
-				Name:    "Memo",
-				Type:    fibery.Text,
-				SubType: fibery.MD,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Bill]) (any, error) {
-				return sd.Item.PrivateNote, nil
-			},
-		},
-		"totalAmt": {
-			Params: fibery.Field{
-				Name: "Total",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Money",
-					"currencyCode":         "USD",
-					"hasThousandSeperator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Bill]) (any, error) {
-				return sd.Item.TotalAmt, nil
-			},
-		},
-		"balance": {
-			Params: fibery.Field{
-				Name: "Balance",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Money",
-					"currencyCode":         "USD",
-					"hasThousandSeperator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Bill]) (any, error) {
-				return sd.Item.Balance, nil
-			},
-		},
-		"vendorId": {
-			Params: fibery.Field{
-				Name: "Vendor Id",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Vendor",
-					TargetName:    "Bills",
-					TargetType:    "vendor",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Bill]) (any, error) {
-				return sd.Item.VendorRef.Value, nil
-			},
-		},
-		"apAccountId": {
-			Params: fibery.Field{
-				Name: "AP Account Id",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "AP Account",
-					TargetName:    "Bills",
-					TargetType:    "account",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Bill]) (any, error) {
-				if sd.Item.APAccountRef != nil {
-					return sd.Item.APAccountRef.Value, nil
-				}
-				return "", nil
-			},
-		},
-		"salesTermId": {
-			Params: fibery.Field{
-				Name: "Sales Term Id",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Terms",
-					TargetName:    "Bills",
-					TargetType:    "salesTerm",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Bill]) (any, error) {
-				if sd.Item.SalesTermRef != nil {
-					return sd.Item.SalesTermRef.Value, nil
-				}
-				return "", nil
-			},
-		},
+		"syncToken":    app.TextField("Sync Token", func(b quickbooks.Bill) string { return b.SyncToken }).ReadOnly(),
+		"__syncAction": app.SyncActionField[quickbooks.Bill](),
+		"docNumber":    app.TextField("Bill Number", func(b quickbooks.Bill) string { return b.DocNumber }),
+		"txnDate":      app.DayField("Bill Date", func(b quickbooks.Bill) *quickbooks.Date { return &b.TxnDate }),
+		"dueDate":      app.DayField("Due Date", func(b quickbooks.Bill) *quickbooks.Date { return &b.DueDate }),
+		"privateNote":  app.MarkdownField("Memo", func(b quickbooks.Bill) string { return b.PrivateNote }),
+		"totalAmt":     app.MoneyField("Total", func(b quickbooks.Bill) json.Number { return b.TotalAmt }),
+		"balance":      app.MoneyField("Balance", func(b quickbooks.Bill) json.Number { return b.Balance }),
+		"currency": app.ReferenceField("Currency", func(b quickbooks.Bill) *quickbooks.ReferenceType { return &b.CurrencyRef }).
+			Describe("ISO code of the currency the bill is in"),
+		"exchangeRate": app.ExchangeRateField(func(b quickbooks.Bill) json.Number { return b.ExchangeRate }),
+		"homeTotalAmt": app.HomeMoneyField("Home Total",
+			func(b quickbooks.Bill) json.Number { return b.TotalAmt },
+			func(b quickbooks.Bill) json.Number { return b.ExchangeRate }),
+		"homeBalance": app.HomeMoneyField("Home Balance",
+			func(b quickbooks.Bill) json.Number { return b.Balance },
+			func(b quickbooks.Bill) json.Number { return b.ExchangeRate }),
+		"vendorId": app.ReferenceField("Vendor Id", func(b quickbooks.Bill) *quickbooks.ReferenceType { return &b.VendorRef }).
+			Relation(fibery.MTO, "Vendor", "Bills", "vendor"),
+		"apAccountId": app.ReferenceField("AP Account Id", func(b quickbooks.Bill) *quickbooks.ReferenceType { return b.APAccountRef }).
+			Relation(fibery.MTO, "AP Account", "Bills", "account"),
+		"salesTermId": app.ReferenceField("Sales Term Id", func(b quickbooks.Bill) *quickbooks.ReferenceType { return b.SalesTermRef }),
 		"attachables": {
 			Params: fibery.Field{
 				Name:    "Files",
@@ -271,16 +137,7 @@ var billItemLine = app.NewDependentDualType(
 		return cr.Bill
 	},
 	map[string]app.DependentFieldDef[quickbooks.Bill, quickbooks.Line]{
-		"qboId": {
-			Params: fibery.Field{
-				Name:     "QBO ID",
-				Type:     fibery.Text,
-				ReadOnly: true,
-			},
-			Convert: func(dd app.DependentData[quickbooks.Bill, quickbooks.Line]) (any, error) {
-				return dd.Item.Id, nil
-			},
-		},
+		"qboId": app.DependentField[quickbooks.Bill](app.TextField("QBO ID", func(l quickbooks.Line) string { return l.Id }).ReadOnly()),
 		"name": {
 			Params: fibery.Field{
 				Name:    "Name",
@@ -297,34 +154,9 @@ var billItemLine = app.NewDependentDualType(
 				return name, nil
 			},
 		},
-		"description": {
-			Params: fibery.Field{
-				Name: "Description",
-				Type: fibery.Text,
-			},
-			Convert: func(dd app.DependentData[quickbooks.Bill, quickbooks.Line]) (any, error) {
-				return dd.Item.Description, nil
-			},
-		},
-		"__syncAction": {
-			Params: fibery.Field{
-				Type: fibery.Text,
-				Name: "Sync Action",
-			},
-			Convert: func(dd app.DependentData[quickbooks.Bill, quickbooks.Line]) (any, error) {
-				return fibery.SET, nil
-			},
-		},
-		"lineNum": {
-			Params: fibery.Field{
-				Name:    "Line",
-				Type:    fibery.Number,
-				SubType: fibery.Integer,
-			},
-			Convert: func(dd app.DependentData[quickbooks.Bill, quickbooks.Line]) (any, error) {
-				return dd.Item.LineNum, nil
-			},
-		},
+		"description":  app.DependentField[quickbooks.Bill](app.TextField("Description", func(l quickbooks.Line) string { return l.Description })),
+		"__syncAction": app.DependentField[quickbooks.Bill](app.SyncActionField[quickbooks.Line]()),
+		"lineNum":      app.DependentField[quickbooks.Bill](app.IntegerField("Line", func(l quickbooks.Line) int { return l.LineNum })),
 		"tax": {
 			Params: fibery.Field{
 				Name:    "Tax",
@@ -372,63 +204,10 @@ var billItemLine = app.NewDependentDualType(
 				return billed, nil
 			},
 		},
-		"qty": {
-			Params: fibery.Field{
-				Name: "Quantity",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Number",
-					"hasThousandSeparator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(dd app.DependentData[quickbooks.Bill, quickbooks.Line]) (any, error) {
-				return dd.Item.ItemBasedExpenseLineDetail.Qty, nil
-			},
-		},
-		"unitPrice": {
-			Params: fibery.Field{
-				Name: "Unit Price",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Money",
-					"currencyCode":         "USD",
-					"hasThousandSeperator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(dd app.DependentData[quickbooks.Bill, quickbooks.Line]) (any, error) {
-				return dd.Item.ItemBasedExpenseLineDetail.UnitPrice, nil
-			},
-		},
-		"markupPercent": {
-			Params: fibery.Field{
-				Name: "Markup",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":    "Percent",
-					"precision": 2,
-				},
-			},
-			Convert: func(dd app.DependentData[quickbooks.Bill, quickbooks.Line]) (any, error) {
-				return dd.Item.ItemBasedExpenseLineDetail.MarkupInfo, nil
-			},
-		},
-		"amount": {
-			Params: fibery.Field{
-				Name: "Amount",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Money",
-					"currencyCode":         "USD",
-					"hasThousandSeperator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(dd app.DependentData[quickbooks.Bill, quickbooks.Line]) (any, error) {
-				return dd.Item.Amount, nil
-			},
-		},
+		"qty":           app.DependentField[quickbooks.Bill](app.NumberField("Quantity", app.QuantityFormat(), func(l quickbooks.Line) json.Number { return l.ItemBasedExpenseLineDetail.Qty })),
+		"unitPrice":     app.DependentField[quickbooks.Bill](app.MoneyField("Unit Price", func(l quickbooks.Line) json.Number { return l.ItemBasedExpenseLineDetail.UnitPrice })),
+		"markupPercent": app.DependentField[quickbooks.Bill](app.NumberField("Markup", app.PercentFormat(), func(l quickbooks.Line) json.Number { return l.ItemBasedExpenseLineDetail.MarkupInfo.Percent })),
+		"amount":        app.DependentField[quickbooks.Bill](app.MoneyField("Amount", func(l quickbooks.Line) json.Number { return l.Amount })),
 		"billId": {
 			Params: fibery.Field{
 				Name: "Bill ID",
@@ -445,70 +224,14 @@ var billItemLine = app.NewDependentDualType(
 				return dd.SourceItem.Id, nil
 			},
 		},
-		"itemId": {
-			Params: fibery.Field{
-				Name: "Item ID",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Item",
-					TargetName:    "Bill Item Lines",
-					TargetType:    "item",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(dd app.DependentData[quickbooks.Bill, quickbooks.Line]) (any, error) {
-				return dd.Item.ItemBasedExpenseLineDetail.ItemRef.Value, nil
-			},
-		},
-		"customerId": {
-			Params: fibery.Field{
-				Name: "Customer ID",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Customer",
-					TargetName:    "Bill Item Lines",
-					TargetType:    "customer",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(dd app.DependentData[quickbooks.Bill, quickbooks.Line]) (any, error) {
-				return dd.Item.ItemBasedExpenseLineDetail.CustomerRef.Value, nil
-			},
-		},
-		"classId": {
-			Params: fibery.Field{
-				Name: "Class ID",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Class",
-					TargetName:    "Bill Item Lines",
-					TargetType:    "class",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(dd app.DependentData[quickbooks.Bill, quickbooks.Line]) (any, error) {
-				return dd.Item.ItemBasedExpenseLineDetail.ClassRef.Value, nil
-			},
-		},
-		"markupAccountId": {
-			Params: fibery.Field{
-				Name: "Markup Account ID",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Markup Income Account",
-					TargetName:    "Bill Item Line Markup",
-					TargetType:    "account",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(dd app.DependentData[quickbooks.Bill, quickbooks.Line]) (any, error) {
-				return dd.Item.ItemBasedExpenseLineDetail.MarkupInfo.MarkUpIncomeAccountRef.Value, nil
-			},
-		},
+		"itemId": app.DependentField[quickbooks.Bill](app.ReferenceField("Item ID", func(l quickbooks.Line) *quickbooks.ReferenceType { return &l.ItemBasedExpenseLineDetail.ItemRef }).
+			Relation(fibery.MTO, "Item", "Bill Item Lines", "item")),
+		"customerId": app.DependentField[quickbooks.Bill](app.ReferenceField("Customer ID", func(l quickbooks.Line) *quickbooks.ReferenceType { return &l.ItemBasedExpenseLineDetail.CustomerRef }).
+			Relation(fibery.MTO, "Customer", "Bill Item Lines", "customer")),
+		"classId": app.DependentField[quickbooks.Bill](app.ReferenceField("Class ID", func(l quickbooks.Line) *quickbooks.ReferenceType { return &l.ItemBasedExpenseLineDetail.ClassRef })),
+		"markupAccountId": app.DependentField[quickbooks.Bill](app.ReferenceField("Markup Account ID", func(l quickbooks.Line) *quickbooks.ReferenceType {
+			return &l.ItemBasedExpenseLineDetail.MarkupInfo.MarkUpIncomeAccountRef
+		}).Relation(fibery.MTO, "Markup Income Account", "Bill Item Line Markup", "account")),
 		"reimburseChargeId": {
 			Params: fibery.Field{
 				Name: "Reimburse Charge ID",
@@ -517,7 +240,7 @@ var billItemLine = app.NewDependentDualType(
 					Cardinality:   fibery.OTO,
 					Name:          "Reimburse Charge",
 					TargetName:    "Bill Item Line",
-					TargetType:    "reimburseCharge",
+					TargetType:    "reimbursecharge",
 					TargetFieldID: "id",
 				},
 			},
@@ -537,4 +260,7 @@ var billItemLine = app.NewDependentDualType(
 func init() {
 	app.Types.Register(bill)
 	app.Types.Register(billItemLine)
+	app.FeatureFields.Require(app.MultiCurrency, "bill", "currency", "exchangeRate", "homeTotalAmt", "homeBalance")
+	app.ForeignAmounts.Register("bill", "totalAmt", "balance")
+	app.ForeignAmounts.Register("billItemLine", "unitPrice", "amount")
 }
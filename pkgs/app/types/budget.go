@@ -0,0 +1,145 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+var budgetTypes = []app.EnumOption{
+	{Value: "ProfitAndLoss", Name: "Profit and Loss"},
+	{Value: "BalanceSheet", Name: "Balance Sheet"},
+}
+
+var budget = app.NewDualType(
+	"Budget",
+	"budget",
+	"Budget",
+	func(b quickbooks.Budget) string {
+		return b.Id
+	},
+	func(b quickbooks.Budget) string {
+		return b.Status
+	},
+	func(id string) quickbooks.Budget {
+		return quickbooks.Budget{
+			Id: id,
+		}
+	},
+	func(bir quickbooks.BatchItemResponse) quickbooks.Budget {
+		return bir.Budget
+	},
+	func(bqr quickbooks.BatchQueryResponse) []quickbooks.Budget {
+		return bqr.Budget
+	},
+	func(cr quickbooks.CDCQueryResponse) []quickbooks.Budget {
+		return cr.Budget
+	},
+	map[string]app.FieldDef[quickbooks.Budget]{
+		"qboId":           app.TextField("QBO Id", func(b quickbooks.Budget) string { return b.Id }).ReadOnly(),
+		"name":            app.TitleField("Name", func(b quickbooks.Budget) string { return b.Name }),
+		"syncToken":       app.TextField("Sync Token", func(b quickbooks.Budget) string { return b.SyncToken }).ReadOnly(),
+		"__syncAction":    app.SyncActionField[quickbooks.Budget](),
+		"active":          app.BoolField("Active", func(b quickbooks.Budget) bool { return b.Active }),
+		"startDate":       app.DayField("Start Date", func(b quickbooks.Budget) *quickbooks.Date { return &b.StartDate }),
+		"endDate":         app.DayField("End Date", func(b quickbooks.Budget) *quickbooks.Date { return &b.EndDate }),
+		"budgetType":      app.SelectField("Budget Type", budgetTypes, func(b quickbooks.Budget) string { return b.BudgetType }).ReadOnly(),
+		"budgetEntryType": app.SelectField("Period", app.EnumOptions("Monthly", "Quarterly", "Yearly"), func(b quickbooks.Budget) string { return b.BudgetEntryType }).ReadOnly(),
+	},
+	nil,
+)
+
+// budgetLineId identifies a budget detail, which has no id of its own, by everything it is
+// budgeted against.
+func budgetLineId(b quickbooks.Budget, d quickbooks.BudgetDetail) string {
+	parts := []string{b.Id, d.AccountRef.Value, d.BudgetDate.Format(time.DateOnly)}
+	for _, ref := range []*quickbooks.ReferenceType{d.ClassRef, d.DepartmentRef, d.CustomerRef} {
+		value := ""
+		if ref != nil {
+			value = ref.Value
+		}
+		parts = append(parts, value)
+	}
+	return strings.Join(parts, ":")
+}
+
+var budgetLine = app.NewDependentDualType(
+	"Budget",
+	"budgetLine",
+	"Budget Line",
+	budgetLineId,
+	func(b quickbooks.Budget, d quickbooks.BudgetDetail) bool {
+		return true
+	},
+	func(b quickbooks.Budget) []quickbooks.BudgetDetail {
+		return b.BudgetDetail
+	},
+	func(b quickbooks.Budget) string {
+		return b.Id
+	},
+	func(b quickbooks.Budget) string {
+		return b.Status
+	},
+	func(id string) quickbooks.Budget {
+		return quickbooks.Budget{
+			Id: id,
+		}
+	},
+	func(bir quickbooks.BatchItemResponse) quickbooks.Budget {
+		return bir.Budget
+	},
+	func(bqr quickbooks.BatchQueryResponse) []quickbooks.Budget {
+		return bqr.Budget
+	},
+	func(cr quickbooks.CDCQueryResponse) []quickbooks.Budget {
+		return cr.Budget
+	},
+	map[string]app.DependentFieldDef[quickbooks.Budget, quickbooks.BudgetDetail]{
+		"name": {
+			Params: fibery.Field{
+				Name:    "Name",
+				Type:    fibery.Text,
+				SubType: fibery.Title,
+			},
+			Convert: func(dd app.DependentData[quickbooks.Budget, quickbooks.BudgetDetail]) (any, error) {
+				return dd.Item.AccountRef.Name + " - " + dd.Item.BudgetDate.Format("Jan 2006"), nil
+			},
+		},
+		"__syncAction": app.DependentField[quickbooks.Budget](app.SyncActionField[quickbooks.BudgetDetail]()),
+		"budgetDate":   app.DependentField[quickbooks.Budget](app.DayField("Period", func(d quickbooks.BudgetDetail) *quickbooks.Date { return &d.BudgetDate })),
+		"amount":       app.DependentField[quickbooks.Budget](app.MoneyField("Amount", func(d quickbooks.BudgetDetail) json.Number { return d.Amount })),
+		"budgetId": {
+			Params: fibery.Field{
+				Name: "Budget ID",
+				Type: fibery.Text,
+				Relation: &fibery.Relation{
+					Cardinality:   fibery.MTO,
+					Name:          "Budget",
+					TargetName:    "Lines",
+					TargetType:    "budget",
+					TargetFieldID: "id",
+				},
+			},
+			Convert: func(dd app.DependentData[quickbooks.Budget, quickbooks.BudgetDetail]) (any, error) {
+				return dd.SourceItem.Id, nil
+			},
+		},
+		"accountId": app.DependentField[quickbooks.Budget](app.ReferenceField("Account ID", func(d quickbooks.BudgetDetail) *quickbooks.ReferenceType { return &d.AccountRef }).
+			Relation(fibery.MTO, "Account", "Budget Lines", "account")),
+		"customerId": app.DependentField[quickbooks.Budget](app.ReferenceField("Customer ID", func(d quickbooks.BudgetDetail) *quickbooks.ReferenceType { return d.CustomerRef }).
+			Relation(fibery.MTO, "Customer", "Budget Lines", "customer")),
+		"classId":      app.DependentField[quickbooks.Budget](app.ReferenceField("Class ID", func(d quickbooks.BudgetDetail) *quickbooks.ReferenceType { return d.ClassRef })),
+		"departmentId": app.DependentField[quickbooks.Budget](app.ReferenceField("Department ID", func(d quickbooks.BudgetDetail) *quickbooks.ReferenceType { return d.DepartmentRef })),
+	},
+)
+
+func init() {
+	app.Types.Register(budget)
+	app.Types.Register(budgetLine)
+	app.FeatureFields.Require(app.ClassTracking, "budgetLine", "classId")
+	app.FeatureFields.Require(app.LocationTracking, "budgetLine", "departmentId")
+}
@@ -0,0 +1,55 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+func TestBudgetLines(t *testing.T) {
+	may := quickbooks.Date{Time: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	materials := quickbooks.ReferenceType{Value: "33", Name: "Job Materials"}
+	b := quickbooks.Budget{
+		Id:              "60",
+		BudgetType:      "ProfitAndLoss",
+		BudgetEntryType: "Monthly",
+		BudgetDetail: []quickbooks.BudgetDetail{
+			{BudgetDate: may, Amount: "300", AccountRef: materials},
+			{BudgetDate: may, Amount: "150", AccountRef: materials, CustomerRef: &quickbooks.ReferenceType{Value: "1"}},
+			{BudgetDate: may, Amount: "75", AccountRef: materials, ClassRef: &quickbooks.ReferenceType{Value: "5"}, DepartmentRef: &quickbooks.ReferenceType{Value: "2"}},
+		},
+	}
+
+	converted, err := budget.Convert(app.StandardData[quickbooks.Budget]{Item: b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if converted["budgetType"] != "Profit and Loss" || converted["budgetEntryType"] != "Monthly" {
+		t.Errorf("unexpected budget: %v", converted)
+	}
+
+	for n, tc := range []struct {
+		id, customerId, classId, departmentId, amount string
+	}{
+		{"60:33:2024-05-01:::", "", "", "", "300"},
+		{"60:33:2024-05-01:::1", "1", "", "", "150"},
+		{"60:33:2024-05-01:5:2:", "", "5", "2", "75"},
+	} {
+		detail := b.BudgetDetail[n]
+		if id := budgetLineId(b, detail); id != tc.id {
+			t.Errorf("expected line id %s, got %s", tc.id, id)
+		}
+		line, err := budgetLine.Convert(app.DependentData[quickbooks.Budget, quickbooks.BudgetDetail]{SourceItem: b, Item: detail})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line["name"] != "Job Materials - May 2024" || line["budgetId"] != "60" || line["accountId"] != "33" ||
+			line["customerId"] != tc.customerId || line["classId"] != tc.classId || line["departmentId"] != tc.departmentId ||
+			fmt.Sprint(line["amount"]) != tc.amount {
+			t.Errorf("unexpected budget line %s: %v", tc.id, line)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+var companyInfo = app.NewRealmType(
+	"companyInfo",
+	"Company Info",
+	func(ci quickbooks.CompanyInfo) string {
+		return ci.Id
+	},
+	func(c *app.Client, params quickbooks.RequestParameters) (quickbooks.CompanyInfo, error) {
+		info, err := c.FindCompanyInfo(params)
+		if err != nil {
+			return quickbooks.CompanyInfo{}, err
+		}
+		return *info, nil
+	},
+	app.Fields(
+		map[string]app.FieldDef[quickbooks.CompanyInfo]{
+			"name":                 app.TitleField("Name", func(ci quickbooks.CompanyInfo) string { return ci.CompanyName }),
+			"syncToken":            app.TextField("Sync Token", func(ci quickbooks.CompanyInfo) string { return ci.SyncToken }).ReadOnly(),
+			"__syncAction":         app.SyncActionField[quickbooks.CompanyInfo](),
+			"legalName":            app.TextField("Legal Name", func(ci quickbooks.CompanyInfo) string { return ci.LegalName }),
+			"country":              app.TextField("Country", func(ci quickbooks.CompanyInfo) string { return ci.Country }),
+			"email":                app.EmailField("Email", func(ci quickbooks.CompanyInfo) *quickbooks.EmailAddress { return &ci.Email }),
+			"phone":                app.TextField("Phone", func(ci quickbooks.CompanyInfo) string { return ci.PrimaryPhone.FreeFormNumber }),
+			"website":              app.WebsiteField("Website", func(ci quickbooks.CompanyInfo) *quickbooks.WebSiteAddress { return &ci.WebAddr }),
+			"companyStartDate":     app.DayField("Company Start Date", func(ci quickbooks.CompanyInfo) *quickbooks.Date { return &ci.CompanyStartDate }),
+			"fiscalYearStartMonth": app.TextField("Fiscal Year Start Month", func(ci quickbooks.CompanyInfo) string { return ci.FiscalYearStartMonth }),
+		},
+		app.AddressFields("address", "Address", func(ci quickbooks.CompanyInfo) *quickbooks.PhysicalAddress { return &ci.CompanyAddr },
+			app.AddressLine1, app.AddressLine2, app.AddressCity, app.AddressState, app.AddressPostalCode, app.AddressCountry),
+		app.AddressFields("legalAddress", "Legal Address", func(ci quickbooks.CompanyInfo) *quickbooks.PhysicalAddress { return &ci.LegalAddr },
+			app.AddressLine1, app.AddressLine2, app.AddressCity, app.AddressState, app.AddressPostalCode, app.AddressCountry),
+	),
+)
+
+func init() {
+	app.Types.Register(companyInfo)
+}
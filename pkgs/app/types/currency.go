@@ -0,0 +1,144 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+// Currencies are keyed by their ISO code, which is what transactions reference them by.
+var currency = app.NewStandardType(
+	"CompanyCurrency",
+	"currency",
+	"Currency",
+	func(c quickbooks.CompanyCurrency) string {
+		return c.Code
+	},
+	func(bir quickbooks.BatchItemResponse) quickbooks.CompanyCurrency {
+		return bir.CompanyCurrency
+	},
+	func(bqr quickbooks.BatchQueryResponse) []quickbooks.CompanyCurrency {
+		return bqr.CompanyCurrency
+	},
+	map[string]app.FieldDef[quickbooks.CompanyCurrency]{
+		"qboId":        app.TextField("QBO Id", func(c quickbooks.CompanyCurrency) string { return c.Id }).ReadOnly(),
+		"name":         app.TitleField("Name", func(c quickbooks.CompanyCurrency) string { return c.Name }),
+		"syncToken":    app.TextField("Sync Token", func(c quickbooks.CompanyCurrency) string { return c.SyncToken }).ReadOnly(),
+		"__syncAction": app.SyncActionField[quickbooks.CompanyCurrency](),
+		"code":         app.TextField("Code", func(c quickbooks.CompanyCurrency) string { return c.Code }).ReadOnly(),
+		"active":       app.BoolField("Active", func(c quickbooks.CompanyCurrency) bool { return c.Active }),
+	},
+)
+
+// fetchExchangeRates reads the rates of every active currency for each day of the window set on
+// the sync filter. QuickBooks only answers rate queries for a single date, so each day is its own
+// query, batched together.
+func fetchExchangeRates(c *app.Client, params quickbooks.RequestParameters, filter map[string]any) ([]quickbooks.ExchangeRate, error) {
+	days, err := app.ExchangeRateDays(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	batcher := app.NewBatcher(c, app.MaxBatchItems, 1)
+
+	resp, err := batcher.Do(params, []quickbooks.BatchItemRequest{{
+		BID:   "currencies",
+		Query: "Select * From CompanyCurrency MAXRESULTS 1000",
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("error querying currencies: %w", err)
+	}
+	if faults := resp[0].Fault.Faults; len(faults) > 0 {
+		return nil, fmt.Errorf("fault querying currencies: %w", quickbooks.BatchError{Faults: faults})
+	}
+	active := make(map[string]bool)
+	for _, cur := range resp[0].QueryResponse.CompanyCurrency {
+		if cur.Active {
+			active[cur.Code] = true
+		}
+	}
+	if len(active) == 0 {
+		return nil, nil
+	}
+
+	dates := app.ExchangeRateDates(days, time.Now())
+	req := make([]quickbooks.BatchItemRequest, 0, len(dates))
+	for _, date := range dates {
+		req = append(req, quickbooks.BatchItemRequest{
+			BID:   date,
+			Query: fmt.Sprintf("Select * From ExchangeRate Where AsOfDate = '%s'", date),
+		})
+	}
+	resp, err = batcher.Do(params, req)
+	if err != nil {
+		return nil, fmt.Errorf("error querying exchange rates: %w", err)
+	}
+
+	var rates []quickbooks.ExchangeRate
+	for _, r := range resp {
+		if faults := r.Fault.Faults; len(faults) > 0 {
+			return nil, fmt.Errorf("fault querying exchange rates for %s: %w", r.BID, quickbooks.BatchError{Faults: faults})
+		}
+		for _, rate := range r.QueryResponse.ExchangeRate {
+			if active[rate.SourceCurrencyCode] {
+				rates = append(rates, rate)
+			}
+		}
+	}
+	return rates, nil
+}
+
+// Exchange rates have no id of their own and QuickBooks keeps a single rate per currency and
+// date, so they are keyed by both.
+var exchangeRate = app.NewRealmListType(
+	"exchangeRate",
+	"Exchange Rate",
+	func(r quickbooks.ExchangeRate) string {
+		return r.SourceCurrencyCode + ":" + r.AsOfDate.Format(time.DateOnly)
+	},
+	fetchExchangeRates,
+	map[string]app.FieldDef[quickbooks.ExchangeRate]{
+		"name": {
+			Params: fibery.Field{
+				Name:    "Name",
+				Type:    fibery.Text,
+				SubType: fibery.Title,
+			},
+			Convert: func(sd app.StandardData[quickbooks.ExchangeRate]) (any, error) {
+				return sd.Item.SourceCurrencyCode + " " + sd.Item.AsOfDate.Format(time.DateOnly), nil
+			},
+		},
+		"syncToken":    app.TextField("Sync Token", func(r quickbooks.ExchangeRate) string { return r.SyncToken }).ReadOnly(),
+		"__syncAction": app.SyncActionField[quickbooks.ExchangeRate](),
+		"asOfDate":     app.DayField("Date", func(r quickbooks.ExchangeRate) *quickbooks.Date { return &r.AsOfDate }),
+		"rate": app.ExchangeRateField(func(r quickbooks.ExchangeRate) json.Number { return r.Rate }).
+			Describe("Home currency units per unit of the currency"),
+		"targetCurrency": app.TextField("Home Currency", func(r quickbooks.ExchangeRate) string { return r.TargetCurrencyCode }).ReadOnly(),
+		"currencyId": {
+			Params: fibery.Field{
+				Name: "Currency ID",
+				Type: fibery.Text,
+				Relation: &fibery.Relation{
+					Cardinality:   fibery.MTO,
+					Name:          "Currency",
+					TargetName:    "Exchange Rates",
+					TargetType:    "currency",
+					TargetFieldID: "id",
+				},
+			},
+			Convert: func(sd app.StandardData[quickbooks.ExchangeRate]) (any, error) {
+				return sd.Item.SourceCurrencyCode, nil
+			},
+		},
+	},
+)
+
+func init() {
+	app.Types.Register(currency)
+	app.Types.Register(exchangeRate)
+	app.FeatureTypes.Require(app.MultiCurrency, "currency", "exchangeRate")
+}
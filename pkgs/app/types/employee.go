@@ -1,8 +1,9 @@
 package types
 
 import (
+	"encoding/json"
+
 	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
-	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
 	"github.com/tommyhedley/quickbooks-go"
 )
 
@@ -31,305 +32,24 @@ var employee = app.NewDualType(
 		return cr.Employee
 	},
 	map[string]app.FieldDef[quickbooks.Employee]{
-		"qboId": {
-			Params: fibery.Field{
-				Name:     "QBO ID",
-				Type:     fibery.Text,
-				ReadOnly: true,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.Id, nil
-			},
-		},
-		"displayName": {
-			Params: fibery.Field{
-				Name:    "Display Name",
-				Type:    fibery.Text,
-				SubType: fibery.Title,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.DisplayName, nil
-			},
-		},
-		"syncToken": {
-			Params: fibery.Field{
-				Name:     "Sync Token",
-				Type:     fibery.Text,
-				ReadOnly: true,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.SyncToken, nil
-			},
-		},
-		"__syncAction": {
-			Params: fibery.Field{
-				Type: fibery.Text,
-				Name: "Sync Action",
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return fibery.SET, nil
-			},
-		},
-		"active": {
-			Params: fibery.Field{
-				Name:    "Active",
-				Type:    fibery.Text,
-				SubType: fibery.Boolean,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.Active, nil
-			},
-		},
-		"title": {
-			Params: fibery.Field{
-				Name: "Title",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.Title, nil
-			},
-		},
-		"givenName": {
-			Params: fibery.Field{
-				Name: "First Name",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.GivenName, nil
-			},
-		},
-		"middleName": {
-			Params: fibery.Field{
-				Name: "Middle Name",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.MiddleName, nil
-			},
-		},
-		"familyName": {
-			Params: fibery.Field{
-				Name: "Last Name",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.FamilyName, nil
-			},
-		},
-		"suffix": {
-			Params: fibery.Field{
-				Name: "Suffix",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.Suffix, nil
-			},
-		},
-		"primaryEmailAddr": {
-			Params: fibery.Field{
-				Name:    "Email",
-				Type:    fibery.Text,
-				SubType: fibery.Email,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				if sd.Item.PrimaryEmailAddr != nil {
-					return sd.Item.PrimaryEmailAddr.Address, nil
-				}
-				return "", nil
-			},
-		},
-		"billableTime": {
-			Params: fibery.Field{
-				Name:        "Billable",
-				Type:        fibery.Text,
-				SubType:     fibery.Boolean,
-				Description: "Is the entity enabled for use in QuickBooks?",
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.BillableTime, nil
-			},
-		},
-		"birthDate": {
-			Params: fibery.Field{
-				Name:    "Date of Birth",
-				Type:    fibery.DateType,
-				SubType: fibery.Day,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				if sd.Item.BirthDate != nil {
-					return sd.Item.BirthDate.Format(fibery.DateFormat), nil
-				}
-				return "", nil
-			},
-		},
-		"primaryPhone": {
-			Params: fibery.Field{
-				Name: "Phone",
-				Type: fibery.Text,
-				Format: map[string]any{
-					"format": "phone",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				if sd.Item.PrimaryPhone != nil {
-					return sd.Item.PrimaryPhone.FreeFormNumber, nil
-				}
-				return "", nil
-			},
-		},
-		"mobile": {
-			Params: fibery.Field{
-				Name: "Mobile",
-				Type: fibery.Text,
-				Format: map[string]any{
-					"format": "phone",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				if sd.Item.Mobile != nil {
-					return sd.Item.Mobile.FreeFormNumber, nil
-				}
-				return "", nil
-			},
-		},
-		"costRate": {
-			Params: fibery.Field{
-				Name: "Cost Rate",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Money",
-					"currencyCode":         "USD",
-					"hasThousandSeperator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.CostRate, nil
-			},
-		},
-		"billRate": {
-			Params: fibery.Field{
-				Name: "Bill Rate",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Money",
-					"currencyCode":         "USD",
-					"hasThousandSeperator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.BillRate, nil
-			},
-		},
-		"employeeNumber": {
-			Params: fibery.Field{
-				Name: "Employee ID",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.EmployeeNumber, nil
-			},
-		},
-		"addressLine1": {
-			Params: fibery.Field{
-				Name: "Address Line 1",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.PrimaryAddr.Line1, nil
-			},
-		},
-		"addressLine2": {
-			Params: fibery.Field{
-				Name: "Address Line 2",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.PrimaryAddr.Line2, nil
-			},
-		},
-		"addressLine3": {
-			Params: fibery.Field{
-				Name: "Address Line 3",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.PrimaryAddr.Line3, nil
-			},
-		},
-		"addressLine4": {
-			Params: fibery.Field{
-				Name: "Address Line 4",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.PrimaryAddr.Line4, nil
-			},
-		},
-		"addressLine5": {
-			Params: fibery.Field{
-				Name: "Address Line 5",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.PrimaryAddr.Line5, nil
-			},
-		},
-		"addressCity": {
-			Params: fibery.Field{
-				Name: "Address City",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.PrimaryAddr.City, nil
-			},
-		},
-		"addressState": {
-			Params: fibery.Field{
-				Name: "Address State",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.PrimaryAddr.CountrySubDivisionCode, nil
-			},
-		},
-		"addressPostalCode": {
-			Params: fibery.Field{
-				Name: "Address Postal Code",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.PrimaryAddr.PostalCode, nil
-			},
-		},
-		"addressCountry": {
-			Params: fibery.Field{
-				Name: "Address Country",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.PrimaryAddr.Country, nil
-			},
-		},
-		"addressLat": {
-			Params: fibery.Field{
-				Name: "Address Latitude",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.PrimaryAddr.Lat, nil
-			},
-		},
-		"addressLong": {
-			Params: fibery.Field{
-				Name: "Address Longitude",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Employee]) (any, error) {
-				return sd.Item.PrimaryAddr.Long, nil
-			},
-		},
+		"qboId":            app.TextField("QBO ID", func(e quickbooks.Employee) string { return e.Id }).ReadOnly(),
+		"displayName":      app.TitleField("Display Name", func(e quickbooks.Employee) string { return e.DisplayName }),
+		"syncToken":        app.TextField("Sync Token", func(e quickbooks.Employee) string { return e.SyncToken }).ReadOnly(),
+		"__syncAction":     app.SyncActionField[quickbooks.Employee](),
+		"active":           app.BoolField("Active", func(e quickbooks.Employee) bool { return e.Active }),
+		"title":            app.TextField("Title", func(e quickbooks.Employee) string { return e.Title }),
+		"givenName":        app.TextField("First Name", func(e quickbooks.Employee) string { return e.GivenName }),
+		"middleName":       app.TextField("Middle Name", func(e quickbooks.Employee) string { return e.MiddleName }),
+		"familyName":       app.TextField("Last Name", func(e quickbooks.Employee) string { return e.FamilyName }),
+		"suffix":           app.TextField("Suffix", func(e quickbooks.Employee) string { return e.Suffix }),
+		"primaryEmailAddr": app.EmailField("Email", func(e quickbooks.Employee) *quickbooks.EmailAddress { return e.PrimaryEmailAddr }),
+		"billableTime":     app.BoolField("Billable", func(e quickbooks.Employee) bool { return e.BillableTime }).Describe("Is the entity enabled for use in QuickBooks?"),
+		"birthDate":        app.DayField("Date of Birth", func(e quickbooks.Employee) *quickbooks.Date { return e.BirthDate }),
+		"primaryPhone":     app.PhoneField("Phone", func(e quickbooks.Employee) *quickbooks.TelephoneNumber { return e.PrimaryPhone }),
+		"mobile":           app.PhoneField("Mobile", func(e quickbooks.Employee) *quickbooks.TelephoneNumber { return e.Mobile }),
+		"costRate":         app.MoneyField("Cost Rate", func(e quickbooks.Employee) json.Number { return e.CostRate }),
+		"billRate":         app.MoneyField("Bill Rate", func(e quickbooks.Employee) json.Number { return e.BillRate }),
+		"employeeNumber":   app.TextField("Employee ID", func(e quickbooks.Employee) string { return e.EmployeeNumber }),
 	},
 	nil,
 )
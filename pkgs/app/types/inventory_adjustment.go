@@ -0,0 +1,145 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+// QuickBooks offers neither change data capture nor webhooks for inventory adjustments, so
+// they are synced in full every time.
+var inventoryAdjustment = app.NewStandardType(
+	"InventoryAdjustment",
+	"inventoryAdjustment",
+	"Inventory Adjustment",
+	func(a quickbooks.InventoryAdjustment) string {
+		return a.Id
+	},
+	func(bir quickbooks.BatchItemResponse) quickbooks.InventoryAdjustment {
+		return bir.InventoryAdjustment
+	},
+	func(bqr quickbooks.BatchQueryResponse) []quickbooks.InventoryAdjustment {
+		return bqr.InventoryAdjustment
+	},
+	map[string]app.FieldDef[quickbooks.InventoryAdjustment]{
+		"qboId": app.TextField("QBO Id", func(a quickbooks.InventoryAdjustment) string { return a.Id }).ReadOnly(),
+		"name": {
+			Params: fibery.Field{
+				Name:    "Name",
+				Type:    fibery.Text,
+				SubType: fibery.Title,
+			},
+			Convert: func(sd app.StandardData[quickbooks.InventoryAdjustment]) (any, error) {
+				name := "Inventory Adjustment"
+				if sd.Item.DocNumber != "" {
+					name += " " + sd.Item.DocNumber
+				}
+				if !sd.Item.TxnDate.IsZero() {
+					name += " (" + sd.Item.TxnDate.Format(fibery.DateFormat) + ")"
+				}
+				return name, nil
+			},
+		},
+		"syncToken":    app.TextField("Sync Token", func(a quickbooks.InventoryAdjustment) string { return a.SyncToken }).ReadOnly(),
+		"__syncAction": app.SyncActionField[quickbooks.InventoryAdjustment](),
+		"docNumber":    app.TextField("Reference No.", func(a quickbooks.InventoryAdjustment) string { return a.DocNumber }),
+		"txnDate":      app.DayField("Adjustment Date", func(a quickbooks.InventoryAdjustment) *quickbooks.Date { return &a.TxnDate }),
+		"privateNote":  app.MarkdownField("Memo", func(a quickbooks.InventoryAdjustment) string { return a.PrivateNote }),
+		"adjustAccountId": app.ReferenceField("Adjustment Account Id", func(a quickbooks.InventoryAdjustment) *quickbooks.ReferenceType { return &a.AdjustAccountRef }).
+			Relation(fibery.MTO, "Adjustment Account", "Inventory Adjustments", "account"),
+	},
+)
+
+var inventoryAdjustmentLine = app.NewDependentType(
+	"InventoryAdjustment",
+	"inventoryAdjustmentLine",
+	"Inventory Adjustment Line",
+	func(a quickbooks.InventoryAdjustment, l quickbooks.Line) string {
+		return fmt.Sprintf("%s:a:%s", a.Id, l.Id)
+	},
+	func(a quickbooks.InventoryAdjustment, l quickbooks.Line) bool {
+		return l.DetailType == quickbooks.ItemAdjustmentLine
+	},
+	func(a quickbooks.InventoryAdjustment) []quickbooks.Line {
+		lines := make([]quickbooks.Line, 0)
+		for _, line := range a.Line {
+			if line.DetailType == quickbooks.ItemAdjustmentLine {
+				lines = append(lines, line)
+			}
+		}
+		return lines
+	},
+	func(bir quickbooks.BatchItemResponse) quickbooks.InventoryAdjustment {
+		return bir.InventoryAdjustment
+	},
+	func(bqr quickbooks.BatchQueryResponse) []quickbooks.InventoryAdjustment {
+		return bqr.InventoryAdjustment
+	},
+	map[string]app.DependentFieldDef[quickbooks.InventoryAdjustment, quickbooks.Line]{
+		"qboId": app.DependentField[quickbooks.InventoryAdjustment](app.TextField("QBO ID", func(l quickbooks.Line) string { return l.Id }).ReadOnly()),
+		"name": {
+			Params: fibery.Field{
+				Name:    "Name",
+				Type:    fibery.Text,
+				SubType: fibery.Title,
+			},
+			Convert: func(dd app.DependentData[quickbooks.InventoryAdjustment, quickbooks.Line]) (any, error) {
+				name := dd.Item.ItemAdjustmentLineDetail.ItemRef.Name
+				if dd.Item.Description != "" {
+					name += " - " + dd.Item.Description
+				}
+				return name, nil
+			},
+		},
+		"__syncAction": app.DependentField[quickbooks.InventoryAdjustment](app.SyncActionField[quickbooks.Line]()),
+		"description":  app.DependentField[quickbooks.InventoryAdjustment](app.TextField("Description", func(l quickbooks.Line) string { return l.Description })),
+		"lineNum":      app.DependentField[quickbooks.InventoryAdjustment](app.IntegerField("Line", func(l quickbooks.Line) int { return l.LineNum })),
+		"qtyDiff": app.DependentField[quickbooks.InventoryAdjustment](app.NumberField("Quantity Change", app.QuantityFormat(), func(l quickbooks.Line) json.Number {
+			return l.ItemAdjustmentLineDetail.QtyDiff
+		})),
+		"adjustmentId": {
+			Params: fibery.Field{
+				Name: "Inventory Adjustment ID",
+				Type: fibery.Text,
+				Relation: &fibery.Relation{
+					Cardinality:   fibery.MTO,
+					Name:          "Inventory Adjustment",
+					TargetName:    "Lines",
+					TargetType:    "inventoryAdjustment",
+					TargetFieldID: "id",
+				},
+			},
+			Convert: func(dd app.DependentData[quickbooks.InventoryAdjustment, quickbooks.Line]) (any, error) {
+				return dd.SourceItem.Id, nil
+			},
+		},
+		"itemId": app.DependentField[quickbooks.InventoryAdjustment](app.ReferenceField("Item ID", func(l quickbooks.Line) *quickbooks.ReferenceType { return &l.ItemAdjustmentLineDetail.ItemRef }).
+			Relation(fibery.MTO, "Item", "Inventory Adjustment Lines", "item")),
+		"classId": app.DependentField[quickbooks.InventoryAdjustment](app.ReferenceField("Class ID", func(l quickbooks.Line) *quickbooks.ReferenceType { return l.ItemAdjustmentLineDetail.ClassRef })),
+		"adjustAccountId": {
+			Params: fibery.Field{
+				Name: "Adjustment Account ID",
+				Type: fibery.Text,
+				Relation: &fibery.Relation{
+					Cardinality:   fibery.MTO,
+					Name:          "Adjustment Account",
+					TargetName:    "Inventory Adjustment Lines",
+					TargetType:    "account",
+					TargetFieldID: "id",
+				},
+			},
+			Convert: func(dd app.DependentData[quickbooks.InventoryAdjustment, quickbooks.Line]) (any, error) {
+				return dd.SourceItem.AdjustAccountRef.Value, nil
+			},
+		},
+	},
+)
+
+func init() {
+	app.Types.Register(inventoryAdjustment)
+	app.Types.Register(inventoryAdjustmentLine)
+	app.FeatureFields.Require(app.ClassTracking, "inventoryAdjustmentLine", "classId")
+}
@@ -0,0 +1,69 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+func TestInventoryAdjustmentLines(t *testing.T) {
+	adjustment := quickbooks.InventoryAdjustment{
+		Id:               "40",
+		DocNumber:        "ADJ-1",
+		AdjustAccountRef: quickbooks.ReferenceType{Value: "82"},
+		Line: []quickbooks.Line{
+			{Id: "1", LineNum: 1, Description: "Damaged", DetailType: quickbooks.ItemAdjustmentLine, ItemAdjustmentLineDetail: quickbooks.ItemAdjustmentLineDetail{
+				ItemRef: quickbooks.ReferenceType{Value: "11", Name: "Pump"},
+				QtyDiff: "-2",
+			}},
+			{Id: "2", LineNum: 2, DetailType: quickbooks.ItemExpenseLine},
+		},
+	}
+
+	lines := inventoryAdjustmentLine.ItemExtractor(adjustment)
+	if len(lines) != 1 {
+		t.Fatalf("expected only item adjustment lines, got %v", lines)
+	}
+	if id := inventoryAdjustmentLine.ItemId(adjustment, lines[0]); id != "40:a:1" {
+		t.Errorf("unexpected line id %s", id)
+	}
+	line, err := inventoryAdjustmentLine.Convert(app.DependentData[quickbooks.InventoryAdjustment, quickbooks.Line]{SourceItem: adjustment, Item: lines[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line["name"] != "Pump - Damaged" || line["itemId"] != "11" || fmt.Sprint(line["qtyDiff"]) != "-2" ||
+		line["adjustmentId"] != "40" || line["adjustAccountId"] != "82" || line["classId"] != "" {
+		t.Errorf("unexpected adjustment line: %v", line)
+	}
+}
+
+func TestItemQuantitySnapshot(t *testing.T) {
+	batch := &quickbooks.BatchItemResponse{QueryResponse: quickbooks.BatchQueryResponse{Item: []quickbooks.Item{
+		{Id: "11", FullyQualifiedName: "Pump", Type: "Inventory", QtyOnHand: "25", ReorderPoint: "5"},
+		{Id: "3", FullyQualifiedName: "Concrete", Type: "Service"},
+	}}}
+	taken := time.Date(2024, 5, 1, 23, 30, 0, 0, time.FixedZone("PDT", -7*60*60))
+
+	snapshots, more, err := itemQuantitySnapshot.ProcessSnapshot(batch, taken, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !more {
+		t.Error("expected a full page to have more")
+	}
+	if len(snapshots) != 1 {
+		t.Fatalf("expected a snapshot of inventory items only, got %v", snapshots)
+	}
+	// snapshots are dated by the UTC day they were taken on
+	snapshot := snapshots[0]
+	if snapshot["id"] != "11:2024-05-02" || snapshot["itemId"] != "11" || fmt.Sprint(snapshot["qtyOnHand"]) != "25" || snapshot["name"] != "Pump" {
+		t.Errorf("unexpected snapshot: %v", snapshot)
+	}
+
+	if _, more, _ := itemQuantitySnapshot.ProcessSnapshot(batch, taken, 3); more {
+		t.Error("expected a short page to be the last")
+	}
+}
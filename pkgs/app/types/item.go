@@ -1,11 +1,22 @@
 package types
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
 	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
 	"github.com/tommyhedley/quickbooks-go"
 )
 
+var itemTypes = []app.EnumOption{
+	{Value: "Inventory", Name: "Inventory"},
+	{Value: "Service", Name: "Service"},
+	{Value: "NonInventory", Name: "Non-Inventory"},
+	{Value: "Category", Name: "Category"},
+	{Value: "Group", Name: "Group"},
+}
+
 var item = app.NewDualType(
 	"Item",
 	"item",
@@ -31,379 +42,138 @@ var item = app.NewDualType(
 		return cr.Item
 	},
 	map[string]app.FieldDef[quickbooks.Item]{
-		"qboId": {
-			Params: fibery.Field{
-				Name:     "QBO ID",
-				Type:     fibery.Text,
-				ReadOnly: true,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.Id, nil
-			},
-		},
+		"qboId":               app.TextField("QBO ID", func(i quickbooks.Item) string { return i.Id }).ReadOnly(),
+		"name":                app.TextField("Base Name", func(i quickbooks.Item) string { return i.Name }),
+		"fullyQualifiedName":  app.TitleField("Full Name", func(i quickbooks.Item) string { return i.FullyQualifiedName }),
+		"syncToken":           app.TextField("Sync Token", func(i quickbooks.Item) string { return i.SyncToken }).ReadOnly(),
+		"__syncAction":        app.SyncActionField[quickbooks.Item](),
+		"active":              app.BoolField("Active", func(i quickbooks.Item) bool { return i.Active }),
+		"description":         app.MarkdownField("Description", func(i quickbooks.Item) string { return i.Description }),
+		"purchaseDesc":        app.TextField("Purchase Description", func(i quickbooks.Item) string { return i.PurchaseDesc }),
+		"invStartDate":        app.DayField("Inventory Start", func(i quickbooks.Item) *quickbooks.Date { return &i.InvStartDate }),
+		"type":                app.SelectField("Type", itemTypes, func(i quickbooks.Item) string { return i.Type }).ReadOnly(),
+		"qtyOnHand":           app.NumberField("Quantity On Hand", app.QuantityFormat(), func(i quickbooks.Item) json.Number { return i.QtyOnHand }),
+		"reorderPoint":        app.NumberField("Reorder Quantity", app.QuantityFormat(), func(i quickbooks.Item) json.Number { return i.ReorderPoint }),
+		"sku":                 app.TextField("SKU", func(i quickbooks.Item) string { return i.SKU }),
+		"taxable":             app.BoolField("Taxable", func(i quickbooks.Item) bool { return i.Taxable }),
+		"salesTaxIncluded":    app.BoolField("Sales Tax Included", func(i quickbooks.Item) bool { return i.SalesTaxIncluded }),
+		"purchaseTaxIncluded": app.BoolField("Purchase Tax Included", func(i quickbooks.Item) bool { return i.PurchaseTaxIncluded }),
+		"salesTaxCodeId":      app.ReferenceField("Sales Tax Code ID", func(i quickbooks.Item) *quickbooks.ReferenceType { return i.SalesTaxCodeRef }).Relation(fibery.MTO, "Sales Tax Code", "Sales Items", "taxCode"),
+		"purchaseTaxCodeId":   app.ReferenceField("Purchase Tax Code ID", func(i quickbooks.Item) *quickbooks.ReferenceType { return i.PurchaseTaxCodeRef }).Relation(fibery.MTO, "Purchase Tax Code", "Purchase Items", "taxCode"),
+		"classId":             app.ReferenceField("Class ID", func(i quickbooks.Item) *quickbooks.ReferenceType { return i.ClassRef }),
+		"prefVendorId": app.ReferenceField("Preferred Vendor ID", func(i quickbooks.Item) *quickbooks.ReferenceType { return i.PrefVendorRef }).
+			Relation(fibery.MTO, "Preferred Vendor", "Primary Sale Items", "vendor"),
+		"categoryId": app.ReferenceField("Parent ID", func(i quickbooks.Item) *quickbooks.ReferenceType { return i.ParentRef }).
+			Relation(fibery.MTO, "Category", "Items", "item"),
+		"purchaseCost": app.MoneyField("Purchase Cost", func(i quickbooks.Item) json.Number { return i.PurchaseCost }),
+		"unitPrice":    app.MoneyField("Unit Price", func(i quickbooks.Item) json.Number { return i.UnitPrice }),
+		"assetAccountId": app.ReferenceField("Asset Account ID", func(i quickbooks.Item) *quickbooks.ReferenceType { return &i.AssetAccountRef }).
+			Relation(fibery.MTO, "Asset Account", "Inventory Items", "account"),
+		"expenseAccountId": app.ReferenceField("Expense Account ID", func(i quickbooks.Item) *quickbooks.ReferenceType { return i.ExpenseAccountRef }).
+			Relation(fibery.MTO, "Expense Account", "Purchase Items", "account"),
+		"incomeAccountId": app.ReferenceField("Income Account ID", func(i quickbooks.Item) *quickbooks.ReferenceType { return &i.IncomeAccountRef }).
+			Relation(fibery.MTO, "Income Account", "Sale Items", "account"),
+	},
+	nil,
+)
+
+var itemGroupComponent = app.NewDependentDualType(
+	"Item",
+	"itemGroupComponent",
+	"Item Group Component",
+	func(i quickbooks.Item, c quickbooks.ItemComponentLine) string {
+		return fmt.Sprintf("%s:g:%s", i.Id, c.ItemRef.Value)
+	},
+	func(i quickbooks.Item, c quickbooks.ItemComponentLine) bool {
+		return i.Type == "Group"
+	},
+	func(i quickbooks.Item) []quickbooks.ItemComponentLine {
+		if i.ItemGroupDetail == nil {
+			return nil
+		}
+		return i.ItemGroupDetail.ItemGroupLine
+	},
+	func(i quickbooks.Item) string {
+		return i.Id
+	},
+	func(i quickbooks.Item) string {
+		return i.Status
+	},
+	func(id string) quickbooks.Item {
+		return quickbooks.Item{
+			Id: id,
+		}
+	},
+	func(bir quickbooks.BatchItemResponse) quickbooks.Item {
+		return bir.Item
+	},
+	func(bqr quickbooks.BatchQueryResponse) []quickbooks.Item {
+		return bqr.Item
+	},
+	func(cr quickbooks.CDCQueryResponse) []quickbooks.Item {
+		return cr.Item
+	},
+	map[string]app.DependentFieldDef[quickbooks.Item, quickbooks.ItemComponentLine]{
 		"name": {
 			Params: fibery.Field{
-				Name: "Base Name",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.Name, nil
-			},
-		},
-		"fullyQualifiedName": {
-			Params: fibery.Field{
-				Name:    "Full Name",
+				Name:    "Name",
 				Type:    fibery.Text,
 				SubType: fibery.Title,
 			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.FullyQualifiedName, nil
-			},
-		},
-		"syncToken": {
-			Params: fibery.Field{
-				Name:     "Sync Token",
-				Type:     fibery.Text,
-				ReadOnly: true,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.SyncToken, nil
-			},
-		},
-		"__syncAction": {
-			Params: fibery.Field{
-				Type: fibery.Text,
-				Name: "Sync Action",
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return fibery.SET, nil
-			},
-		},
-		"active": {
-			Params: fibery.Field{
-				Name:    "Active",
-				Type:    fibery.Text,
-				SubType: fibery.Boolean,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.Active, nil
-			},
-		},
-		"description": {
-			Params: fibery.Field{
-				Name:    "Description",
-				Type:    fibery.Text,
-				SubType: fibery.MD,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.Description, nil
-			},
-		},
-		"purchaseDesc": {
-			Params: fibery.Field{
-				Name: "Purchase Description",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.PurchaseDesc, nil
-			},
-		},
-		"invStartDate": {
-			Params: fibery.Field{
-				Name:    "Inventory Start",
-				Type:    fibery.DateType,
-				SubType: fibery.Day,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				if !sd.Item.InvStartDate.IsZero() {
-					return sd.Item.InvStartDate.Format(fibery.DateFormat), nil
-				}
-				return "", nil
-			},
-		},
-		"type": {
-			Params: fibery.Field{
-				Name:     "Type",
-				Type:     fibery.Text,
-				SubType:  fibery.SingleSelect,
-				ReadOnly: true,
-				Options: []map[string]any{
-					{
-						"name": "Inventory",
-					},
-					{
-						"name": "Service",
-					},
-					{
-						"name": "Non-Inventory",
-					},
-					{
-						"name": "Category",
-					},
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				switch sd.Item.Type {
-				case "Inventory":
-					return "Inventory", nil
-				case "Service":
-					return "Service", nil
-				case "NonInventory":
-					return "Non-Inventory", nil
-				case "Category":
-					return "Category", nil
-				default:
-					return sd.Item.Type, nil
-				}
-			},
-		},
-		"qtyOnHand": {
-			Params: fibery.Field{
-				Name: "Quantity On Hand",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Number",
-					"hasThousandSeparator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.QtyOnHand, nil
-			},
-		},
-		"reorderPoint": {
-			Params: fibery.Field{
-				Name: "Reorder Quantity",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Number",
-					"hasThousandSeparator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.ReorderPoint, nil
-			},
-		},
-		"sku": {
-			Params: fibery.Field{
-				Name: "SKU",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.SKU, nil
-			},
-		},
-		"taxable": {
-			Params: fibery.Field{
-				Name:    "Taxable",
-				Type:    fibery.Text,
-				SubType: fibery.Boolean,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.Taxable, nil
-			},
-		},
-		"salesTaxIncluded": {
-			Params: fibery.Field{
-				Name:    "Sales Tax Included",
-				Type:    fibery.Text,
-				SubType: fibery.Boolean,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.SalesTaxIncluded, nil
-			},
-		},
-		"purchaseTaxIncluded": {
-			Params: fibery.Field{
-				Name:    "Purchase Tax Included",
-				Type:    fibery.Text,
-				SubType: fibery.Boolean,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.PurchaseTaxIncluded, nil
-			},
-		},
-		"salesTaxCodeId": {
-			Params: fibery.Field{
-				Name: "Sales Tax Code ID",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Sales Tax",
-					TargetName:    "Sales Tax On Items",
-					TargetType:    "taxCode",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				if sd.Item.SalesTaxCodeRef != nil {
-					return sd.Item.SalesTaxCodeRef.Value, nil
-				}
-				return "", nil
-			},
-		},
-		"purchaseTaxCodeId": {
-			Params: fibery.Field{
-				Name: "Purchase Tax Code ID",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Purchase Tax",
-					TargetName:    "Purchase Tax On Items",
-					TargetType:    "taxCode",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				if sd.Item.PurchaseTaxCodeRef != nil {
-					return sd.Item.PurchaseTaxCodeRef.Value, nil
-				}
-				return "", nil
-			},
-		},
-		"classId": {
-			Params: fibery.Field{
-				Name: "Class ID",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Class",
-					TargetName:    "Items",
-					TargetType:    "class",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				if sd.Item.ClassRef != nil {
-					return sd.Item.ClassRef.Value, nil
-				}
-				return "", nil
+			Convert: func(dd app.DependentData[quickbooks.Item, quickbooks.ItemComponentLine]) (any, error) {
+				return dd.SourceItem.Name + " - " + dd.Item.ItemRef.Name, nil
 			},
 		},
-		"prefVendorId": {
+		"__syncAction": app.DependentField[quickbooks.Item](app.SyncActionField[quickbooks.ItemComponentLine]()),
+		"qty":          app.DependentField[quickbooks.Item](app.NumberField("Quantity", app.QuantityFormat(), func(c quickbooks.ItemComponentLine) json.Number { return c.Qty })),
+		"groupId": {
 			Params: fibery.Field{
-				Name: "Preferred Vendor ID",
+				Name: "Group ID",
 				Type: fibery.Text,
 				Relation: &fibery.Relation{
 					Cardinality:   fibery.MTO,
-					Name:          "Preferred Vendor",
-					TargetName:    "Primary Sale Items",
-					TargetType:    "vendor",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				if sd.Item.PrefVendorRef != nil {
-					return sd.Item.PrefVendorRef.Value, nil
-				}
-				return "", nil
-			},
-		},
-		"categoryId": {
-			Params: fibery.Field{
-				Name: "Parent ID",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Category",
-					TargetName:    "Items",
+					Name:          "Group",
+					TargetName:    "Components",
 					TargetType:    "item",
 					TargetFieldID: "id",
 				},
 			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				if sd.Item.ParentRef != nil {
-					return sd.Item.ParentRef.Value, nil
-				}
-				return "", nil
-			},
-		},
-		"purchaseCost": {
-			Params: fibery.Field{
-				Name: "Purchase Cost",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Money",
-					"currencyCode":         "USD",
-					"hasThousandSeperator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.PurchaseCost, nil
-			},
-		},
-		"unitPrice": {
-			Params: fibery.Field{
-				Name: "Unit Price",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Money",
-					"currencyCode":         "USD",
-					"hasThousandSeperator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.UnitPrice, nil
-			},
-		},
-		"assetAccountId": {
-			Params: fibery.Field{
-				Name: "Asset Account ID",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Asset Account",
-					TargetName:    "Inventory Items",
-					TargetType:    "account",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.AssetAccountRef.Value, nil
-			},
-		},
-		"expenseAccountId": {
-			Params: fibery.Field{
-				Name: "Expense Account ID",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Expense Account",
-					TargetName:    "Purchase Items",
-					TargetType:    "account",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				if sd.Item.ExpenseAccountRef != nil {
-					return sd.Item.ExpenseAccountRef.Value, nil
-				}
-				return "", nil
-			},
-		},
-		"incomeAccountId": {
-			Params: fibery.Field{
-				Name: "Income Account ID",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Income Account",
-					TargetName:    "Sale Items",
-					TargetType:    "account",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Item]) (any, error) {
-				return sd.Item.IncomeAccountRef.Value, nil
+			Convert: func(dd app.DependentData[quickbooks.Item, quickbooks.ItemComponentLine]) (any, error) {
+				return dd.SourceItem.Id, nil
 			},
 		},
+		"itemId": app.DependentField[quickbooks.Item](app.ReferenceField("Item ID", func(c quickbooks.ItemComponentLine) *quickbooks.ReferenceType { return &c.ItemRef }).
+			Relation(fibery.MTO, "Item", "Groups", "item")),
+	},
+)
+
+var itemQuantitySnapshot = app.NewSnapshotType(
+	"Item",
+	"itemQuantitySnapshot",
+	"Item Quantity Snapshot",
+	func(i quickbooks.Item) string {
+		return i.Id
+	},
+	func(i quickbooks.Item) bool {
+		return i.Type == "Inventory"
+	},
+	func(bqr quickbooks.BatchQueryResponse) []quickbooks.Item {
+		return bqr.Item
+	},
+	map[string]app.FieldDef[quickbooks.Item]{
+		"name":         app.TitleField("Name", func(i quickbooks.Item) string { return i.FullyQualifiedName }),
+		"__syncAction": app.SyncActionField[quickbooks.Item](),
+		"qtyOnHand":    app.NumberField("Quantity On Hand", app.QuantityFormat(), func(i quickbooks.Item) json.Number { return i.QtyOnHand }),
+		"reorderPoint": app.NumberField("Reorder Quantity", app.QuantityFormat(), func(i quickbooks.Item) json.Number { return i.ReorderPoint }),
+		"itemId": app.TextField("Item ID", func(i quickbooks.Item) string { return i.Id }).
+			Relation(fibery.MTO, "Item", "Quantity Snapshots", "item"),
 	},
-	nil,
 )
 
 func init() {
 	app.Types.Register(item)
+	app.Types.Register(itemGroupComponent)
+	app.Types.Register(itemQuantitySnapshot)
+	app.FeatureFields.Require(app.ClassTracking, "item", "classId")
+	app.FeatureFields.Require(app.InventoryTracking, "item", "invStartDate", "qtyOnHand", "reorderPoint")
 }
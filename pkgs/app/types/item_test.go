@@ -0,0 +1,55 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+func TestItemGroupComponents(t *testing.T) {
+	bundle := quickbooks.Item{
+		Id:   "19",
+		Name: "Fountain Kit",
+		Type: "Group",
+		ItemGroupDetail: &quickbooks.ItemGroupDetail{ItemGroupLine: []quickbooks.ItemComponentLine{
+			{ItemRef: quickbooks.ReferenceType{Value: "3", Name: "Concrete"}, Qty: "2"},
+			{ItemRef: quickbooks.ReferenceType{Value: "11", Name: "Pump"}, Qty: "1"},
+		}},
+	}
+
+	converted, err := item.Convert(app.StandardData[quickbooks.Item]{Item: bundle})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if converted["type"] != "Group" {
+		t.Errorf("expected bundle to convert to a Group item, got %v", converted["type"])
+	}
+
+	lines := itemGroupComponent.ItemExtractor(bundle)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 components, got %v", lines)
+	}
+	for n, tc := range []struct {
+		id, name, itemId, qty string
+	}{
+		{"19:g:3", "Fountain Kit - Concrete", "3", "2"},
+		{"19:g:11", "Fountain Kit - Pump", "11", "1"},
+	} {
+		if id := itemGroupComponent.ItemId(bundle, lines[n]); id != tc.id {
+			t.Errorf("expected component id %s, got %s", tc.id, id)
+		}
+		component, err := itemGroupComponent.Convert(app.DependentData[quickbooks.Item, quickbooks.ItemComponentLine]{SourceItem: bundle, Item: lines[n]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if component["name"] != tc.name || component["groupId"] != "19" || component["itemId"] != tc.itemId || fmt.Sprint(component["qty"]) != tc.qty {
+			t.Errorf("unexpected component %s: %v", tc.id, component)
+		}
+	}
+
+	if lines := itemGroupComponent.ItemExtractor(quickbooks.Item{Id: "3", Type: "Inventory"}); len(lines) != 0 {
+		t.Errorf("expected items without a group detail to have no components, got %v", lines)
+	}
+}
@@ -0,0 +1,46 @@
+package types
+
+import (
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+var preferences = app.NewRealmType(
+	"preferences",
+	"Preferences",
+	func(p quickbooks.Preferences) string {
+		return p.Id
+	},
+	func(c *app.Client, params quickbooks.RequestParameters) (quickbooks.Preferences, error) {
+		prefs, err := c.FindPreferences(params)
+		if err != nil {
+			return quickbooks.Preferences{}, err
+		}
+		return *prefs, nil
+	},
+	map[string]app.FieldDef[quickbooks.Preferences]{
+		"name":                 app.TitleField("Name", func(quickbooks.Preferences) string { return "Company Preferences" }),
+		"syncToken":            app.TextField("Sync Token", func(p quickbooks.Preferences) string { return p.SyncToken }).ReadOnly(),
+		"__syncAction":         app.SyncActionField[quickbooks.Preferences](),
+		"homeCurrency":         app.TextField("Home Currency", func(p quickbooks.Preferences) string { return app.HomeCurrency(&p) }),
+		"multiCurrencyEnabled": app.BoolField("Multi-Currency", func(p quickbooks.Preferences) bool { return p.CurrencyPrefs.MultiCurrencyEnabled }),
+		"classTrackingPerTxn": app.BoolField("Class Per Transaction", func(p quickbooks.Preferences) bool { return p.AccountingInfoPrefs.ClassTrackingPerTxn }).
+			Describe("Classes are assigned to whole transactions"),
+		"classTrackingPerTxnLine": app.BoolField("Class Per Line", func(p quickbooks.Preferences) bool { return p.AccountingInfoPrefs.ClassTrackingPerTxnLine }).
+			Describe("Classes are assigned to individual transaction lines"),
+		"trackLocations": app.BoolField("Location Tracking", func(p quickbooks.Preferences) bool { return p.AccountingInfoPrefs.TrackDepartments }),
+		"locationLabel": app.TextField("Location Label", func(p quickbooks.Preferences) string { return p.AccountingInfoPrefs.DepartmentTerminology }).
+			Describe("What the company calls locations"),
+		"customerLabel": app.TextField("Customer Label", func(p quickbooks.Preferences) string { return p.AccountingInfoPrefs.CustomerTerminology }).
+			Describe("What the company calls customers"),
+		"fiscalYearStartMonth":    app.TextField("Fiscal Year Start Month", func(p quickbooks.Preferences) string { return p.AccountingInfoPrefs.FirstMonthOfFiscalYear }),
+		"bookCloseDate":           app.DayField("Books Closed Through", func(p quickbooks.Preferences) *quickbooks.Date { return &p.AccountingInfoPrefs.BookCloseDate }),
+		"trackQuantityOnHand":     app.BoolField("Inventory Tracking", func(p quickbooks.Preferences) bool { return p.ProductAndServicesPrefs.QuantityOnHand }),
+		"usingSalesTax":           app.BoolField("Sales Tax", func(p quickbooks.Preferences) bool { return p.TaxPrefs.UsingSalesTax }),
+		"billableExpenseTracking": app.BoolField("Billable Expenses", func(p quickbooks.Preferences) bool { return p.VendorAndPurchasesPrefs.BillableExpenseTracking }),
+	},
+)
+
+func init() {
+	app.Types.Register(preferences)
+}
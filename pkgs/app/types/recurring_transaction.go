@@ -0,0 +1,141 @@
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+var (
+	recurringTemplateTypes = []app.EnumOption{
+		{Value: "Bill", Name: "Bill"},
+		{Value: "Invoice", Name: "Invoice"},
+		{Value: "JournalEntry", Name: "Journal Entry"},
+	}
+	recurTypes = []app.EnumOption{
+		{Value: "Automated", Name: "Automated"},
+		{Value: "Reminded", Name: "Reminded"},
+		{Value: "UnScheduled", Name: "Unscheduled"},
+	}
+)
+
+// recurringTemplate holds the parts of a recurring template shared by every transaction type.
+type recurringTemplate struct {
+	TxnType     string
+	Id          string
+	DocNumber   string
+	PrivateNote string
+	TotalAmt    json.Number
+	CurrencyRef *quickbooks.ReferenceType
+	VendorRef   *quickbooks.ReferenceType
+	CustomerRef *quickbooks.ReferenceType
+	Info        quickbooks.RecurringInfo
+}
+
+func templateOf(rt quickbooks.RecurringTransaction) recurringTemplate {
+	var (
+		t    recurringTemplate
+		info *quickbooks.RecurringInfo
+	)
+	switch {
+	case rt.Bill != nil:
+		t = recurringTemplate{
+			TxnType:     "Bill",
+			Id:          rt.Bill.Id,
+			DocNumber:   rt.Bill.DocNumber,
+			PrivateNote: rt.Bill.PrivateNote,
+			TotalAmt:    rt.Bill.TotalAmt,
+			CurrencyRef: &rt.Bill.CurrencyRef,
+			VendorRef:   &rt.Bill.VendorRef,
+		}
+		info = rt.Bill.RecurringInfo
+	case rt.Invoice != nil:
+		t = recurringTemplate{
+			TxnType:     "Invoice",
+			Id:          rt.Invoice.Id,
+			DocNumber:   rt.Invoice.DocNumber,
+			PrivateNote: rt.Invoice.PrivateNote,
+			TotalAmt:    rt.Invoice.TotalAmt,
+			CurrencyRef: &rt.Invoice.CurrencyRef,
+			CustomerRef: &rt.Invoice.CustomerRef,
+		}
+		info = rt.Invoice.RecurringInfo
+	case rt.JournalEntry != nil:
+		t = recurringTemplate{
+			TxnType:     "JournalEntry",
+			Id:          rt.JournalEntry.Id,
+			DocNumber:   rt.JournalEntry.DocNumber,
+			PrivateNote: rt.JournalEntry.PrivateNote,
+			TotalAmt:    rt.JournalEntry.TotalAmt,
+			CurrencyRef: &rt.JournalEntry.CurrencyRef,
+		}
+		info = rt.JournalEntry.RecurringInfo
+	}
+	if info != nil {
+		t.Info = *info
+	}
+	return t
+}
+
+func scheduleOf(rt quickbooks.RecurringTransaction) quickbooks.RecurringScheduleInfo {
+	return templateOf(rt).Info.ScheduleInfo
+}
+
+// QuickBooks offers neither change data capture nor webhooks for recurring transactions, so they
+// are synced in full every time. Template ids are only unique per transaction type and are
+// prefixed with it.
+var recurringTransaction = app.NewStandardType(
+	"RecurringTransaction",
+	"recurringTransaction",
+	"Recurring Transaction",
+	func(rt quickbooks.RecurringTransaction) string {
+		t := templateOf(rt)
+		return t.TxnType + ":" + t.Id
+	},
+	func(bir quickbooks.BatchItemResponse) quickbooks.RecurringTransaction {
+		return bir.RecurringTransaction
+	},
+	func(bqr quickbooks.BatchQueryResponse) []quickbooks.RecurringTransaction {
+		return bqr.RecurringTransaction
+	},
+	map[string]app.FieldDef[quickbooks.RecurringTransaction]{
+		"qboId":        app.TextField("QBO Id", func(rt quickbooks.RecurringTransaction) string { return templateOf(rt).Id }).ReadOnly(),
+		"name":         app.TitleField("Name", func(rt quickbooks.RecurringTransaction) string { return templateOf(rt).Info.Name }),
+		"__syncAction": app.SyncActionField[quickbooks.RecurringTransaction](),
+		"templateType": app.SelectField("Template Type", recurringTemplateTypes, func(rt quickbooks.RecurringTransaction) string { return templateOf(rt).TxnType }).ReadOnly(),
+		"recurType":    app.SelectField("Type", recurTypes, func(rt quickbooks.RecurringTransaction) string { return templateOf(rt).Info.RecurType }).ReadOnly(),
+		"active":       app.BoolField("Active", func(rt quickbooks.RecurringTransaction) bool { return templateOf(rt).Info.Active }),
+		"intervalType": app.SelectField("Interval", app.EnumOptions("Daily", "Weekly", "Monthly", "Yearly"), func(rt quickbooks.RecurringTransaction) string {
+			return scheduleOf(rt).IntervalType
+		}).ReadOnly(),
+		"numInterval":          app.IntegerField("Every", func(rt quickbooks.RecurringTransaction) int { return scheduleOf(rt).NumInterval }),
+		"startDate":            app.DayField("Start Date", func(rt quickbooks.RecurringTransaction) *quickbooks.Date { return scheduleOf(rt).StartDate }),
+		"nextDate":             app.DayField("Next Date", func(rt quickbooks.RecurringTransaction) *quickbooks.Date { return scheduleOf(rt).NextDate }),
+		"previousDate":         app.DayField("Previous Date", func(rt quickbooks.RecurringTransaction) *quickbooks.Date { return scheduleOf(rt).PreviousDate }),
+		"endDate":              app.DayField("End Date", func(rt quickbooks.RecurringTransaction) *quickbooks.Date { return scheduleOf(rt).EndDate }),
+		"maxOccurrences":       app.IntegerField("Occurrences", func(rt quickbooks.RecurringTransaction) int { return scheduleOf(rt).MaxOccurrences }),
+		"remainingOccurrences": app.IntegerField("Remaining Occurrences", func(rt quickbooks.RecurringTransaction) int { return scheduleOf(rt).RemainingOccurrences }),
+		"docNumber":            app.TextField("Reference No.", func(rt quickbooks.RecurringTransaction) string { return templateOf(rt).DocNumber }),
+		"privateNote":          app.MarkdownField("Memo", func(rt quickbooks.RecurringTransaction) string { return templateOf(rt).PrivateNote }),
+		"totalAmt":             app.MoneyField("Total", func(rt quickbooks.RecurringTransaction) json.Number { return templateOf(rt).TotalAmt }),
+		"currency": app.ReferenceField("Currency", func(rt quickbooks.RecurringTransaction) *quickbooks.ReferenceType { return templateOf(rt).CurrencyRef }).
+			Describe("ISO code of the currency the template is in"),
+		"vendorId": app.ReferenceField("Vendor Id", func(rt quickbooks.RecurringTransaction) *quickbooks.ReferenceType { return templateOf(rt).VendorRef }).
+			Relation(fibery.MTO, "Vendor", "Recurring Transactions", "vendor"),
+		"customerId": app.ReferenceField("Customer Id", func(rt quickbooks.RecurringTransaction) *quickbooks.ReferenceType { return templateOf(rt).CustomerRef }).
+			Relation(fibery.MTO, "Customer", "Recurring Transactions", "customer"),
+	},
+)
+
+func init() {
+	// QuickBooks also keeps templates for transaction types templateOf does not read, which would
+	// all share the id ":", so they are left out
+	recurringTransaction.ItemCheck = func(rt quickbooks.RecurringTransaction) bool {
+		return templateOf(rt).TxnType != ""
+	}
+	app.Types.Register(recurringTransaction)
+	app.FeatureFields.Require(app.MultiCurrency, "recurringTransaction", "currency")
+	app.ForeignAmounts.Register("recurringTransaction", "totalAmt")
+}
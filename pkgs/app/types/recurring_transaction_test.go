@@ -0,0 +1,90 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+func TestRecurringTransactionConvert(t *testing.T) {
+	next := &quickbooks.Date{Time: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+	monthly := &quickbooks.RecurringInfo{
+		Name:      "Phone",
+		RecurType: "Automated",
+		Active:    true,
+		ScheduleInfo: quickbooks.RecurringScheduleInfo{
+			IntervalType: "Monthly", NumInterval: 1, NextDate: next, RemainingOccurrences: 7,
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		template quickbooks.RecurringTransaction
+		want     map[string]any
+	}{
+		"bill": {
+			template: quickbooks.RecurringTransaction{Bill: &quickbooks.Bill{
+				Id: "12", TotalAmt: "103.55", VendorRef: quickbooks.ReferenceType{Value: "4"}, RecurringInfo: monthly,
+			}},
+			want: map[string]any{
+				"id": "Bill:12", "qboId": "12", "templateType": "Bill", "name": "Phone", "recurType": "Automated",
+				"intervalType": "Monthly", "nextDate": next.Format(fibery.DateFormat), "endDate": "",
+				"remainingOccurrences": 7, "totalAmt": "103.55", "vendorId": "4", "customerId": "",
+			},
+		},
+		"invoice with the same id": {
+			template: quickbooks.RecurringTransaction{Invoice: &quickbooks.Invoice{
+				Id: "12", CustomerRef: quickbooks.ReferenceType{Value: "2"}, RecurringInfo: &quickbooks.RecurringInfo{
+					Name: "Board storage", RecurType: "Reminded", ScheduleInfo: quickbooks.RecurringScheduleInfo{NumInterval: 2},
+				},
+			}},
+			want: map[string]any{
+				"id": "Invoice:12", "templateType": "Invoice", "name": "Board storage", "recurType": "Reminded",
+				"numInterval": 2, "customerId": "2", "vendorId": "",
+			},
+		},
+		"journal entry": {
+			template: quickbooks.RecurringTransaction{JournalEntry: &quickbooks.JournalEntry{Id: "7", RecurringInfo: &quickbooks.RecurringInfo{RecurType: "UnScheduled"}}},
+			want:     map[string]any{"id": "JournalEntry:7", "templateType": "Journal Entry", "recurType": "Unscheduled", "vendorId": "", "customerId": ""},
+		},
+		"template without recurring info": {
+			template: quickbooks.RecurringTransaction{Bill: &quickbooks.Bill{Id: "13"}},
+			want:     map[string]any{"id": "Bill:13", "name": "", "active": false, "nextDate": "", "numInterval": 0},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			item, err := recurringTransaction.Convert(app.StandardData[quickbooks.RecurringTransaction]{Item: tc.template})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for field, want := range tc.want {
+				if got := item[field]; fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("expected %s to be %v, got %v", field, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRecurringTransactionUnknownTemplates(t *testing.T) {
+	batch := &quickbooks.BatchItemResponse{QueryResponse: quickbooks.BatchQueryResponse{
+		RecurringTransaction: []quickbooks.RecurringTransaction{
+			{Bill: &quickbooks.Bill{Id: "12"}},
+			{},
+			{},
+		},
+	}}
+	items, more, err := recurringTransaction.ProcessBatchQuery(batch, nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0]["id"] != "Bill:12" {
+		t.Errorf("expected only the bill template, got %v", items)
+	}
+	if !more {
+		t.Error("expected the skipped templates to still count toward a full page")
+	}
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"strings"
@@ -30,16 +31,7 @@ var reimburseCharge = app.NewCDCType(
 		return cr.ReimburseCharge
 	},
 	map[string]app.FieldDef[quickbooks.ReimburseCharge]{
-		"qboId": {
-			Params: fibery.Field{
-				Name:     "QBO ID",
-				Type:     fibery.Text,
-				ReadOnly: true,
-			},
-			Convert: func(sd app.StandardData[quickbooks.ReimburseCharge]) (any, error) {
-				return sd.Item.Id, nil
-			},
-		},
+		"qboId": app.TextField("QBO ID", func(r quickbooks.ReimburseCharge) string { return r.Id }).ReadOnly(),
 		"name": {
 			Params: fibery.Field{
 				Name:    "Name",
@@ -56,88 +48,18 @@ var reimburseCharge = app.NewCDCType(
 				return name, nil
 			},
 		},
-		"description": {
-			Params: fibery.Field{
-				Name: "Description",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.ReimburseCharge]) (any, error) {
-				return sd.Item.PrivateNote, nil
-			},
-		},
-		"__syncAction": {
-			Params: fibery.Field{
-				Type: fibery.Text,
-				Name: "Sync Action",
-			},
-			Convert: func(sd app.StandardData[quickbooks.ReimburseCharge]) (any, error) {
-				return fibery.SET, nil
-			},
-		},
-		"syncToken": {
-			Params: fibery.Field{
-				Name:     "Sync Token",
-				Type:     fibery.Text,
-				ReadOnly: true,
-			},
-			Convert: func(sd app.StandardData[quickbooks.ReimburseCharge]) (any, error) {
-				return sd.Item.SyncToken, nil
-			},
-		},
-		"txnDate": {
-			Params: fibery.Field{
-				Name:    "Date",
-				Type:    fibery.DateType,
-				SubType: fibery.Day,
-			},
-			Convert: func(sd app.StandardData[quickbooks.ReimburseCharge]) (any, error) {
-				if sd.Item.TxnDate != nil {
-					return sd.Item.TxnDate.Format(fibery.DateFormat), nil
-				}
-				return "", nil
-			},
-		},
-		"customerId": {
-			Params: fibery.Field{
-				Name: "Customer ID",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Customer",
-					TargetName:    "Reimburse Charges",
-					TargetType:    "customer",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.ReimburseCharge]) (any, error) {
-				return sd.Item.CustomerRef.Value, nil
-			},
-		},
-		"totalAmount": {
-			Params: fibery.Field{
-				Name: "Total Amount",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Money",
-					"currencyCode":         "USD",
-					"hasThousandSeperator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.ReimburseCharge]) (any, error) {
-				return sd.Item.Amount, nil
-			},
-		},
+		"description":  app.TextField("Description", func(r quickbooks.ReimburseCharge) string { return r.PrivateNote }),
+		"__syncAction": app.SyncActionField[quickbooks.ReimburseCharge](),
+		"syncToken":    app.TextField("Sync Token", func(r quickbooks.ReimburseCharge) string { return r.SyncToken }).ReadOnly(),
+		"txnDate":      app.DayField("Date", func(r quickbooks.ReimburseCharge) *quickbooks.Date { return r.TxnDate }),
+		"customerId": app.ReferenceField("Customer ID", func(r quickbooks.ReimburseCharge) *quickbooks.ReferenceType { return &r.CustomerRef }).
+			Relation(fibery.MTO, "Customer", "Reimburse Charges", "customer"),
+		"totalAmount": app.MoneyField("Total Amount", func(r quickbooks.ReimburseCharge) json.Number { return r.Amount }),
 		"amount": {
 			Params: fibery.Field{
-				Name: "Amount",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Money",
-					"currencyCode":         "USD",
-					"hasThousandSeperator": true,
-					"precision":            2,
-				},
+				Name:   "Amount",
+				Type:   fibery.Number,
+				Format: app.MoneyFormat(),
 			},
 			Convert: func(sd app.StandardData[quickbooks.ReimburseCharge]) (any, error) {
 				for _, line := range sd.Item.Line {
@@ -148,6 +70,12 @@ var reimburseCharge = app.NewCDCType(
 				return 0, nil
 			},
 		},
+		"currency": app.ReferenceField("Currency", func(r quickbooks.ReimburseCharge) *quickbooks.ReferenceType { return &r.CurrencyRef }).
+			Describe("ISO code of the currency the charge is in"),
+		"exchangeRate": app.ExchangeRateField(func(r quickbooks.ReimburseCharge) json.Number { return r.ExchangeRate }),
+		"homeTotalAmount": app.HomeMoneyField("Home Total Amount",
+			func(r quickbooks.ReimburseCharge) json.Number { return r.Amount },
+			func(r quickbooks.ReimburseCharge) json.Number { return r.ExchangeRate }),
 		"accountId": {
 			Params: fibery.Field{
 				Name: "Account ID",
@@ -242,13 +170,6 @@ var reimburseCharge = app.NewCDCType(
 			Params: fibery.Field{
 				Name: "Linked Invoice ID",
 				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Linked Invoice",
-					TargetName:    "Reimburse Charges",
-					TargetType:    "invoice",
-					TargetFieldID: "id",
-				},
 			},
 			Convert: func(sd app.StandardData[quickbooks.ReimburseCharge]) (any, error) {
 				for _, txn := range sd.Item.LinkedTxn {
@@ -264,4 +185,6 @@ var reimburseCharge = app.NewCDCType(
 
 func init() {
 	app.Types.Register(reimburseCharge)
+	app.FeatureFields.Require(app.MultiCurrency, "reimbursecharge", "currency", "exchangeRate", "homeTotalAmount")
+	app.ForeignAmounts.Register("reimbursecharge", "totalAmount", "amount")
 }
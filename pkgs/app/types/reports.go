@@ -0,0 +1,235 @@
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+)
+
+// agingColumns maps aging report field ids to the QuickBooks column titles they are read from.
+var agingColumns = []struct {
+	fieldId, name, column string
+}{
+	{"current", "Current", "Current"},
+	{"days1To30", "1 - 30 Days", "1 - 30"},
+	{"days31To60", "31 - 60 Days", "31 - 60"},
+	{"days61To90", "61 - 90 Days", "61 - 90"},
+	{"days91AndOver", "91 and Over", "91 and over"},
+	{"total", "Total", "Total"},
+}
+
+func reportDate(value string) (any, error) {
+	if value == "" {
+		return "", nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return date.Format(fibery.DateFormat), nil
+}
+
+// reportFields are the fields every report type shares, merged with the report's own fields.
+func reportFields(addlFields map[string]app.ReportFieldDef) map[string]app.ReportFieldDef {
+	fields := map[string]app.ReportFieldDef{
+		"name": {
+			Params: fibery.Field{
+				Name:    "Name",
+				Type:    fibery.Text,
+				SubType: fibery.Title,
+			},
+			Convert: func(row app.ReportRow) (any, error) {
+				return row.Label, nil
+			},
+		},
+		"__syncAction": {
+			Params: fibery.Field{
+				Name: "Sync Action",
+				Type: fibery.Text,
+			},
+			Convert: func(row app.ReportRow) (any, error) {
+				return fibery.SET, nil
+			},
+		},
+		"period": {
+			Params: fibery.Field{
+				Name: "Period",
+				Type: fibery.Text,
+			},
+			Convert: func(row app.ReportRow) (any, error) {
+				return row.Period, nil
+			},
+		},
+		"startPeriod": {
+			Params: fibery.Field{
+				Name:    "Start Period",
+				Type:    fibery.DateType,
+				SubType: fibery.Day,
+			},
+			Convert: func(row app.ReportRow) (any, error) {
+				return reportDate(row.Header.StartPeriod)
+			},
+		},
+		"endPeriod": {
+			Params: fibery.Field{
+				Name:    "End Period",
+				Type:    fibery.DateType,
+				SubType: fibery.Day,
+			},
+			Convert: func(row app.ReportRow) (any, error) {
+				return reportDate(row.Header.EndPeriod)
+			},
+		},
+		"path": {
+			Params: fibery.Field{
+				Name: "Path",
+				Type: fibery.Text,
+			},
+			Convert: func(row app.ReportRow) (any, error) {
+				return strings.Join(row.Path, " / "), nil
+			},
+		},
+		"depth": {
+			Params: fibery.Field{
+				Name:    "Depth",
+				Type:    fibery.Number,
+				SubType: fibery.Integer,
+			},
+			Convert: func(row app.ReportRow) (any, error) {
+				return len(row.Path), nil
+			},
+		},
+		"rowType": {
+			Params: fibery.Field{
+				Name:     "Row Type",
+				Type:     fibery.Text,
+				SubType:  fibery.SingleSelect,
+				ReadOnly: true,
+				Options:  app.SelectOptions(app.ReportDataRow, app.ReportSummaryRow),
+			},
+			Convert: func(row app.ReportRow) (any, error) {
+				return row.Type, nil
+			},
+		},
+		"group": {
+			Params: fibery.Field{
+				Name: "Group",
+				Type: fibery.Text,
+			},
+			Convert: func(row app.ReportRow) (any, error) {
+				return row.Group, nil
+			},
+		},
+	}
+	for k, v := range addlFields {
+		fields[k] = v
+	}
+	return fields
+}
+
+func accountReportFields(targetName string) map[string]app.ReportFieldDef {
+	return reportFields(map[string]app.ReportFieldDef{
+		"amount": {
+			Params: fibery.Field{
+				Name:   "Amount",
+				Type:   fibery.Number,
+				Format: app.MoneyFormat(),
+			},
+			Convert: func(row app.ReportRow) (any, error) {
+				return row.Amount("Total")
+			},
+		},
+		"accountId": {
+			Params: fibery.Field{
+				Name: "Account Id",
+				Type: fibery.Text,
+				Relation: &fibery.Relation{
+					Cardinality:   fibery.MTO,
+					Name:          "Account",
+					TargetName:    targetName,
+					TargetType:    "account",
+					TargetFieldID: "id",
+				},
+			},
+			Convert: func(row app.ReportRow) (any, error) {
+				return row.RefId, nil
+			},
+		},
+	})
+}
+
+func agingReportFields(relationId, relationName, targetType, targetName string) map[string]app.ReportFieldDef {
+	fields := map[string]app.ReportFieldDef{
+		relationId: {
+			Params: fibery.Field{
+				Name: relationName + " Id",
+				Type: fibery.Text,
+				Relation: &fibery.Relation{
+					Cardinality:   fibery.MTO,
+					Name:          relationName,
+					TargetName:    targetName,
+					TargetType:    targetType,
+					TargetFieldID: "id",
+				},
+			},
+			Convert: func(row app.ReportRow) (any, error) {
+				return row.RefId, nil
+			},
+		},
+	}
+	for _, col := range agingColumns {
+		column := col.column
+		fields[col.fieldId] = app.ReportFieldDef{
+			Params: fibery.Field{
+				Name:   col.name,
+				Type:   fibery.Number,
+				Format: app.MoneyFormat(),
+			},
+			Convert: func(row app.ReportRow) (any, error) {
+				return row.Amount(column)
+			},
+		}
+	}
+	return reportFields(fields)
+}
+
+var profitAndLoss = app.NewReportType(
+	"ProfitAndLoss",
+	"profitAndLoss",
+	"Profit and Loss",
+	false,
+	accountReportFields("Profit and Loss Rows"),
+)
+
+var balanceSheet = app.NewReportType(
+	"BalanceSheet",
+	"balanceSheet",
+	"Balance Sheet",
+	false,
+	accountReportFields("Balance Sheet Rows"),
+)
+
+var agedReceivables = app.NewReportType(
+	"AgedReceivables",
+	"agedReceivables",
+	"Aged Receivables",
+	true,
+	agingReportFields("customerId", "Customer", "customer", "Aged Receivables"),
+)
+
+var agedPayables = app.NewReportType(
+	"AgedPayables",
+	"agedPayables",
+	"Aged Payables",
+	true,
+	agingReportFields("vendorId", "Vendor", "vendor", "Aged Payables"),
+)
+
+func init() {
+	app.Types.Register(profitAndLoss)
+	app.Types.Register(balanceSheet)
+	app.Types.Register(agedReceivables)
+	app.Types.Register(agedPayables)
+}
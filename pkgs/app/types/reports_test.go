@@ -0,0 +1,55 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+func TestProcessReports(t *testing.T) {
+	aging := &quickbooks.Report{
+		Header: quickbooks.ReportHeader{ReportName: "AgedReceivables"},
+		Columns: quickbooks.ReportColumns{Column: []quickbooks.ReportColumn{
+			{ColTitle: ""}, {ColTitle: "Current"}, {ColTitle: "1 - 30"}, {ColTitle: "91 and over"}, {ColTitle: "Total"},
+		}},
+		Rows: quickbooks.ReportRows{Row: []quickbooks.ReportRow{
+			{ColData: []quickbooks.ReportColData{{Value: "Cool Cars", Id: "3"}, {Value: ""}, {Value: "694.00"}, {Value: "1000.00"}, {Value: "1694.00"}}},
+			{ColData: []quickbooks.ReportColData{{Value: "Cool Cars", Id: "9"}, {Value: "5.00"}, {Value: ""}, {Value: ""}, {Value: "5.00"}}},
+			{Group: "GrandTotal", Summary: &quickbooks.ReportRowData{ColData: []quickbooks.ReportColData{{Value: "TOTAL"}, {Value: "5.00"}}}},
+		}},
+	}
+	items, err := agedReceivables.ProcessReports([]app.ReportPeriod{{Period: "Today", Report: aging}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("expected 3 rows, got %v", items)
+	}
+
+	for _, tc := range []struct {
+		item                      map[string]any
+		id, name, customerId      string
+		current, days1To30, total float64
+		rowType                   string
+	}{
+		{items[0], "AgedReceivables:Today:Cool Cars", "Cool Cars", "3", 0, 694, 1694, app.ReportDataRow},
+		{items[1], "AgedReceivables:Today:Cool Cars (2)", "Cool Cars", "9", 5, 0, 5, app.ReportDataRow},
+		{items[2], "AgedReceivables:Today:GrandTotal", "TOTAL", "", 5, 0, 0, app.ReportSummaryRow},
+	} {
+		if tc.item["id"] != tc.id || tc.item["name"] != tc.name || tc.item["customerId"] != tc.customerId || tc.item["rowType"] != tc.rowType {
+			t.Errorf("unexpected identity for %s: %v", tc.id, tc.item)
+		}
+		if tc.item["current"] != tc.current || tc.item["days1To30"] != tc.days1To30 || tc.item["total"] != tc.total {
+			t.Errorf("unexpected amounts for %s: %v", tc.id, tc.item)
+		}
+	}
+
+	bad := &quickbooks.Report{
+		Columns: quickbooks.ReportColumns{Column: []quickbooks.ReportColumn{{ColTitle: ""}, {ColTitle: "Total"}}},
+		Rows:    quickbooks.ReportRows{Row: []quickbooks.ReportRow{{ColData: []quickbooks.ReportColData{{Value: "Supplies"}, {Value: "n/a"}}}}},
+	}
+	if _, err := profitAndLoss.ProcessReports([]app.ReportPeriod{{Period: "This Month", Report: bad}}); err == nil {
+		t.Error("expected an invalid amount to fail")
+	}
+}
@@ -0,0 +1,255 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+var taxAgency = app.NewDualType(
+	"TaxAgency",
+	"taxAgency",
+	"Tax Agency",
+	func(a quickbooks.TaxAgency) string {
+		return a.Id
+	},
+	func(a quickbooks.TaxAgency) string {
+		return a.Status
+	},
+	func(id string) quickbooks.TaxAgency {
+		return quickbooks.TaxAgency{
+			Id: id,
+		}
+	},
+	func(bir quickbooks.BatchItemResponse) quickbooks.TaxAgency {
+		return bir.TaxAgency
+	},
+	func(bqr quickbooks.BatchQueryResponse) []quickbooks.TaxAgency {
+		return bqr.TaxAgency
+	},
+	func(cr quickbooks.CDCQueryResponse) []quickbooks.TaxAgency {
+		return cr.TaxAgency
+	},
+	map[string]app.FieldDef[quickbooks.TaxAgency]{
+		"qboId":                 app.TextField("QBO Id", func(a quickbooks.TaxAgency) string { return a.Id }).ReadOnly(),
+		"name":                  app.TitleField("Name", func(a quickbooks.TaxAgency) string { return a.DisplayName }),
+		"syncToken":             app.TextField("Sync Token", func(a quickbooks.TaxAgency) string { return a.SyncToken }).ReadOnly(),
+		"__syncAction":          app.SyncActionField[quickbooks.TaxAgency](),
+		"taxRegistrationNumber": app.TextField("Registration Number", func(a quickbooks.TaxAgency) string { return a.TaxRegistrationNumber }),
+		"taxTrackedOnSales":     app.BoolField("Tracked On Sales", func(a quickbooks.TaxAgency) bool { return a.TaxTrackedOnSales }),
+		"taxTrackedOnPurchases": app.BoolField("Tracked On Purchases", func(a quickbooks.TaxAgency) bool { return a.TaxTrackedOnPurchases }),
+	},
+	nil,
+)
+
+// QuickBooks offers neither change data capture nor webhooks for tax rates and tax codes, so
+// they are synced in full every time.
+var taxRate = app.NewStandardType(
+	"TaxRate",
+	"taxRate",
+	"Tax Rate",
+	func(r quickbooks.TaxRate) string {
+		return r.Id
+	},
+	func(bir quickbooks.BatchItemResponse) quickbooks.TaxRate {
+		return bir.TaxRate
+	},
+	func(bqr quickbooks.BatchQueryResponse) []quickbooks.TaxRate {
+		return bqr.TaxRate
+	},
+	map[string]app.FieldDef[quickbooks.TaxRate]{
+		"qboId":          app.TextField("QBO Id", func(r quickbooks.TaxRate) string { return r.Id }).ReadOnly(),
+		"name":           app.TitleField("Name", func(r quickbooks.TaxRate) string { return r.Name }),
+		"syncToken":      app.TextField("Sync Token", func(r quickbooks.TaxRate) string { return r.SyncToken }).ReadOnly(),
+		"__syncAction":   app.SyncActionField[quickbooks.TaxRate](),
+		"description":    app.TextField("Description", func(r quickbooks.TaxRate) string { return r.Description }),
+		"active":         app.BoolField("Active", func(r quickbooks.TaxRate) bool { return r.Active }),
+		"rateValue":      app.NumberField("Rate", app.PercentFormat(), func(r quickbooks.TaxRate) json.Number { return r.RateValue }),
+		"specialTaxType": app.TextField("Special Tax Type", func(r quickbooks.TaxRate) string { return r.SpecialTaxType }).ReadOnly(),
+		"agencyId": app.ReferenceField("Agency Id", func(r quickbooks.TaxRate) *quickbooks.ReferenceType { return r.AgencyRef }).
+			Relation(fibery.MTO, "Agency", "Tax Rates", "taxAgency"),
+	},
+)
+
+var taxCode = app.NewStandardType(
+	"TaxCode",
+	"taxCode",
+	"Tax Code",
+	func(c quickbooks.TaxCode) string {
+		return c.Id
+	},
+	func(bir quickbooks.BatchItemResponse) quickbooks.TaxCode {
+		return bir.TaxCode
+	},
+	func(bqr quickbooks.BatchQueryResponse) []quickbooks.TaxCode {
+		return bqr.TaxCode
+	},
+	map[string]app.FieldDef[quickbooks.TaxCode]{
+		"qboId":        app.TextField("QBO Id", func(c quickbooks.TaxCode) string { return c.Id }).ReadOnly(),
+		"name":         app.TitleField("Name", func(c quickbooks.TaxCode) string { return c.Name }),
+		"syncToken":    app.TextField("Sync Token", func(c quickbooks.TaxCode) string { return c.SyncToken }).ReadOnly(),
+		"__syncAction": app.SyncActionField[quickbooks.TaxCode](),
+		"description":  app.TextField("Description", func(c quickbooks.TaxCode) string { return c.Description }),
+		"active":       app.BoolField("Active", func(c quickbooks.TaxCode) bool { return c.Active }),
+		"taxable":      app.BoolField("Taxable", func(c quickbooks.TaxCode) bool { return c.Taxable }),
+		"taxGroup":     app.BoolField("Tax Group", func(c quickbooks.TaxCode) bool { return c.TaxGroup }),
+	},
+)
+
+// taxCodeRateDetail is a rate of a tax code together with the list it is on, as the same rate
+// can apply to both sales and purchases.
+type taxCodeRateDetail struct {
+	List string
+	quickbooks.TaxRateDetail
+}
+
+var taxCodeRate = app.NewDependentType(
+	"TaxCode",
+	"taxCodeRate",
+	"Tax Code Rate",
+	func(c quickbooks.TaxCode, d taxCodeRateDetail) string {
+		return fmt.Sprintf("%s:%s:%s", c.Id, d.List, d.TaxRateRef.Value)
+	},
+	func(c quickbooks.TaxCode, d taxCodeRateDetail) bool {
+		return d.TaxRateRef.Value != ""
+	},
+	func(c quickbooks.TaxCode) []taxCodeRateDetail {
+		details := make([]taxCodeRateDetail, 0, len(c.SalesTaxRateList.TaxRateDetail)+len(c.PurchaseTaxRateList.TaxRateDetail))
+		for _, d := range c.SalesTaxRateList.TaxRateDetail {
+			details = append(details, taxCodeRateDetail{List: "Sales", TaxRateDetail: d})
+		}
+		for _, d := range c.PurchaseTaxRateList.TaxRateDetail {
+			details = append(details, taxCodeRateDetail{List: "Purchase", TaxRateDetail: d})
+		}
+		return details
+	},
+	func(bir quickbooks.BatchItemResponse) quickbooks.TaxCode {
+		return bir.TaxCode
+	},
+	func(bqr quickbooks.BatchQueryResponse) []quickbooks.TaxCode {
+		return bqr.TaxCode
+	},
+	map[string]app.DependentFieldDef[quickbooks.TaxCode, taxCodeRateDetail]{
+		"name": {
+			Params: fibery.Field{
+				Name:    "Name",
+				Type:    fibery.Text,
+				SubType: fibery.Title,
+			},
+			Convert: func(dd app.DependentData[quickbooks.TaxCode, taxCodeRateDetail]) (any, error) {
+				return dd.SourceItem.Name + " - " + dd.Item.TaxRateRef.Name, nil
+			},
+		},
+		"__syncAction":      app.DependentField[quickbooks.TaxCode](app.SyncActionField[taxCodeRateDetail]()),
+		"list":              app.DependentField[quickbooks.TaxCode](app.SelectField("Applies To", app.EnumOptions("Sales", "Purchase"), func(d taxCodeRateDetail) string { return d.List }).ReadOnly()),
+		"taxTypeApplicable": app.DependentField[quickbooks.TaxCode](app.TextField("Tax Type", func(d taxCodeRateDetail) string { return d.TaxTypeApplicable }).ReadOnly()),
+		"taxOrder":          app.DependentField[quickbooks.TaxCode](app.IntegerField("Order", func(d taxCodeRateDetail) int { return d.TaxOrder })),
+		"taxCodeId": {
+			Params: fibery.Field{
+				Name: "Tax Code ID",
+				Type: fibery.Text,
+				Relation: &fibery.Relation{
+					Cardinality:   fibery.MTO,
+					Name:          "Tax Code",
+					TargetName:    "Rates",
+					TargetType:    "taxCode",
+					TargetFieldID: "id",
+				},
+			},
+			Convert: func(dd app.DependentData[quickbooks.TaxCode, taxCodeRateDetail]) (any, error) {
+				return dd.SourceItem.Id, nil
+			},
+		},
+		"taxRateId": app.DependentField[quickbooks.TaxCode](app.ReferenceField("Tax Rate ID", func(d taxCodeRateDetail) *quickbooks.ReferenceType { return &d.TaxRateRef }).
+			Relation(fibery.MTO, "Tax Rate", "Tax Codes", "taxRate")),
+	},
+)
+
+// Transaction tax lines carry no id of their own. A transaction has at most one tax line per
+// rate, so lines are keyed by the rate.
+var txnTaxLine = app.NewDependentDualType(
+	"Bill",
+	"txnTaxLine",
+	"Transaction Tax Line",
+	func(b quickbooks.Bill, l quickbooks.Line) string {
+		return fmt.Sprintf("%s:t:%s", b.Id, l.TaxLineDetail.TaxRateRef.Value)
+	},
+	func(b quickbooks.Bill, l quickbooks.Line) bool {
+		return l.DetailType == quickbooks.TaxLine
+	},
+	func(b quickbooks.Bill) []quickbooks.Line {
+		if b.TxnTaxDetail == nil {
+			return nil
+		}
+		return b.TxnTaxDetail.TaxLine
+	},
+	func(b quickbooks.Bill) string {
+		return b.Id
+	},
+	func(b quickbooks.Bill) string {
+		return b.Status
+	},
+	func(id string) quickbooks.Bill {
+		return quickbooks.Bill{
+			Id: id,
+		}
+	},
+	func(bir quickbooks.BatchItemResponse) quickbooks.Bill {
+		return bir.Bill
+	},
+	func(bqr quickbooks.BatchQueryResponse) []quickbooks.Bill {
+		return bqr.Bill
+	},
+	func(cr quickbooks.CDCQueryResponse) []quickbooks.Bill {
+		return cr.Bill
+	},
+	map[string]app.DependentFieldDef[quickbooks.Bill, quickbooks.Line]{
+		"name": {
+			Params: fibery.Field{
+				Name:    "Name",
+				Type:    fibery.Text,
+				SubType: fibery.Title,
+			},
+			Convert: func(dd app.DependentData[quickbooks.Bill, quickbooks.Line]) (any, error) {
+				return dd.Item.TaxLineDetail.TaxRateRef.Name, nil
+			},
+		},
+		"__syncAction": app.DependentField[quickbooks.Bill](app.SyncActionField[quickbooks.Line]()),
+		"percentBased": app.DependentField[quickbooks.Bill](app.BoolField("Percent Based", func(l quickbooks.Line) bool { return l.TaxLineDetail.PercentBased })),
+		"taxPercent": app.DependentField[quickbooks.Bill](app.NumberField("Tax Percent", app.PercentFormat(), func(l quickbooks.Line) json.Number {
+			return l.TaxLineDetail.TaxPercent
+		})),
+		"netAmountTaxable": app.DependentField[quickbooks.Bill](app.MoneyField("Taxable Amount", func(l quickbooks.Line) json.Number { return l.TaxLineDetail.NetAmountTaxable })),
+		"amount":           app.DependentField[quickbooks.Bill](app.MoneyField("Tax Amount", func(l quickbooks.Line) json.Number { return l.Amount })),
+		"billId": {
+			Params: fibery.Field{
+				Name: "Bill ID",
+				Type: fibery.Text,
+				Relation: &fibery.Relation{
+					Cardinality:   fibery.MTO,
+					Name:          "Bill",
+					TargetName:    "Tax Lines",
+					TargetType:    "bill",
+					TargetFieldID: "id",
+				},
+			},
+			Convert: func(dd app.DependentData[quickbooks.Bill, quickbooks.Line]) (any, error) {
+				return dd.SourceItem.Id, nil
+			},
+		},
+		"taxRateId": app.DependentField[quickbooks.Bill](app.ReferenceField("Tax Rate ID", func(l quickbooks.Line) *quickbooks.ReferenceType { return &l.TaxLineDetail.TaxRateRef }).
+			Relation(fibery.MTO, "Tax Rate", "Transaction Tax Lines", "taxRate")),
+	},
+)
+
+func init() {
+	app.Types.Register(taxAgency)
+	app.Types.Register(taxRate)
+	app.Types.Register(taxCode)
+	app.Types.Register(taxCodeRate)
+	app.Types.Register(txnTaxLine)
+	app.ForeignAmounts.Register("txnTaxLine", "netAmountTaxable", "amount")
+}
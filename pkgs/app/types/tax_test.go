@@ -0,0 +1,82 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+func TestTaxCodeRates(t *testing.T) {
+	rate := func(id, name string, order int) quickbooks.TaxRateDetail {
+		return quickbooks.TaxRateDetail{TaxRateRef: quickbooks.ReferenceType{Value: id, Name: name}, TaxTypeApplicable: "TaxOnAmount", TaxOrder: order}
+	}
+	code := quickbooks.TaxCode{
+		Id:                  "2",
+		Name:                "California",
+		SalesTaxRateList:    quickbooks.TaxRateList{TaxRateDetail: []quickbooks.TaxRateDetail{rate("3", "State", 0), rate("4", "County", 1)}},
+		PurchaseTaxRateList: quickbooks.TaxRateList{TaxRateDetail: []quickbooks.TaxRateDetail{rate("3", "State", 0)}},
+	}
+
+	details := taxCodeRate.ItemExtractor(code)
+	want := []struct {
+		id, name, list, taxRateId string
+		taxOrder                  int
+	}{
+		{"2:Sales:3", "California - State", "Sales", "3", 0},
+		{"2:Sales:4", "California - County", "Sales", "4", 1},
+		{"2:Purchase:3", "California - State", "Purchase", "3", 0},
+	}
+	if len(details) != len(want) {
+		t.Fatalf("expected a rate per list entry, got %v", details)
+	}
+	for n, tc := range want {
+		if id := taxCodeRate.ItemId(code, details[n]); id != tc.id {
+			t.Errorf("expected rate id %s, got %s", tc.id, id)
+		}
+		item, err := taxCodeRate.Convert(app.DependentData[quickbooks.TaxCode, taxCodeRateDetail]{SourceItem: code, Item: details[n]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item["name"] != tc.name || item["list"] != tc.list || item["taxCodeId"] != "2" || item["taxRateId"] != tc.taxRateId || item["taxOrder"] != tc.taxOrder {
+			t.Errorf("unexpected tax code rate %s: %v", tc.id, item)
+		}
+	}
+}
+
+func TestTxnTaxLines(t *testing.T) {
+	bill := quickbooks.Bill{
+		Id: "26",
+		TxnTaxDetail: &quickbooks.TxnTaxDetail{TaxLine: []quickbooks.Line{{
+			Amount:     "4",
+			DetailType: quickbooks.TaxLine,
+			TaxLineDetail: quickbooks.TaxLineDetail{
+				TaxRateRef:       quickbooks.ReferenceType{Value: "3", Name: "State"},
+				PercentBased:     true,
+				TaxPercent:       "8",
+				NetAmountTaxable: "50",
+			},
+		}}},
+	}
+
+	lines := txnTaxLine.ItemExtractor(bill)
+	if len(lines) != 1 {
+		t.Fatalf("expected one tax line, got %v", lines)
+	}
+	if id := txnTaxLine.ItemId(bill, lines[0]); id != "26:t:3" {
+		t.Errorf("unexpected tax line id %s", id)
+	}
+	line, err := txnTaxLine.Convert(app.DependentData[quickbooks.Bill, quickbooks.Line]{SourceItem: bill, Item: lines[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line["name"] != "State" || line["billId"] != "26" || line["taxRateId"] != "3" || line["percentBased"] != true ||
+		fmt.Sprint(line["taxPercent"]) != "8" || fmt.Sprint(line["netAmountTaxable"]) != "50" || fmt.Sprint(line["amount"]) != "4" {
+		t.Errorf("unexpected tax line: %v", line)
+	}
+
+	if lines := txnTaxLine.ItemExtractor(quickbooks.Bill{Id: "25"}); len(lines) != 0 {
+		t.Errorf("expected bills without tax detail to have no tax lines, got %v", lines)
+	}
+}
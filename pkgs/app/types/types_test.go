@@ -0,0 +1,41 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+)
+
+func TestRegisteredSchemas(t *testing.T) {
+	if err := app.Types.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRelations(t *testing.T) {
+	for _, tc := range []struct {
+		typeId, fieldId, targetType string
+	}{
+		{"customer", "defaultTaxCodeId", "taxCode"},
+		{"item", "salesTaxCodeId", "taxCode"},
+		{"item", "purchaseTaxCodeId", "taxCode"},
+		{"taxRate", "agencyId", "taxAgency"},
+		{"taxCodeRate", "taxCodeId", "taxCode"},
+		{"taxCodeRate", "taxRateId", "taxRate"},
+		{"txnTaxLine", "taxRateId", "taxRate"},
+	} {
+		t.Run(tc.typeId+"."+tc.fieldId, func(t *testing.T) {
+			registered, ok := app.Types.Get(tc.typeId)
+			if !ok {
+				t.Fatalf("%s is not registered", tc.typeId)
+			}
+			field, ok := registered.Schema()[tc.fieldId]
+			if !ok {
+				t.Fatalf("%s has no %s field", tc.typeId, tc.fieldId)
+			}
+			if field.Relation == nil || field.Relation.TargetType != tc.targetType {
+				t.Errorf("expected %s.%s to relate to %s, got %+v", tc.typeId, tc.fieldId, tc.targetType, field.Relation)
+			}
+		})
+	}
+}
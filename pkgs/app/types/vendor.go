@@ -1,8 +1,10 @@
 package types
 
 import (
+	"encoding/json"
+	"strings"
+
 	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
-	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
 	"github.com/tommyhedley/quickbooks-go"
 )
 
@@ -31,418 +33,46 @@ var vendor = app.NewDualType(
 		return cr.Vendor
 	},
 	map[string]app.FieldDef[quickbooks.Vendor]{
-		"qboId": {
-			Params: fibery.Field{
-				Name:     "QBO ID",
-				Type:     fibery.Text,
-				ReadOnly: true,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return sd.Item.Id, nil
-			},
-		},
-		"displayName": {
-			Params: fibery.Field{
-				Name:    "Display Name",
-				Type:    fibery.Text,
-				SubType: fibery.Title,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return sd.Item.DisplayName, nil
-			},
-		},
-		"syncToken": {
-			Params: fibery.Field{
-				Name:     "Sync Token",
-				Type:     fibery.Text,
-				ReadOnly: true,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return sd.Item.SyncToken, nil
-			},
-		},
-		"__syncAction": {
-			Params: fibery.Field{
-				Type: fibery.Text,
-				Name: "Sync Action",
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return fibery.SET, nil
-			},
-		},
-		"active": {
-			Params: fibery.Field{
-				Name:    "Active",
-				Type:    fibery.Text,
-				SubType: fibery.Boolean,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return sd.Item.Active, nil
-			},
-		},
-		"title": {
-			Params: fibery.Field{
-				Name: "Title",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return sd.Item.Title, nil
-			},
-		},
-		"givenName": {
-			Params: fibery.Field{
-				Name: "First Name",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return sd.Item.GivenName, nil
-			},
-		},
-		"middleName": {
-			Params: fibery.Field{
-				Name: "Middle Name",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return sd.Item.MiddleName, nil
-			},
-		},
-		"familyName": {
-			Params: fibery.Field{
-				Name: "Last Name",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return sd.Item.FamilyName, nil
-			},
-		},
-		"suffix": {
-			Params: fibery.Field{
-				Name: "Suffix",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return sd.Item.Suffix, nil
-			},
-		},
-		"companyName": {
-			Params: fibery.Field{
-				Name: "Company Name",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return sd.Item.CompanyName, nil
-			},
-		},
-		"primaryEmail": {
-			Params: fibery.Field{
-				Name:    "Email",
-				Type:    fibery.Text,
-				SubType: fibery.Email,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.PrimaryEmailAddr != nil {
-					return sd.Item.PrimaryEmailAddr.Address, nil
-				}
-				return "", nil
-			},
-		},
-		"salesTermId": {
-			Params: fibery.Field{
-				Name: "Sales Term ID",
-				Type: fibery.Text,
-				Relation: &fibery.Relation{
-					Cardinality:   fibery.MTO,
-					Name:          "Sales Term",
-					TargetName:    "Vendors",
-					TargetType:    "SalesTerm",
-					TargetFieldID: "id",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.TermRef != nil {
-					return sd.Item.TermRef.Value, nil
-				}
-				return "", nil
-			},
-		},
-		"primaryPhone": {
-			Params: fibery.Field{
-				Name: "Phone",
-				Type: fibery.Text,
-				Format: map[string]any{
-					"format": "phone",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.PrimaryPhone != nil {
-					return sd.Item.PrimaryPhone.FreeFormNumber, nil
-				}
-				return "", nil
-			},
-		},
-		"alternatePhone": {
-			Params: fibery.Field{
-				Name: "Alternate Phone",
-				Type: fibery.Text,
-				Format: map[string]any{
-					"format": "phone",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.AlternatePhone != nil {
-					return sd.Item.AlternatePhone.FreeFormNumber, nil
-				}
-				return "", nil
-			},
-		},
-		"mobile": {
-			Params: fibery.Field{
-				Name: "Mobile",
-				Type: fibery.Text,
-				Format: map[string]any{
-					"format": "phone",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.Mobile != nil {
-					return sd.Item.Mobile.FreeFormNumber, nil
-				}
-				return "", nil
-			},
-		},
-		"fax": {
-			Params: fibery.Field{
-				Name: "Fax",
-				Type: fibery.Text,
-				Format: map[string]any{
-					"format": "phone",
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.Fax != nil {
-					return sd.Item.Fax.FreeFormNumber, nil
-				}
-				return "", nil
-			},
-		},
-		"1099": {
-			Params: fibery.Field{
-				Name:        "1099",
-				Type:        fibery.Text,
-				SubType:     fibery.Boolean,
-				Description: "Is the Vendor a 1099 contractor?",
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return sd.Item.Vendor1099, nil
-			},
-		},
-		"costRate": {
-			Params: fibery.Field{
-				Name:        "Cost Rate",
-				Type:        fibery.Number,
-				Description: "Default cost rate of the Vendor",
-				Format: map[string]any{
-					"format":               "Money",
-					"currencyCode":         "USD",
-					"hasThousandSeperator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return sd.Item.CostRate, nil
-			},
-		},
-		"billRate": {
-			Params: fibery.Field{
-				Name:        "Bill Rate",
-				Type:        fibery.Number,
-				Description: "Default billing rate of the Vendor",
-				Format: map[string]any{
-					"format":               "Money",
-					"currencyCode":         "USD",
-					"hasThousandSeperator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return sd.Item.BillRate, nil
-			},
-		},
-		"website": {
-			Params: fibery.Field{
-				Name:    "Website",
-				Type:    fibery.Text,
-				SubType: fibery.URL,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.WebAddr != nil {
-					return sd.Item.WebAddr.URI, nil
-				}
-				return "", nil
-			},
-		},
-		"accountNumber": {
-			Params: fibery.Field{
-				Name:        "Account Number",
-				Type:        fibery.Text,
-				Description: "Name or number of the account associated with this vendor",
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return sd.Item.AcctNum, nil
-			},
-		},
-		"balance": {
-			Params: fibery.Field{
-				Name: "Balance",
-				Type: fibery.Number,
-				Format: map[string]any{
-					"format":               "Money",
-					"currencyCode":         "USD",
-					"hasThousandSeperator": true,
-					"precision":            2,
-				},
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				return sd.Item.Balance, nil
-			},
-		},
-		"billingLine1": {
-			Params: fibery.Field{
-				Name: "Billing Line 1",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.BillAddr != nil {
-					return sd.Item.BillAddr.Line1, nil
-				}
-				return "", nil
-			},
-		},
-		"billingLine2": {
-			Params: fibery.Field{
-				Name: "Billing Line 2",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.BillAddr != nil {
-					return sd.Item.BillAddr.Line2, nil
-				}
-				return "", nil
-			},
-		},
-		"billingLine3": {
-			Params: fibery.Field{
-				Name: "Billing Line 3",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.BillAddr != nil {
-					return sd.Item.BillAddr.Line3, nil
-				}
-				return "", nil
-			},
-		},
-		"billingLine4": {
-			Params: fibery.Field{
-				Name: "Billing Line 4",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.BillAddr != nil {
-					return sd.Item.BillAddr.Line4, nil
-				}
-				return "", nil
-			},
-		},
-		"billingLine5": {
-			Params: fibery.Field{
-				Name: "Billing Line 5",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.BillAddr != nil {
-					return sd.Item.BillAddr.Line5, nil
-				}
-				return "", nil
-			},
-		},
-		"billingCity": {
-			Params: fibery.Field{
-				Name: "Billing City",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.BillAddr != nil {
-					return sd.Item.BillAddr.City, nil
-				}
-				return "", nil
-			},
-		},
-		"billingState": {
-			Params: fibery.Field{
-				Name: "Billing State",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.BillAddr != nil {
-					return sd.Item.BillAddr.CountrySubDivisionCode, nil
-				}
-				return "", nil
-			},
-		},
-		"billingPostalCode": {
-			Params: fibery.Field{
-				Name: "Billing Postal Code",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.BillAddr != nil {
-					return sd.Item.BillAddr.PostalCode, nil
-				}
-				return "", nil
-			},
-		},
-		"billingCountry": {
-			Params: fibery.Field{
-				Name: "Billing Country",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.BillAddr != nil {
-					return sd.Item.BillAddr.Country, nil
-				}
-				return "", nil
-			},
-		},
-		"billingLat": {
-			Params: fibery.Field{
-				Name: "Billing Latitude",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.BillAddr != nil {
-					return sd.Item.BillAddr.Lat, nil
-				}
-				return "", nil
-			},
-		},
-		"billingLong": {
-			Params: fibery.Field{
-				Name: "Billing Longitude",
-				Type: fibery.Text,
-			},
-			Convert: func(sd app.StandardData[quickbooks.Vendor]) (any, error) {
-				if sd.Item.BillAddr != nil {
-					return sd.Item.BillAddr.Long, nil
-				}
-				return "", nil
-			},
-		},
+		"qboId":          app.TextField("QBO ID", func(v quickbooks.Vendor) string { return v.Id }).ReadOnly(),
+		"displayName":    app.TitleField("Display Name", func(v quickbooks.Vendor) string { return v.DisplayName }),
+		"syncToken":      app.TextField("Sync Token", func(v quickbooks.Vendor) string { return v.SyncToken }).ReadOnly(),
+		"__syncAction":   app.SyncActionField[quickbooks.Vendor](),
+		"active":         app.BoolField("Active", func(v quickbooks.Vendor) bool { return v.Active }),
+		"title":          app.TextField("Title", func(v quickbooks.Vendor) string { return v.Title }),
+		"givenName":      app.TextField("First Name", func(v quickbooks.Vendor) string { return v.GivenName }),
+		"middleName":     app.TextField("Middle Name", func(v quickbooks.Vendor) string { return v.MiddleName }),
+		"familyName":     app.TextField("Last Name", func(v quickbooks.Vendor) string { return v.FamilyName }),
+		"suffix":         app.TextField("Suffix", func(v quickbooks.Vendor) string { return v.Suffix }),
+		"companyName":    app.TextField("Company Name", func(v quickbooks.Vendor) string { return v.CompanyName }),
+		"primaryEmail":   app.EmailField("Email", func(v quickbooks.Vendor) *quickbooks.EmailAddress { return v.PrimaryEmailAddr }),
+		"salesTermId":    app.ReferenceField("Sales Term ID", func(v quickbooks.Vendor) *quickbooks.ReferenceType { return v.TermRef }),
+		"primaryPhone":   app.PhoneField("Phone", func(v quickbooks.Vendor) *quickbooks.TelephoneNumber { return v.PrimaryPhone }),
+		"alternatePhone": app.PhoneField("Alternate Phone", func(v quickbooks.Vendor) *quickbooks.TelephoneNumber { return v.AlternatePhone }),
+		"mobile":         app.PhoneField("Mobile", func(v quickbooks.Vendor) *quickbooks.TelephoneNumber { return v.Mobile }),
+		"fax":            app.PhoneField("Fax", func(v quickbooks.Vendor) *quickbooks.TelephoneNumber { return v.Fax }),
+		"1099":           app.BoolField("1099", func(v quickbooks.Vendor) bool { return v.Vendor1099 }).Describe("Is the Vendor a 1099 contractor?"),
+		"taxIdentifier":  app.TextField("Tax ID", func(v quickbooks.Vendor) string { return maskTaxIdentifier(v.TaxIdentifier) }).ReadOnly().Describe("Tax identifier of the Vendor, masked to its last four characters"),
+		"costRate":       app.MoneyField("Cost Rate", func(v quickbooks.Vendor) json.Number { return v.CostRate }).Describe("Default cost rate of the Vendor"),
+		"billRate":       app.MoneyField("Bill Rate", func(v quickbooks.Vendor) json.Number { return v.BillRate }).Describe("Default billing rate of the Vendor"),
+		"website":        app.WebsiteField("Website", func(v quickbooks.Vendor) *quickbooks.WebSiteAddress { return v.WebAddr }),
+		"accountNumber":  app.TextField("Account Number", func(v quickbooks.Vendor) string { return v.AcctNum }).Describe("Name or number of the account associated with this vendor"),
+		"balance":        app.MoneyField("Balance", func(v quickbooks.Vendor) json.Number { return v.Balance }),
+		"currency":       app.ReferenceField("Currency", func(v quickbooks.Vendor) *quickbooks.ReferenceType { return &v.CurrencyRef }).Describe("ISO code of the currency the vendor is billed in"),
 	},
 	nil,
 )
 
+// maskTaxIdentifier keeps only the last four characters of a tax identifier. QuickBooks masks
+// identifiers itself, masking again keeps a full identifier out of Fibery should one be returned.
+func maskTaxIdentifier(id string) string {
+	if len(id) <= 4 {
+		return strings.Repeat("X", len(id))
+	}
+	return strings.Repeat("X", len(id)-4) + id[len(id)-4:]
+}
+
 func init() {
 	app.Types.Register(vendor)
+	app.FeatureFields.Require(app.MultiCurrency, "vendor", "currency")
+	app.ForeignAmounts.Register("vendor", "balance")
 }
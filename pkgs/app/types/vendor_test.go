@@ -0,0 +1,19 @@
+package types
+
+import "testing"
+
+func TestMaskTaxIdentifier(t *testing.T) {
+	for _, tc := range []struct {
+		id, want string
+	}{
+		{"", ""},
+		{"123", "XXX"},
+		{"1234", "XXXX"},
+		{"12345", "X2345"},
+		{"123-45-6789", "XXXXXXX6789"},
+	} {
+		if got := maskTaxIdentifier(tc.id); got != tc.want {
+			t.Errorf("maskTaxIdentifier(%q) = %q; want %q", tc.id, got, tc.want)
+		}
+	}
+}
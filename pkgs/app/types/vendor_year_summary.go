@@ -0,0 +1,175 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/fibery"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+// yearToDatePageSize is the largest page QuickBooks returns for a query.
+const yearToDatePageSize = 1000
+
+// yearToDateColumns are the only columns totalVendorYears reads, so a year of transactions is
+// queried without their lines.
+var yearToDateColumns = map[string]string{
+	"BillPayment": "Id, VendorRef, TotalAmt, ExchangeRate, PayType",
+	"Purchase":    "Id, EntityRef, TotalAmt, ExchangeRate, PaymentType, Credit",
+}
+
+// vendorYear totals what was paid to a vendor in a calendar year, in the home currency.
+type vendorYear struct {
+	VendorId     string
+	VendorName   string
+	Year         int
+	BillPayments float64
+	Purchases    float64
+	CardPayments float64
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// queryYearToDate reads every bill payment and purchase dated on or after since. Each batch
+// holds the next page of both entities until one runs out. QuickBooks only shows that a query
+// is done by returning a short page, so the pages of one entity are read one after another.
+func queryYearToDate(batcher *app.Batcher, params quickbooks.RequestParameters, since string) ([]quickbooks.BillPayment, []quickbooks.Purchase, error) {
+	var (
+		payments  []quickbooks.BillPayment
+		purchases []quickbooks.Purchase
+	)
+	pages := map[string]int{"BillPayment": 1, "Purchase": 1}
+	for len(pages) > 0 {
+		req := make([]quickbooks.BatchItemRequest, 0, len(pages))
+		for entity, page := range pages {
+			req = append(req, quickbooks.BatchItemRequest{
+				BID:   app.EncodeQueryBID(entity, page, false),
+				Query: fmt.Sprintf("Select %s From %s Where TxnDate >= '%s' ORDERBY Id STARTPOSITION %d MAXRESULTS %d", yearToDateColumns[entity], entity, since, (page-1)*yearToDatePageSize+1, yearToDatePageSize),
+			})
+		}
+		resp, err := batcher.Do(params, req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error querying year-to-date payments: %w", err)
+		}
+
+		for _, r := range resp {
+			entity, page, _, err := app.DecodeQueryBID(r.BID)
+			if err != nil {
+				return nil, nil, err
+			}
+			if faults := r.Fault.Faults; len(faults) > 0 {
+				return nil, nil, fmt.Errorf("fault querying %s: %w", entity, quickbooks.BatchError{Faults: faults})
+			}
+
+			var count int
+			switch entity {
+			case "BillPayment":
+				payments = append(payments, r.QueryResponse.BillPayment...)
+				count = len(r.QueryResponse.BillPayment)
+			case "Purchase":
+				purchases = append(purchases, r.QueryResponse.Purchase...)
+				count = len(r.QueryResponse.Purchase)
+			}
+			if count < yearToDatePageSize {
+				delete(pages, entity)
+			} else {
+				pages[entity] = page + 1
+			}
+		}
+	}
+	return payments, purchases, nil
+}
+
+// fetchVendorYears totals the bill payments and vendor purchases of the current calendar year
+// per vendor. Purchase credits, such as card refunds, reduce the total. QuickBooks does not
+// expose the realm's timezone, so the year turns over at midnight UTC.
+func fetchVendorYears(c *app.Client, params quickbooks.RequestParameters, _ map[string]any) ([]vendorYear, error) {
+	year := time.Now().UTC().Year()
+	payments, purchases, err := queryYearToDate(app.NewBatcher(c, app.MaxBatchItems, 1), params, fmt.Sprintf("%d-01-01", year))
+	if err != nil {
+		return nil, err
+	}
+	return totalVendorYears(year, payments, purchases)
+}
+
+// totalVendorYears adds up payments and purchases per vendor, in the home currency.
+func totalVendorYears(year int, payments []quickbooks.BillPayment, purchases []quickbooks.Purchase) ([]vendorYear, error) {
+	years := make(map[string]*vendorYear)
+	add := func(ref quickbooks.ReferenceType, amount float64, card bool, total func(*vendorYear) *float64) {
+		y, ok := years[ref.Value]
+		if !ok {
+			y = &vendorYear{VendorId: ref.Value, VendorName: ref.Name, Year: year}
+			years[ref.Value] = y
+		}
+		*total(y) += amount
+		if card {
+			y.CardPayments += amount
+		}
+	}
+
+	for _, p := range payments {
+		amount, err := app.HomeAmount(p.TotalAmt, p.ExchangeRate)
+		if err != nil {
+			return nil, fmt.Errorf("error totaling bill payment %s: %w", p.Id, err)
+		}
+		add(p.VendorRef, amount, p.PayType == "CreditCard", func(y *vendorYear) *float64 { return &y.BillPayments })
+	}
+
+	for _, p := range purchases {
+		if p.EntityRef == nil || p.EntityRef.Type != "Vendor" {
+			continue
+		}
+		amount, err := app.HomeAmount(p.TotalAmt, p.ExchangeRate)
+		if err != nil {
+			return nil, fmt.Errorf("error totaling purchase %s: %w", p.Id, err)
+		}
+		if p.Credit {
+			amount = -amount
+		}
+		add(*p.EntityRef, amount, p.PaymentType == "CreditCard", func(y *vendorYear) *float64 { return &y.Purchases })
+	}
+
+	out := make([]vendorYear, 0, len(years))
+	for _, y := range years {
+		out = append(out, *y)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].VendorId < out[j].VendorId })
+	return out, nil
+}
+
+// Vendor year summaries are recomputed from the year's payments on every sync, so they are
+// always synced in full.
+var vendorYearSummary = app.NewRealmListType(
+	"vendorYearSummary",
+	"Vendor Year Summary",
+	func(y vendorYear) string {
+		return y.VendorId + ":" + strconv.Itoa(y.Year)
+	},
+	fetchVendorYears,
+	map[string]app.FieldDef[vendorYear]{
+		"name": app.TitleField("Name", func(y vendorYear) string {
+			return fmt.Sprintf("%s %d", y.VendorName, y.Year)
+		}),
+		"__syncAction": app.SyncActionField[vendorYear](),
+		"year":         app.IntegerField("Year", func(y vendorYear) int { return y.Year }),
+		"billPayments": app.MoneyField("Bill Payments", func(y vendorYear) float64 { return roundCents(y.BillPayments) }),
+		"purchases":    app.MoneyField("Purchases", func(y vendorYear) float64 { return roundCents(y.Purchases) }),
+		"totalPaid":    app.MoneyField("Paid Year-to-date", func(y vendorYear) float64 { return roundCents(y.BillPayments + y.Purchases) }),
+		"paid1099": app.MoneyField("1099 Payments", func(y vendorYear) float64 {
+			return roundCents(y.BillPayments + y.Purchases - y.CardPayments)
+		}).Describe("Payments not made by credit card, which are reported by the card issuer instead"),
+		"vendorId": app.ReferenceField("Vendor Id", func(y vendorYear) *quickbooks.ReferenceType {
+			return &quickbooks.ReferenceType{Value: y.VendorId}
+		}).Relation(fibery.MTO, "Vendor", "Year Summaries", "vendor"),
+	},
+)
+
+func init() {
+	app.Types.Register(vendorYearSummary)
+}
@@ -0,0 +1,151 @@
+package types
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tommyhedley/fibery-quickbooks-app/pkgs/app"
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+func TestTotalVendorYears(t *testing.T) {
+	vendor := func(id string) *quickbooks.ReferenceType {
+		return &quickbooks.ReferenceType{Value: id, Name: "Vendor " + id, Type: "Vendor"}
+	}
+
+	for name, tc := range map[string]struct {
+		payments  []quickbooks.BillPayment
+		purchases []quickbooks.Purchase
+		want      map[string]vendorYear
+	}{
+		"bill payments and card payments": {
+			payments: []quickbooks.BillPayment{
+				{Id: "1", VendorRef: *vendor("3"), TotalAmt: "100", PayType: "Check"},
+				{Id: "2", VendorRef: *vendor("3"), TotalAmt: "40", PayType: "CreditCard"},
+			},
+			want: map[string]vendorYear{"3": {BillPayments: 140, CardPayments: 40}},
+		},
+		"credit purchase reduces the total": {
+			purchases: []quickbooks.Purchase{
+				{Id: "1", EntityRef: vendor("3"), TotalAmt: "75.50", PaymentType: "Cash"},
+				{Id: "2", EntityRef: vendor("3"), TotalAmt: "20.25", PaymentType: "Cash", Credit: true},
+			},
+			want: map[string]vendorYear{"3": {Purchases: 55.25}},
+		},
+		"card refund reduces card payments": {
+			purchases: []quickbooks.Purchase{
+				{Id: "1", EntityRef: vendor("3"), TotalAmt: "60", PaymentType: "CreditCard"},
+				{Id: "2", EntityRef: vendor("3"), TotalAmt: "10", PaymentType: "CreditCard", Credit: true},
+			},
+			want: map[string]vendorYear{"3": {Purchases: 50, CardPayments: 50}},
+		},
+		"credit amount is negated numerically": {
+			purchases: []quickbooks.Purchase{
+				{Id: "1", EntityRef: vendor("3"), TotalAmt: "-5", PaymentType: "Cash", Credit: true},
+			},
+			want: map[string]vendorYear{"3": {Purchases: 5}},
+		},
+		"foreign currency in home currency": {
+			purchases: []quickbooks.Purchase{
+				{Id: "1", EntityRef: vendor("3"), TotalAmt: "100", ExchangeRate: "1.25", PaymentType: "Cash"},
+				{Id: "2", EntityRef: vendor("3"), TotalAmt: "10", ExchangeRate: "1.25", PaymentType: "Cash", Credit: true},
+			},
+			want: map[string]vendorYear{"3": {Purchases: 112.5}},
+		},
+		"purchases from other entities are skipped": {
+			purchases: []quickbooks.Purchase{
+				{Id: "1", EntityRef: &quickbooks.ReferenceType{Value: "9", Type: "Customer"}, TotalAmt: "30"},
+				{Id: "2", TotalAmt: "30"},
+			},
+			want: map[string]vendorYear{},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			years, err := totalVendorYears(2024, tc.payments, tc.purchases)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(years) != len(tc.want) {
+				t.Fatalf("expected %d vendors, got %+v", len(tc.want), years)
+			}
+			for _, got := range years {
+				want, ok := tc.want[got.VendorId]
+				if !ok {
+					t.Fatalf("unexpected vendor %s", got.VendorId)
+				}
+				if got.Year != 2024 || got.VendorName != "Vendor "+got.VendorId ||
+					roundCents(got.BillPayments) != want.BillPayments ||
+					roundCents(got.Purchases) != want.Purchases ||
+					roundCents(got.CardPayments) != want.CardPayments {
+					t.Errorf("expected %+v, got %+v", want, got)
+				}
+			}
+		})
+	}
+
+	if _, err := totalVendorYears(2024, []quickbooks.BillPayment{{Id: "1", TotalAmt: "abc"}}, nil); err == nil {
+		t.Error("expected an invalid amount to fail")
+	}
+}
+
+// pagedQueries answers year-to-date queries with full pages until an entity's page limit.
+type pagedQueries struct {
+	mu      sync.Mutex
+	pages   map[string]int
+	batches [][]string
+}
+
+func (q *pagedQueries) BatchRequest(params quickbooks.RequestParameters, req []quickbooks.BatchItemRequest) ([]quickbooks.BatchItemResponse, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	bids := make([]string, 0, len(req))
+	resp := make([]quickbooks.BatchItemResponse, 0, len(req))
+	for _, item := range req {
+		bids = append(bids, item.BID)
+		entity, page, _, err := app.DecodeQueryBID(item.BID)
+		if err != nil {
+			return nil, err
+		}
+		count := 1
+		if page < q.pages[entity] {
+			count = yearToDatePageSize
+		}
+		r := quickbooks.BatchItemResponse{BID: item.BID}
+		switch {
+		case strings.Contains(item.Query, "From BillPayment"):
+			r.QueryResponse.BillPayment = make([]quickbooks.BillPayment, count)
+		case strings.Contains(item.Query, "From Purchase"):
+			r.QueryResponse.Purchase = make([]quickbooks.Purchase, count)
+		}
+		resp = append(resp, r)
+	}
+	q.batches = append(q.batches, bids)
+	return resp, nil
+}
+
+func TestQueryYearToDate(t *testing.T) {
+	q := &pagedQueries{pages: map[string]int{"BillPayment": 1, "Purchase": 3}}
+	payments, purchases, err := queryYearToDate(app.NewBatcher(q, app.MaxBatchItems, 1), quickbooks.RequestParameters{}, "2024-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payments) != 1 || len(purchases) != 2*yearToDatePageSize+1 {
+		t.Errorf("expected 1 payment and %d purchases, got %d and %d", 2*yearToDatePageSize+1, len(payments), len(purchases))
+	}
+
+	want := [][]string{{"BillPayment:1", "Purchase:1"}, {"Purchase:2"}, {"Purchase:3"}}
+	if len(q.batches) != len(want) {
+		t.Fatalf("expected batches %v, got %v", want, q.batches)
+	}
+	for n, batch := range q.batches {
+		got := append([]string(nil), batch...)
+		if len(got) == 2 && got[0] > got[1] {
+			got[0], got[1] = got[1], got[0]
+		}
+		if strings.Join(got, ",") != strings.Join(want[n], ",") {
+			t.Errorf("batch %d: expected %v, got %v", n, want[n], batch)
+		}
+	}
+}
@@ -58,7 +58,8 @@ func HandleRequestError(w http.ResponseWriter, code int, errMsg string, err erro
 	}
 
 	var rateLimitError *quickbooks.RateLimitError
-	if errors.As(err, &rateLimitError) {
+	var rateLimitWaitError *RateLimitWaitError
+	if errors.As(err, &rateLimitError) || errors.As(err, &rateLimitWaitError) {
 		RespondWithRateLimit(w, http.StatusTooManyRequests, responseError)
 	} else {
 		RespondWithError(w, code, responseError)
@@ -47,7 +47,7 @@ type WebhookRequest struct {
 
 type WebhookUpdatedSource struct {
 	ids           []string
-	batchData     *quickbooks.BatchItemResponse
+	batchPages    map[int]*quickbooks.BatchItemResponse
 	getAttachable bool
 	attachables   map[string][]quickbooks.Attachable
 }
@@ -161,12 +161,16 @@ func buildWebhookGroup(req WebhookRequest, i *Integration, cdcLookback time.Dura
 			continue
 		}
 		for _, entity := range event.DataChangeEvent.Entities {
-			if getAttach, exists := allSources[entity.Name]; exists {
+			getAttach, exists := allSources[entity.Name]
+			i.metrics.WebhookEvent(entity.Name, entity.Operation, exists)
+			if exists {
 				switch entity.Operation {
 				case "Create", "Update", "Emailed", "Void":
 					updateSource, ok := group.updatedSources[entity.Name]
 					if !ok {
-						updateSource = &WebhookUpdatedSource{}
+						updateSource = &WebhookUpdatedSource{
+							batchPages: make(map[int]*quickbooks.BatchItemResponse),
+						}
 					}
 
 					updateSource.ids = append(updateSource.ids, entity.Id)
@@ -203,77 +207,107 @@ func buildWebhookGroup(req WebhookRequest, i *Integration, cdcLookback time.Dura
 	return group, nil
 }
 
-func (wg *WebhookGroup) indexBatch(batch []quickbooks.BatchItemResponse) error {
+func (wg *WebhookGroup) attachableIds(entityType string) []string {
+	for _, rlType := range wg.relatedTypes {
+		if rlType.getAttachable && rlType.typ.Type() == entityType {
+			return nil
+		}
+	}
+
+	if source, ok := wg.updatedSources[entityType]; ok {
+		return source.ids
+	}
+
+	return nil
+}
+
+func (wg *WebhookGroup) indexBatch(batch []quickbooks.BatchItemResponse) (map[string]struct{}, error) {
 	wg.Lock()
 	defer wg.Unlock()
+
+	moreAttachables := map[string]struct{}{}
+	pageSize := wg.integration.config.QuickBooks.PageSize
+
 	for _, resp := range batch {
 		faults := resp.Fault.Faults
 		if len(faults) > 0 {
-			return fmt.Errorf("fault for %s: %w", resp.BID, quickbooks.BatchError{Faults: faults})
+			return nil, fmt.Errorf("fault for %s: %w", resp.BID, quickbooks.BatchError{Faults: faults})
 		}
 
-		entityType, _, isAttachable, err := DecodeQueryBID(resp.BID)
+		entityType, page, isAttachable, err := DecodeQueryBID(resp.BID)
 		if err != nil {
-			return fmt.Errorf("error decoding query BID: %w", err)
+			return nil, fmt.Errorf("error decoding query BID: %w", err)
 		}
 
-		relatedType, exists := wg.relatedTypes[entityType]
-
-		if exists && isAttachable {
+		if isAttachable {
 			attachables := resp.QueryResponse.Attachable
-			if len(attachables) > 0 {
-				existing := relatedType.attachables
-				updated, more := indexAttachables(
-					entityType, attachables, existing, wg.integration.config.QuickBooks.PageSize,
-				)
-				relatedType.attachables = updated
-				if more {
-					return fmt.Errorf("more than %d attachables returned for: %s", wg.integration.config.QuickBooks.PageSize, entityType)
+			indexed := false
+
+			for _, relatedType := range wg.relatedTypes {
+				if !relatedType.getAttachable || relatedType.typ.Type() != entityType {
+					continue
 				}
+				relatedType.attachables, _ = indexAttachables(
+					entityType, attachables, relatedType.attachables, pageSize,
+				)
+				indexed = true
+			}
 
+			if updatedSource, exists := wg.updatedSources[entityType]; exists && updatedSource.getAttachable {
+				updatedSource.attachables, _ = indexAttachables(
+					entityType, attachables, updatedSource.attachables, pageSize,
+				)
+				indexed = true
+			}
+
+			if !indexed {
+				return nil, fmt.Errorf("no updatedSources or relatedTypes found for attachables of: %s", entityType)
+			}
+
+			if len(attachables) == pageSize {
+				moreAttachables[entityType] = struct{}{}
 			}
 			continue
 		}
 
 		updatedSource, exists := wg.updatedSources[entityType]
 		if !exists {
-			return fmt.Errorf("no updatedSources found for: %s", entityType)
+			return nil, fmt.Errorf("no updatedSources found for: %s", entityType)
 		}
 
-		if isAttachable {
-			attachables := resp.QueryResponse.Attachable
-			if len(attachables) > 0 {
-				existing := updatedSource.attachables
-				updated, more := indexAttachables(
-					entityType, attachables, existing, wg.integration.config.QuickBooks.PageSize,
-				)
-				updatedSource.attachables = updated
-				if more {
-					return fmt.Errorf("more than %d attachables returned for: %s", wg.integration.config.QuickBooks.PageSize, entityType)
-				}
-			}
-		} else {
-			if updatedSource.batchData != nil {
-				return fmt.Errorf("a batch response entry already exists for %s", entityType)
-			}
-
-			updatedSource.batchData = &resp
+		if _, exists := updatedSource.batchPages[page]; exists {
+			return nil, fmt.Errorf("a batch response entry already exists for %s:%d", entityType, page)
 		}
+
+		updatedSource.batchPages[page] = &resp
 	}
 
-	return nil
+	return moreAttachables, nil
 }
 
 func (wg *WebhookGroup) doBatch(req []quickbooks.BatchItemRequest, params quickbooks.RequestParameters) error {
-	client := wg.integration.client
-	batch, err := client.BatchRequest(params, req)
-	if err != nil {
-		return fmt.Errorf("error fetching inital batch: %w", err)
-	}
+	batcher := wg.integration.batcher
+	pageSize := wg.integration.config.QuickBooks.PageSize
 
-	err = wg.indexBatch(batch)
-	if err != nil {
-		return fmt.Errorf("error indexing webhook batch: %w", err)
+	attachablePage := 1
+
+	for len(req) > 0 {
+		batch, err := batcher.Do(params, req)
+		if err != nil {
+			return fmt.Errorf("error fetching webhook batch: %w", err)
+		}
+
+		nextAttachEntities, err := wg.indexBatch(batch)
+		if err != nil {
+			return fmt.Errorf("error indexing webhook batch: %w", err)
+		}
+
+		attachablePage++
+		nextReq := make([]quickbooks.BatchItemRequest, 0, len(nextAttachEntities))
+		for entityType := range nextAttachEntities {
+			nextReq = append(nextReq, batchQueryRequest(entityType, wg.attachableIds(entityType), attachablePage, pageSize, true))
+		}
+		req = nextReq
 	}
 
 	return nil
@@ -296,6 +330,15 @@ func (wg *WebhookGroup) doCDC(req []string, params quickbooks.RequestParameters)
 	return nil
 }
 
+func (wg *WebhookGroup) requestParameters(ctx context.Context) quickbooks.RequestParameters {
+	return quickbooks.RequestParameters{
+		Ctx:             ctx,
+		RealmId:         wg.account.RealmId,
+		Token:           &wg.account.BearerToken,
+		WaitOnRateLimit: true,
+	}
+}
+
 func (wg *WebhookGroup) fetchAll(ctx context.Context) error {
 	var (
 		fetch sync.WaitGroup
@@ -307,10 +350,11 @@ func (wg *WebhookGroup) fetchAll(ctx context.Context) error {
 	pageSize := wg.integration.config.QuickBooks.PageSize
 
 	batchReq := make([]quickbooks.BatchItemRequest, 0, len(wg.updatedSources))
+	attachableSources := make(map[string]struct{})
 
 	for sourceType, source := range wg.updatedSources {
 		if source.getAttachable {
-			batchReq = append(batchReq, batchQueryRequest(sourceType, source.ids, page, pageSize, true))
+			attachableSources[sourceType] = struct{}{}
 		}
 		batchReq = append(batchReq, batchQueryRequest(sourceType, source.ids, page, pageSize, false))
 	}
@@ -319,21 +363,20 @@ func (wg *WebhookGroup) fetchAll(ctx context.Context) error {
 
 	for _, rlType := range wg.relatedTypes {
 		if rlType.getAttachable {
-			batchReq = append(batchReq, batchQueryRequest(rlType.typ.Type(), nil, page, pageSize, true))
+			attachableSources[rlType.typ.Type()] = struct{}{}
 		}
 		cdcReq = append(cdcReq, rlType.typ.Type())
 	}
 
+	for sourceType := range attachableSources {
+		batchReq = append(batchReq, batchQueryRequest(sourceType, wg.attachableIds(sourceType), page, pageSize, true))
+	}
+
 	record := func(err error) {
 		once.Do(func() { first = err })
 	}
 
-	params := quickbooks.RequestParameters{
-		Ctx:             ctx,
-		RealmId:         wg.account.RealmId,
-		Token:           &wg.account.BearerToken,
-		WaitOnRateLimit: true,
-	}
+	params := wg.requestParameters(ctx)
 
 	if len(cdcReq) > 0 {
 		fmt.Println(cdcReq)
@@ -362,142 +405,205 @@ func (wg *WebhookGroup) fetchAll(ctx context.Context) error {
 	return first
 }
 
-func (wg *WebhookGroup) process() (map[string][]map[string]any, error) {
-	output := map[string][]map[string]any{}
+func appendWebhookOutput(output map[string][]map[string]any, typeId string, items ...[]map[string]any) {
+	length := 0
+	for _, i := range items {
+		length += len(i)
+	}
+
+	if length == 0 {
+		return
+	}
+
+	typeOutput, ok := output[typeId]
+	if !ok {
+		typeOutput = make([]map[string]any, 0, length)
+	}
+
+	for _, i := range items {
+		typeOutput = append(typeOutput, i...)
+	}
+
+	output[typeId] = typeOutput
+}
+
+func (wg *WebhookGroup) processPage(page int, output map[string][]map[string]any) (map[string]struct{}, error) {
+	moreSources := make(map[string]struct{})
 	pageSize := wg.integration.config.QuickBooks.PageSize
+
 	for typeId, regType := range wg.webhookTypes {
 		switch t := regType.(type) {
 		case UnionType:
 			batchResponses := make(map[string]*quickbooks.BatchItemResponse)
-			sourceDeletions := make(map[string][]string)
 			for _, sourceType := range t.Types() {
 				source, ok := wg.updatedSources[sourceType.Type()]
 				if !ok {
 					continue
 				}
 
-				batchResponses[sourceType.Type()] = source.batchData
-
-				deleteIds, ok := wg.deletedSources[sourceType.Type()]
+				batchData, ok := source.batchPages[page]
 				if !ok {
 					continue
 				}
 
-				sourceDeletions[sourceType.Type()] = deleteIds
-			}
-
-			updateItems, moreSource, err := t.ProcessBatchQuery(batchResponses, pageSize)
-			if err != nil {
-				return nil, fmt.Errorf("error processing batch query: %w", err)
+				batchResponses[sourceType.Type()] = batchData
 			}
 
-			if len(moreSource) > 0 {
-				return nil, fmt.Errorf("%s batch response returned more than 1 page", typeId)
+			if len(batchResponses) == 0 {
+				continue
 			}
 
-			deleteItems, err := t.ProcessWebhookDeletions(sourceDeletions)
+			updateItems, moreSource, err := t.ProcessWebhookUpdates(batchResponses, pageSize)
 			if err != nil {
-				return nil, fmt.Errorf("error processing deletedItems: %w", err)
+				return nil, fmt.Errorf("error processing batch query: %w", err)
 			}
 
-			responseLength := len(updateItems) + len(deleteItems)
-
-			if responseLength > 0 {
-
-				typeOutout, ok := output[typeId]
-				if !ok {
-					typeOutout = make([]map[string]any, 0, responseLength)
-				}
-
-				typeOutout = append(typeOutout, updateItems...)
-				typeOutout = append(typeOutout, deleteItems...)
-
-				output[typeId] = typeOutout
+			for sourceType := range moreSource {
+				moreSources[sourceType] = struct{}{}
 			}
+
+			appendWebhookOutput(output, typeId, updateItems)
 		case WebhookDependentType:
 			source, ok := wg.updatedSources[t.SourceType()]
 			if !ok {
 				continue
 			}
 
-			updateItems, more, err := t.ProcessBatchQuery(source.batchData, wg.idCache, pageSize)
+			batchData, ok := source.batchPages[page]
+			if !ok {
+				continue
+			}
+
+			updateItems, more, err := t.ProcessBatchQuery(batchData, wg.idCache, pageSize)
 			if err != nil {
 				return nil, fmt.Errorf("error processing batch query: %w", err)
 			}
 
 			if more {
-				return nil, fmt.Errorf("%s batch response returned more than 1 page", typeId)
+				moreSources[t.SourceType()] = struct{}{}
 			}
 
-			deleteIds, ok := wg.deletedSources[t.SourceType()]
+			appendWebhookOutput(output, typeId, updateItems)
+		case WebhookType:
+			source, ok := wg.updatedSources[t.Type()]
 			if !ok {
-				deleteIds = make([]string, 0)
+				continue
 			}
 
-			deleteItems, err := t.ProcessWebhookDeletions(deleteIds, wg.idCache)
+			batchData, ok := source.batchPages[page]
+			if !ok {
+				continue
+			}
+
+			updateItems, more, err := t.ProcessBatchQuery(batchData, source.attachables, pageSize)
 			if err != nil {
-				return nil, fmt.Errorf("error processing deletedItems: %w", err)
+				return nil, fmt.Errorf("error processing batch query: %w", err)
 			}
 
-			typeOutout, ok := output[typeId]
-			if !ok {
-				typeOutout = make([]map[string]any, 0, len(updateItems)+len(deleteItems))
+			if more {
+				moreSources[t.Type()] = struct{}{}
 			}
 
-			typeOutout = append(typeOutout, updateItems...)
-			typeOutout = append(typeOutout, deleteItems...)
+			appendWebhookOutput(output, typeId, updateItems)
+		}
+	}
 
-			output[typeId] = typeOutout
-		case WebhookType:
-			source, ok := wg.updatedSources[t.Type()]
-			if !ok {
-				continue
+	return moreSources, nil
+}
+
+func (wg *WebhookGroup) processDeletions(output map[string][]map[string]any) error {
+	for typeId, regType := range wg.webhookTypes {
+		switch t := regType.(type) {
+		case UnionType:
+			sourceDeletions := make(map[string][]string)
+			for _, sourceType := range t.Types() {
+				deleteIds, ok := wg.deletedSources[sourceType.Type()]
+				if !ok {
+					continue
+				}
+
+				sourceDeletions[sourceType.Type()] = deleteIds
 			}
 
-			updateItems, more, err := t.ProcessBatchQuery(source.batchData, source.attachables, pageSize)
+			deleteItems, err := t.ProcessWebhookDeletions(sourceDeletions)
 			if err != nil {
-				return nil, fmt.Errorf("error processing batch query: %w", err)
+				return fmt.Errorf("error processing deletedItems: %w", err)
 			}
 
-			if more {
-				return nil, fmt.Errorf("%s batch response returned more than 1 page", typeId)
+			appendWebhookOutput(output, typeId, deleteItems)
+		case WebhookDependentType:
+			deleteIds, ok := wg.deletedSources[t.SourceType()]
+			if !ok {
+				continue
+			}
+
+			deleteItems, err := t.ProcessWebhookDeletions(deleteIds, wg.idCache)
+			if err != nil {
+				return fmt.Errorf("error processing deletedItems: %w", err)
 			}
 
+			appendWebhookOutput(output, typeId, deleteItems)
+		case WebhookType:
 			deleteIds, ok := wg.deletedSources[t.Type()]
 			if !ok {
-				deleteIds = make([]string, 0)
+				continue
 			}
 
 			deleteItems, err := t.ProcessWebhookDeletions(deleteIds)
 			if err != nil {
-				return nil, fmt.Errorf("error processing deletedItems: %w", err)
+				return fmt.Errorf("error processing deletedItems: %w", err)
 			}
 
-			typeOutout, ok := output[typeId]
+			appendWebhookOutput(output, typeId, deleteItems)
+		}
+	}
+
+	return nil
+}
+
+func (wg *WebhookGroup) process(ctx context.Context) (map[string][]map[string]any, error) {
+	output := map[string][]map[string]any{}
+	pageSize := wg.integration.config.QuickBooks.PageSize
+	params := wg.requestParameters(ctx)
+
+	page := 1
+
+	for {
+		moreSources, err := wg.processPage(page, output)
+		if err != nil {
+			return nil, fmt.Errorf("error processing page %d: %w", page, err)
+		}
+
+		if len(moreSources) == 0 {
+			break
+		}
+
+		page++
+		batchReq := make([]quickbooks.BatchItemRequest, 0, len(moreSources))
+		for sourceType := range moreSources {
+			source, ok := wg.updatedSources[sourceType]
 			if !ok {
-				typeOutout = make([]map[string]any, 0, len(updateItems)+len(deleteItems))
+				return nil, fmt.Errorf("no updatedSources found for: %s", sourceType)
 			}
+			batchReq = append(batchReq, batchQueryRequest(sourceType, source.ids, page, pageSize, false))
+		}
 
-			typeOutout = append(typeOutout, updateItems...)
-			typeOutout = append(typeOutout, deleteItems...)
-
-			output[typeId] = typeOutout
+		if err := wg.doBatch(batchReq, params); err != nil {
+			return nil, fmt.Errorf("error fetching page %d: %w", page, err)
 		}
 	}
 
+	if err := wg.processDeletions(output); err != nil {
+		return nil, err
+	}
+
 	for typeId, rlType := range wg.relatedTypes {
 		items, err := rlType.typ.ProcessCDCQuery(wg.changeDataCapture, rlType.attachables, pageSize)
 		if err != nil {
 			return nil, fmt.Errorf("error processing changeDataCapture for %s", typeId)
 		}
 
-		typeOutout, ok := output[typeId]
-		if !ok {
-			typeOutout = make([]map[string]any, 0, len(items))
-		}
-
-		typeOutout = append(typeOutout, items...)
-		output[typeId] = typeOutout
+		appendWebhookOutput(output, typeId, items)
 	}
 
 	return output, nil
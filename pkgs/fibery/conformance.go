@@ -0,0 +1,431 @@
+package fibery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Conformance drives an integration the way Fibery does and checks every response against the
+// schemas the integration reports. It is meant to be run from tests.
+type Conformance struct {
+	Integration IntegrationCore
+	// Account is sent as the auth fields to /validate, the validated account is used from then on.
+	Account map[string]any
+	// Types limits the sync to a subset of the configured types, all types are synced when empty.
+	Types  []string
+	Filter map[string]any
+	// BeforeDelta runs between the full and delta passes so callers can change upstream data.
+	BeforeDelta func(ctx context.Context) error
+	// WebhookPayload is posted to the transform endpoint after the delta pass when set.
+	WebhookPayload any
+	// MaxPages stops a type that never reports the last page, defaults to 100.
+	MaxPages int
+}
+
+type Violation struct {
+	Step    string
+	Type    string
+	ItemId  string
+	Field   string
+	Message string
+}
+
+func (v Violation) String() string {
+	out := v.Step
+	if v.Type != "" {
+		out += " " + v.Type
+	}
+	if v.ItemId != "" {
+		out += " item " + v.ItemId
+	}
+	if v.Field != "" {
+		out += " field " + v.Field
+	}
+	return out + ": " + v.Message
+}
+
+type ConformanceReport struct {
+	sync.Mutex
+	AppConfig  AppConfig
+	SyncConfig SyncConfig
+	Schema     map[string]map[string]Field
+	// Full, Delta and Webhook count the items returned per type in each pass.
+	Full       map[string]int
+	Delta      map[string]int
+	Webhook    map[string]int
+	Violations []Violation
+}
+
+func (r *ConformanceReport) add(v Violation) {
+	r.Lock()
+	defer r.Unlock()
+	r.Violations = append(r.Violations, v)
+}
+
+func (r *ConformanceReport) addf(step, typeId, itemId, field, format string, args ...any) {
+	r.add(Violation{Step: step, Type: typeId, ItemId: itemId, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err joins all violations into a single error, nil when the integration conforms.
+func (r *ConformanceReport) Err() error {
+	errs := make([]error, 0, len(r.Violations))
+	for _, v := range r.Violations {
+		errs = append(errs, errors.New(v.String()))
+	}
+	return errors.Join(errs...)
+}
+
+const (
+	stepAppConfig  = "appConfig"
+	stepValidate   = "validate"
+	stepSyncConfig = "syncConfig"
+	stepSchema     = "schema"
+	stepFull       = "fullSync"
+	stepDelta      = "deltaSync"
+	stepWebhook    = "webhookTransform"
+)
+
+// Run performs config, validate, sync config and schema requests, a full sync of every type with a
+// shared operationId, a delta sync and, when a payload is set, a webhook transform. Violations are
+// collected in the report; Run only returns early when a step leaves nothing to continue with.
+func (c *Conformance) Run(ctx context.Context) *ConformanceReport {
+	report := &ConformanceReport{
+		Full:    map[string]int{},
+		Delta:   map[string]int{},
+		Webhook: map[string]int{},
+	}
+
+	mux := http.NewServeMux()
+	RegisterFiberyRoutes(mux, c.Integration)
+
+	if err := c.call(ctx, mux, http.MethodGet, "/", nil, &report.AppConfig); err != nil {
+		report.addf(stepAppConfig, "", "", "", "%v", err)
+		return report
+	}
+	c.checkAppConfig(report)
+
+	account := c.Account
+	if len(report.AppConfig.Authentication) > 0 {
+		validated := map[string]any{}
+		body := map[string]any{"id": report.AppConfig.Authentication[0].Id, "fields": c.Account}
+		if err := c.call(ctx, mux, http.MethodPost, "/validate", body, &validated); err != nil {
+			report.addf(stepValidate, "", "", "", "%v", err)
+			return report
+		}
+		if name, _ := validated["name"].(string); name == "" {
+			report.addf(stepValidate, "", "", "name", "validated account has no name")
+		}
+		account = validated
+	}
+
+	if err := c.call(ctx, mux, http.MethodPost, "/api/v1/synchronizer/config", map[string]any{"account": account}, &report.SyncConfig); err != nil {
+		report.addf(stepSyncConfig, "", "", "", "%v", err)
+		return report
+	}
+
+	types := c.Types
+	if len(types) == 0 {
+		for _, t := range report.SyncConfig.Types {
+			types = append(types, t.Id)
+		}
+	}
+	if len(types) == 0 {
+		report.addf(stepSyncConfig, "", "", "", "no types configured")
+		return report
+	}
+
+	schemaReq := map[string]any{"types": types, "filter": c.Filter, "account": account}
+	if err := c.call(ctx, mux, http.MethodPost, "/api/v1/synchronizer/schema", schemaReq, &report.Schema); err != nil {
+		report.addf(stepSchema, "", "", "", "%v", err)
+		return report
+	}
+	c.checkSchemas(report, types)
+
+	started := time.Now()
+	c.syncPass(ctx, mux, report, stepFull, account, types, time.Time{}, report.Full)
+
+	if c.BeforeDelta != nil {
+		if err := c.BeforeDelta(ctx); err != nil {
+			report.addf(stepDelta, "", "", "", "before delta: %v", err)
+			return report
+		}
+	}
+	c.syncPass(ctx, mux, report, stepDelta, account, types, started, report.Delta)
+
+	if c.WebhookPayload == nil {
+		return report
+	}
+	if _, ok := c.Integration.(IntegrationWebhooks); !ok {
+		report.addf(stepWebhook, "", "", "", "integration does not implement webhooks")
+		return report
+	}
+
+	transformReq := map[string]any{
+		"params":  map[string]any{},
+		"types":   types,
+		"filter":  c.Filter,
+		"account": account,
+		"payload": c.WebhookPayload,
+	}
+	var transformed WebhookTransformResponse
+	if err := c.call(ctx, mux, http.MethodPost, "/api/v1/synchronizer/webhooks/transform", transformReq, &transformed); err != nil {
+		report.addf(stepWebhook, "", "", "", "%v", err)
+		return report
+	}
+	for typeId, items := range transformed.Data {
+		report.Webhook[typeId] = len(items)
+		c.checkItems(report, stepWebhook, typeId, Delta, items)
+	}
+
+	return report
+}
+
+func (c *Conformance) call(ctx context.Context, handler http.Handler, method, path string, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("unable to encode %s request: %w", path, err)
+		}
+	}
+
+	req := httptest.NewRequestWithContext(ctx, method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		return fmt.Errorf("%s %s returned %d: %s", method, path, rec.Code, bytes.TrimSpace(rec.Body.Bytes()))
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+		return fmt.Errorf("unable to decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+// syncPass pages through every type concurrently since integrations may wait for all types of an
+// operation before fetching.
+func (c *Conformance) syncPass(ctx context.Context, handler http.Handler, report *ConformanceReport, step string, account map[string]any, types []string, lastSynced time.Time, counts map[string]int) {
+	maxPages := c.MaxPages
+	if maxPages == 0 {
+		maxPages = 100
+	}
+	operationId := fmt.Sprintf("conformance-%s-%d", step, time.Now().UnixNano())
+
+	var wg sync.WaitGroup
+	for _, typeId := range types {
+		wg.Add(1)
+		go func(typeId string) {
+			defer wg.Done()
+			seen := map[string]struct{}{}
+			next := NextPageConfig{Page: 1}
+			for range maxPages {
+				req := map[string]any{
+					"requestedType": typeId,
+					"operationId":   operationId,
+					"types":         types,
+					"schema":        report.Schema,
+					"filter":        c.Filter,
+					"account":       account,
+					"pagination":    next,
+				}
+				if !lastSynced.IsZero() {
+					req["lastSynchronizedAt"] = lastSynced.Format(DateFormat)
+				}
+
+				var resp DataHandlerResponse
+				if err := c.call(ctx, handler, http.MethodPost, "/api/v1/synchronizer/data", req, &resp); err != nil {
+					report.addf(step, typeId, "", "", "page %d: %v", next.Page, err)
+					return
+				}
+
+				if lastSynced.IsZero() && resp.SynchronizationType == Delta {
+					report.addf(step, typeId, "", "", "page %d: delta synchronizationType without lastSynchronizedAt", next.Page)
+				}
+				c.checkItems(report, step, typeId, resp.SynchronizationType, resp.Items)
+				for _, item := range resp.Items {
+					id := fmt.Sprint(item["id"])
+					if _, dup := seen[id]; dup {
+						report.addf(step, typeId, id, "id", "returned more than once")
+					}
+					seen[id] = struct{}{}
+				}
+
+				report.Lock()
+				counts[typeId] += len(resp.Items)
+				report.Unlock()
+
+				if !resp.Pagination.HasNext {
+					return
+				}
+				if resp.Pagination.NextPageConfig == next {
+					report.addf(step, typeId, "", "", "page %d: hasNext without advancing nextPageConfig", next.Page)
+					return
+				}
+				next = resp.Pagination.NextPageConfig
+			}
+			report.addf(step, typeId, "", "", "did not finish within %d pages", maxPages)
+		}(typeId)
+	}
+	wg.Wait()
+}
+
+func (c *Conformance) checkAppConfig(report *ConformanceReport) {
+	cfg := report.AppConfig
+	if cfg.Id == "" {
+		report.addf(stepAppConfig, "", "", "id", "missing")
+	}
+	if cfg.Name == "" {
+		report.addf(stepAppConfig, "", "", "name", "missing")
+	}
+	if cfg.Version == "" {
+		report.addf(stepAppConfig, "", "", "version", "missing")
+	}
+	if len(cfg.Authentication) == 0 {
+		report.addf(stepAppConfig, "", "", "authentication", "at least one authentication method is required")
+	}
+}
+
+var fieldTypes = map[FieldType]struct{}{Id: {}, Text: {}, Number: {}, DateType: {}, TextArray: {}}
+
+func (c *Conformance) checkSchemas(report *ConformanceReport, types []string) {
+	known := make(map[string]struct{}, len(report.SyncConfig.Types))
+	for _, t := range report.SyncConfig.Types {
+		known[t.Id] = struct{}{}
+	}
+
+	for _, typeId := range types {
+		schema, ok := report.Schema[typeId]
+		if !ok {
+			report.addf(stepSchema, typeId, "", "", "no schema returned")
+			continue
+		}
+
+		if id, ok := schema["id"]; !ok || id.Type != Id {
+			report.addf(stepSchema, typeId, "", "id", "schema needs an id field of type %q", Id)
+		}
+
+		fieldIds := make([]string, 0, len(schema))
+		for fieldId := range schema {
+			fieldIds = append(fieldIds, fieldId)
+		}
+		sort.Strings(fieldIds)
+
+		for _, fieldId := range fieldIds {
+			field := schema[fieldId]
+			if field.Name == "" {
+				report.addf(stepSchema, typeId, "", fieldId, "missing name")
+			}
+			if _, ok := fieldTypes[field.Type]; !ok && !field.Ignore {
+				report.addf(stepSchema, typeId, "", fieldId, "unknown type %q", field.Type)
+			}
+			if field.Relation == nil {
+				continue
+			}
+			if _, ok := known[field.Relation.TargetType]; !ok {
+				report.addf(stepSchema, typeId, "", fieldId, "relation targets unknown type %q", field.Relation.TargetType)
+			}
+			if field.Relation.TargetFieldID == "" {
+				report.addf(stepSchema, typeId, "", fieldId, "relation has no targetFieldId")
+			}
+		}
+	}
+}
+
+func (c *Conformance) checkItems(report *ConformanceReport, step, typeId string, syncType SyncType, items []map[string]any) {
+	schema := report.Schema[typeId]
+
+	for _, item := range items {
+		id, _ := item["id"].(string)
+		if id == "" {
+			report.addf(step, typeId, "", "id", "item has no string id")
+		}
+
+		if action, ok := item["__syncAction"]; ok {
+			switch action {
+			case string(SET):
+			case string(REMOVE):
+				if syncType != Delta {
+					report.addf(step, typeId, id, "__syncAction", "REMOVE is only valid in delta responses")
+				}
+			default:
+				report.addf(step, typeId, id, "__syncAction", "invalid value %v", action)
+			}
+		}
+
+		for fieldId, value := range item {
+			field, ok := schema[fieldId]
+			if !ok {
+				if fieldId != "__syncAction" {
+					report.addf(step, typeId, id, fieldId, "not declared in schema")
+				}
+				continue
+			}
+			if value == nil {
+				continue
+			}
+
+			switch {
+			case field.Type == DateType:
+				if err := checkDate(field, value); err != nil {
+					report.addf(step, typeId, id, fieldId, "%v", err)
+				}
+			case field.Type == Number:
+				if _, ok := value.(float64); !ok {
+					report.addf(step, typeId, id, fieldId, "expected a number, got %T", value)
+				}
+			case field.Type == TextArray:
+				values, ok := value.([]any)
+				if !ok {
+					report.addf(step, typeId, id, fieldId, "expected an array, got %T", value)
+					continue
+				}
+				for _, v := range values {
+					if _, ok := v.(string); !ok {
+						report.addf(step, typeId, id, fieldId, "expected array of text, got %T element", v)
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+var dateLayouts = []string{time.RFC3339, time.DateOnly}
+
+func checkDate(field Field, value any) error {
+	if field.SubType == Daterange {
+		rng, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("expected a date range object, got %T", value)
+		}
+		for _, key := range []string{"start", "end"} {
+			if err := checkDate(Field{Type: DateType}, rng[key]); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		return nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("expected a date string, got %T", value)
+	}
+	if s == "" {
+		return nil
+	}
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid date %q", s)
+}
@@ -0,0 +1,109 @@
+package fibery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type fakeIntegration struct {
+	schema map[string]Field
+	pages  [][]map[string]any
+}
+
+func (f fakeIntegration) write(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (f fakeIntegration) AppConfigHandler(w http.ResponseWriter, r *http.Request) {
+	f.write(w, AppConfig{Id: "fake", Name: "Fake", Version: "1", Authentication: []Authentication{{Id: "token", Name: "Token"}}})
+}
+
+func (f fakeIntegration) AccountValidateHandler(w http.ResponseWriter, r *http.Request) {
+	f.write(w, map[string]any{"name": "Fake Account"})
+}
+
+func (f fakeIntegration) SyncConfigHandler(w http.ResponseWriter, r *http.Request) {
+	f.write(w, SyncConfig{Types: []SyncConfigTypes{{Id: "thing", Name: "Thing"}}})
+}
+
+func (f fakeIntegration) SyncSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	f.write(w, map[string]map[string]Field{"thing": f.schema})
+}
+
+func (f fakeIntegration) SyncDataHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Pagination NextPageConfig `json:"pagination"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	page := req.Pagination.Page
+	resp := DataHandlerResponse{Items: f.pages[page-1], SynchronizationType: Full}
+	if page < len(f.pages) {
+		resp.Pagination = Pagination{HasNext: true, NextPageConfig: NextPageConfig{Page: page + 1}}
+	}
+	f.write(w, resp)
+}
+
+func TestConformanceValidIntegration(t *testing.T) {
+	t.Parallel()
+	integration := fakeIntegration{
+		schema: map[string]Field{
+			"id":      {Name: "Id", Type: Id},
+			"name":    {Name: "Name", Type: Text, SubType: Title},
+			"created": {Name: "Created", Type: DateType},
+			"parent":  {Name: "Parent", Type: Text, Relation: &Relation{Cardinality: MTO, Name: "Parent", TargetName: "Children", TargetType: "thing", TargetFieldID: "id"}},
+		},
+		pages: [][]map[string]any{
+			{{"id": "1", "name": "one", "created": "2024-01-02T03:04:05Z"}},
+			{{"id": "2", "name": "two", "created": "2024-01-02", "parent": "1"}},
+		},
+	}
+
+	report := (&Conformance{Integration: integration}).Run(context.Background())
+	if err := report.Err(); err != nil {
+		t.Fatalf("expected no violations, got:\n%v", err)
+	}
+	if report.Full["thing"] != 2 || report.Delta["thing"] != 2 {
+		t.Errorf("unexpected item counts full=%v delta=%v", report.Full, report.Delta)
+	}
+}
+
+func TestConformanceReportsViolations(t *testing.T) {
+	t.Parallel()
+	integration := fakeIntegration{
+		schema: map[string]Field{
+			"name":    {Name: "Name", Type: Text},
+			"created": {Name: "Created", Type: DateType},
+			"owner":   {Name: "Owner", Type: Text, Relation: &Relation{TargetType: "user", TargetFieldID: "id"}},
+		},
+		pages: [][]map[string]any{{
+			{"name": "missing id"},
+			{"id": "2", "created": "02/01/2024", "__syncAction": "REMOVE"},
+			{"id": "3", "color": "blue", "__syncAction": "UPSERT"},
+		}},
+	}
+
+	report := (&Conformance{Integration: integration}).Run(context.Background())
+	err := report.Err()
+	if err == nil {
+		t.Fatal("expected violations")
+	}
+
+	for _, want := range []string{
+		"schema thing field id: schema needs an id field",
+		`relation targets unknown type "user"`,
+		"item has no string id",
+		`invalid date "02/01/2024"`,
+		"REMOVE is only valid in delta responses",
+		"field color: not declared in schema",
+		"invalid value UPSERT",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected violation %q in:\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package qbosim
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+//go:embed fixtures/*.json
+var embedded embed.FS
+
+// Fixture seeds a single simulated realm. Entities are raw QuickBooks JSON keyed by entity name
+// (e.g. "Vendor", "Bill", "Attachable") so fixtures can be captured straight from the sandbox API.
+type Fixture struct {
+	RealmId     string                      `json:"realmId"`
+	CompanyInfo map[string]any              `json:"companyInfo"`
+	Preferences map[string]any              `json:"preferences"`
+	Entities    map[string][]map[string]any `json:"entities"`
+	Deleted     map[string][]map[string]any `json:"deleted"`
+	Files       map[string]File             `json:"files"`
+	Reports     map[string]map[string]any   `json:"reports"`
+}
+
+// File is the content served for an attachable download, keyed by attachable id.
+type File struct {
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Content     string `json:"content"`
+}
+
+func LoadFixture(fsys fs.FS, name string) (Fixture, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return Fixture{}, fmt.Errorf("unable to read fixture %s: %w", name, err)
+	}
+	return decodeFixture(data, name)
+}
+
+func LoadFixtureFile(path string) (Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Fixture{}, fmt.Errorf("unable to read fixture %s: %w", path, err)
+	}
+	return decodeFixture(data, path)
+}
+
+func decodeFixture(data []byte, name string) (Fixture, error) {
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return Fixture{}, fmt.Errorf("unable to decode fixture %s: %w", name, err)
+	}
+
+	if fixture.RealmId == "" {
+		return Fixture{}, fmt.Errorf("fixture %s is missing realmId", name)
+	}
+
+	return fixture, nil
+}
+
+// SandboxFixture is a small company with enough vendors, bills and attachables to exercise paging.
+func SandboxFixture() Fixture {
+	fixture, err := LoadFixture(embedded, "fixtures/sandbox.json")
+	if err != nil {
+		panic(err)
+	}
+	return fixture
+}
@@ -0,0 +1,612 @@
+// Package qbosim is an in-process QuickBooks Online API simulator for offline end-to-end tests.
+// It serves OpenID discovery, OAuth token exchange and refresh, query, batch, CDC and attachable
+// downloads for realms seeded from fixture JSON, and signs webhook payloads like Intuit does.
+package qbosim
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+const (
+	ClientId     = "qbosim-client"
+	ClientSecret = "qbosim-secret"
+	WebhookToken = "qbosim-verifier"
+
+	tokenLifetime   = time.Hour
+	refreshLifetime = 100 * 24 * time.Hour
+	cdcLookbackDays = 30
+)
+
+type realm struct {
+	companyInfo map[string]any
+	preferences map[string]any
+	entities    map[string][]map[string]any
+	deleted     map[string][]map[string]any
+	files       map[string]File
+	reports     map[string]map[string]any
+}
+
+type RecordedRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+}
+
+type Server struct {
+	*httptest.Server
+	mu            sync.Mutex
+	realms        map[string]*realm
+	accessTokens  map[string]string
+	refreshTokens map[string]string
+	codes         map[string]string
+	rateLimited   int
+	requests      []RecordedRequest
+}
+
+func New(fixtures ...Fixture) *Server {
+	s := &Server{
+		realms:        make(map[string]*realm),
+		accessTokens:  make(map[string]string),
+		refreshTokens: make(map[string]string),
+		codes:         make(map[string]string),
+	}
+
+	for _, fixture := range fixtures {
+		s.Seed(fixture)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/{config}", s.discoveryHandler)
+	mux.HandleFunc("GET /oauth2/authorize", s.authorizeHandler)
+	mux.HandleFunc("POST /oauth2/tokens/bearer", s.tokenHandler)
+	mux.HandleFunc("GET /v3/company/{realmId}/query", s.authorized(s.queryHandler))
+	mux.HandleFunc("POST /v3/company/{realmId}/query", s.authorized(s.queryHandler))
+	mux.HandleFunc("POST /v3/company/{realmId}/batch", s.authorized(s.batchHandler))
+	mux.HandleFunc("GET /v3/company/{realmId}/cdc", s.authorized(s.cdcHandler))
+	mux.HandleFunc("GET /v3/company/{realmId}/companyinfo/{id}", s.authorized(s.companyInfoHandler))
+	mux.HandleFunc("GET /v3/company/{realmId}/preferences", s.authorized(s.preferencesHandler))
+	mux.HandleFunc("GET /v3/company/{realmId}/reports/{name}", s.authorized(s.reportHandler))
+	mux.HandleFunc("GET /v3/company/{realmId}/download/{id}", s.authorized(s.downloadHandler))
+	mux.HandleFunc("GET /files/{realmId}/{id}", s.fileHandler)
+
+	s.Server = httptest.NewServer(s.record(mux))
+	return s
+}
+
+// Seed replaces the realm's data with the fixture, normalizing MetaData so CDC can filter on it.
+func (s *Server) Seed(fixture Fixture) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := &realm{
+		companyInfo: fixture.CompanyInfo,
+		preferences: fixture.Preferences,
+		entities:    make(map[string][]map[string]any),
+		deleted:     make(map[string][]map[string]any),
+		files:       fixture.Files,
+		reports:     fixture.Reports,
+	}
+	if r.files == nil {
+		r.files = make(map[string]File)
+	}
+
+	// seeded entities predate the simulator so delta syncs only see changes made through Upsert and Delete
+	seeded := time.Now().Add(-24 * time.Hour).Format(time.RFC3339)
+	for name, entities := range fixture.Entities {
+		for _, entity := range entities {
+			if _, ok := entity["MetaData"]; !ok {
+				entity["MetaData"] = map[string]any{"CreateTime": seeded, "LastUpdatedTime": seeded}
+			}
+			if _, ok := entity["SyncToken"]; !ok {
+				entity["SyncToken"] = "0"
+			}
+		}
+		r.entities[name] = entities
+	}
+	for name, entities := range fixture.Deleted {
+		r.deleted[name] = entities
+	}
+
+	s.realms[fixture.RealmId] = r
+}
+
+func (s *Server) DiscoveryURL() string {
+	return s.URL + "/.well-known/openid_configuration"
+}
+
+// IssueToken mints a valid bearer token for the realm, as if the OAuth flow had completed.
+func (s *Server) IssueToken(realmId string) quickbooks.BearerToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issueToken(realmId)
+}
+
+func (s *Server) issueToken(realmId string) quickbooks.BearerToken {
+	token := quickbooks.BearerToken{
+		AccessToken:            randomToken("at"),
+		RefreshToken:           randomToken("rt"),
+		TokenType:              "bearer",
+		ExpiresIn:              int64(tokenLifetime.Seconds()),
+		XRefreshTokenExpiresIn: int64(refreshLifetime.Seconds()),
+	}
+	s.accessTokens[token.AccessToken] = realmId
+	s.refreshTokens[token.RefreshToken] = realmId
+	return token
+}
+
+// AuthorizationCode returns a one-time code that the token endpoint exchanges for the realm's tokens.
+func (s *Server) AuthorizationCode(realmId string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code := randomToken("code")
+	s.codes[code] = realmId
+	return code
+}
+
+// RateLimitNext makes the next n API calls return 429 Too Many Requests.
+func (s *Server) RateLimitNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimited = n
+}
+
+func (s *Server) Requests() []RecordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]RecordedRequest(nil), s.requests...)
+}
+
+// Upsert creates or replaces an entity, bumping SyncToken and LastUpdatedTime the way QuickBooks does.
+func (s *Server) Upsert(realmId, entityType string, entity map[string]any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.realms[realmId]
+	if !ok {
+		return fmt.Errorf("unknown realm %s", realmId)
+	}
+
+	id := fmt.Sprint(entity["Id"])
+	now := time.Now().Format(time.RFC3339)
+	entities := r.entities[entityType]
+
+	for idx, existing := range entities {
+		if fmt.Sprint(existing["Id"]) != id {
+			continue
+		}
+		syncToken, _ := strconv.Atoi(fmt.Sprint(existing["SyncToken"]))
+		created := now
+		if meta, ok := existing["MetaData"].(map[string]any); ok {
+			created = fmt.Sprint(meta["CreateTime"])
+		}
+		entity["SyncToken"] = strconv.Itoa(syncToken + 1)
+		entity["MetaData"] = map[string]any{"CreateTime": created, "LastUpdatedTime": now}
+		entities[idx] = entity
+		return nil
+	}
+
+	entity["SyncToken"] = "0"
+	entity["MetaData"] = map[string]any{"CreateTime": now, "LastUpdatedTime": now}
+	r.entities[entityType] = append(entities, entity)
+	return nil
+}
+
+func (s *Server) Delete(realmId, entityType, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.realms[realmId]
+	if !ok {
+		return fmt.Errorf("unknown realm %s", realmId)
+	}
+
+	entities := r.entities[entityType]
+	for idx, existing := range entities {
+		if fmt.Sprint(existing["Id"]) != id {
+			continue
+		}
+		r.entities[entityType] = append(entities[:idx:idx], entities[idx+1:]...)
+		r.deleted[entityType] = append(r.deleted[entityType], map[string]any{
+			"Id":       id,
+			"status":   "Deleted",
+			"domain":   "QBO",
+			"MetaData": map[string]any{"LastUpdatedTime": time.Now().Format(time.RFC3339)},
+		})
+		return nil
+	}
+	return fmt.Errorf("%s %s not found in realm %s", entityType, id, realmId)
+}
+
+func (s *Server) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, RecordedRequest{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query()})
+		s.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) authorized(next func(http.ResponseWriter, *http.Request, *realm)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		realmId := r.PathValue("realmId")
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		s.mu.Lock()
+		tokenRealm, validToken := s.accessTokens[token]
+		rlm, realmExists := s.realms[realmId]
+		limited := s.rateLimited > 0
+		if limited {
+			s.rateLimited--
+		}
+		s.mu.Unlock()
+
+		switch {
+		case limited:
+			writeFault(w, http.StatusTooManyRequests, "ThrottleExceeded", "003001", "message=ThrottleExceeded; errorCode=003001; statusCode=429")
+		case !validToken || tokenRealm != realmId:
+			writeFault(w, http.StatusUnauthorized, "AuthenticationFailed", "3200", "message=AuthenticationFailed; errorCode=003200; statusCode=401")
+		case !realmExists:
+			writeFault(w, http.StatusForbidden, "ApplicationAuthorizationFailed", "003100", fmt.Sprintf("realm %s not found", realmId))
+		default:
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			next(w, r, rlm)
+		}
+	}
+}
+
+func (s *Server) discoveryHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 s.URL,
+		"authorization_endpoint": s.URL + "/oauth2/authorize",
+		"token_endpoint":         s.URL + "/oauth2/tokens/bearer",
+		"userinfo_endpoint":      s.URL + "/oauth2/userinfo",
+		"revocation_endpoint":    s.URL + "/oauth2/tokens/revoke",
+		"jwks_uri":               s.URL + "/oauth2/keys",
+	})
+}
+
+// authorizeHandler stands in for the Intuit consent screen: it redirects straight back with a code
+// for the realm given in the realmId query parameter, or the only seeded realm.
+func (s *Server) authorizeHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	realmId := q.Get("realmId")
+
+	s.mu.Lock()
+	if realmId == "" && len(s.realms) == 1 {
+		for id := range s.realms {
+			realmId = id
+		}
+	}
+	_, ok := s.realms[realmId]
+	s.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "unknown realm", http.StatusBadRequest)
+		return
+	}
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || redirect.String() == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	values := redirect.Query()
+	values.Set("code", s.AuthorizationCode(realmId))
+	values.Set("state", q.Get("state"))
+	values.Set("realmId", realmId)
+	redirect.RawQuery = values.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) tokenHandler(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != ClientId || secret != ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		realmId string
+		found   bool
+	)
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code := r.PostForm.Get("code")
+		realmId, found = s.codes[code]
+		delete(s.codes, code)
+	case "refresh_token":
+		refresh := r.PostForm.Get("refresh_token")
+		realmId, found = s.refreshTokens[refresh]
+		delete(s.refreshTokens, refresh)
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	if !found {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.issueToken(realmId))
+}
+
+func (s *Server) queryHandler(w http.ResponseWriter, r *http.Request, rlm *realm) {
+	raw := r.URL.Query().Get("query")
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err == nil && raw == "" {
+			raw = r.PostForm.Get("query")
+		}
+	}
+
+	resp, err := rlm.query(raw)
+	if err != nil {
+		writeFault(w, http.StatusBadRequest, "QueryParserError", "4000", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"QueryResponse": resp,
+		"time":          time.Now().Format(time.RFC3339),
+	})
+}
+
+func (s *Server) batchHandler(w http.ResponseWriter, r *http.Request, rlm *realm) {
+	var req struct {
+		BatchItemRequest []struct {
+			BID   string `json:"bId"`
+			Query string `json:"Query"`
+		}
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeFault(w, http.StatusBadRequest, "ValidationFault", "2020", fmt.Sprintf("unable to decode batch request: %s", err))
+		return
+	}
+
+	if len(req.BatchItemRequest) > 30 {
+		writeFault(w, http.StatusBadRequest, "ValidationFault", "2020", fmt.Sprintf("batch of %d items exceeds the limit of 30", len(req.BatchItemRequest)))
+		return
+	}
+
+	items := make([]map[string]any, 0, len(req.BatchItemRequest))
+	for _, item := range req.BatchItemRequest {
+		if item.Query == "" {
+			items = append(items, map[string]any{"bId": item.BID, "Fault": fault("ValidationFault", "2020", "only query operations are simulated")})
+			continue
+		}
+
+		resp, err := rlm.query(item.Query)
+		if err != nil {
+			items = append(items, map[string]any{"bId": item.BID, "Fault": fault("ValidationFault", "4000", err.Error())})
+			continue
+		}
+		items = append(items, map[string]any{"bId": item.BID, "QueryResponse": resp})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"BatchItemResponse": items,
+		"time":              time.Now().Format(time.RFC3339),
+	})
+}
+
+func (s *Server) cdcHandler(w http.ResponseWriter, r *http.Request, rlm *realm) {
+	q := r.URL.Query()
+	changedSince, err := time.Parse(time.RFC3339, q.Get("changedSince"))
+	if err != nil {
+		writeFault(w, http.StatusBadRequest, "ValidationFault", "2020", fmt.Sprintf("invalid changedSince: %s", err))
+		return
+	}
+	if time.Since(changedSince) > cdcLookbackDays*24*time.Hour {
+		writeFault(w, http.StatusBadRequest, "ValidationFault", "2020", fmt.Sprintf("changedSince cannot be more than %d days ago", cdcLookbackDays))
+		return
+	}
+
+	responses := make([]map[string]any, 0)
+	for _, requested := range strings.Split(q.Get("entities"), ",") {
+		name, ok := rlm.entityName(strings.TrimSpace(requested))
+		if !ok {
+			continue
+		}
+
+		changed := make([]map[string]any, 0)
+		for _, entity := range rlm.entities[name] {
+			if updatedSince(entity, changedSince) {
+				changed = append(changed, entity)
+			}
+		}
+		for _, entity := range rlm.deleted[name] {
+			if updatedSince(entity, changedSince) {
+				changed = append(changed, entity)
+			}
+		}
+
+		if len(changed) == 0 {
+			responses = append(responses, map[string]any{})
+			continue
+		}
+
+		responses = append(responses, map[string]any{
+			name:            changed,
+			"startPosition": 1,
+			"maxResults":    len(changed),
+			"totalCount":    len(changed),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"CDCResponse": []map[string]any{{"QueryResponse": responses}},
+		"time":        time.Now().Format(time.RFC3339),
+	})
+}
+
+func (s *Server) companyInfoHandler(w http.ResponseWriter, r *http.Request, rlm *realm) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"CompanyInfo": rlm.companyInfo,
+		"time":        time.Now().Format(time.RFC3339),
+	})
+}
+
+func (s *Server) preferencesHandler(w http.ResponseWriter, r *http.Request, rlm *realm) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"Preferences": rlm.preferences,
+		"time":        time.Now().Format(time.RFC3339),
+	})
+}
+
+// reportHandler serves the fixture report for any period, echoing the requested date macro.
+func (s *Server) reportHandler(w http.ResponseWriter, r *http.Request, rlm *realm) {
+	report, ok := rlm.reports[r.PathValue("name")]
+	if !ok {
+		writeFault(w, http.StatusBadRequest, "ValidationFault", "2030", fmt.Sprintf("unsupported report %s", r.PathValue("name")))
+		return
+	}
+
+	header, _ := report["Header"].(map[string]any)
+	out := make(map[string]any, len(report))
+	for key, value := range report {
+		out[key] = value
+	}
+	withMacro := make(map[string]any, len(header)+1)
+	for key, value := range header {
+		withMacro[key] = value
+	}
+	withMacro["DateMacro"] = strings.ToLower(r.URL.Query().Get("date_macro"))
+	out["Header"] = withMacro
+
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) downloadHandler(w http.ResponseWriter, r *http.Request, rlm *realm) {
+	id := r.PathValue("id")
+	if _, ok := rlm.files[id]; !ok {
+		writeFault(w, http.StatusBadRequest, "ObjectNotFound", "610", fmt.Sprintf("attachable %s not found", id))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s/files/%s/%s", s.URL, r.PathValue("realmId"), id)
+}
+
+func (s *Server) fileHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	rlm, ok := s.realms[r.PathValue("realmId")]
+	var file File
+	if ok {
+		file, ok = rlm.files[r.PathValue("id")]
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if file.ContentType != "" {
+		w.Header().Set("Content-Type", file.ContentType)
+	}
+	if file.FileName != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.FileName))
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(file.Content)))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(file.Content))
+}
+
+func (r *realm) entityName(name string) (string, bool) {
+	if _, ok := r.entities[name]; ok {
+		return name, true
+	}
+
+	names := make([]string, 0, len(r.entities)+len(r.deleted))
+	for known := range r.entities {
+		names = append(names, known)
+	}
+	for known := range r.deleted {
+		names = append(names, known)
+	}
+	sort.Strings(names)
+
+	for _, known := range names {
+		if strings.EqualFold(known, name) {
+			return known, true
+		}
+	}
+	return name, false
+}
+
+func (r *realm) query(raw string) (map[string]any, error) {
+	q, err := parseQuery(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := r.entityName(q.entity)
+	q.entity = name
+	return q.run(r.entities[name]), nil
+}
+
+func updatedSince(entity map[string]any, since time.Time) bool {
+	for _, value := range lookup(entity, []string{"MetaData", "LastUpdatedTime"}) {
+		updated, err := time.Parse(time.RFC3339, fmt.Sprint(value))
+		if err == nil && !updated.Before(since) {
+			return true
+		}
+	}
+	return false
+}
+
+func fault(faultType, code, message string) map[string]any {
+	return map[string]any{
+		"Error": []map[string]string{{
+			"Message": message,
+			"Detail":  message,
+			"code":    code,
+		}},
+		"type": faultType,
+	}
+}
+
+func writeFault(w http.ResponseWriter, status int, faultType, code, message string) {
+	writeJSON(w, status, map[string]any{
+		"Fault": fault(faultType, code, message),
+		"time":  time.Now().Format(time.RFC3339),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func randomToken(prefix string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return prefix + "-" + hex.EncodeToString(b)
+}
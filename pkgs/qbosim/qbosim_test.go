@@ -0,0 +1,148 @@
+package qbosim
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/tommyhedley/quickbooks-go"
+)
+
+func TestParseQuery(t *testing.T) {
+	t.Parallel()
+	q, err := parseQuery("Select Id, AttachableRef From Attachable Where AttachableRef.EntityRef.Type = 'Bill' And AttachableRef.EntityRef.Value in ('26','27') STARTPOSITION 11 MAXRESULTS 10")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if q.entity != "Attachable" || len(q.fields) != 2 || q.startPosition != 11 || q.maxResults != 10 {
+		t.Errorf("unexpected query: %+v", q)
+	}
+	if len(q.conditions) != 2 || q.conditions[1].op != "in" || len(q.conditions[1].values) != 2 {
+		t.Errorf("unexpected conditions: %+v", q.conditions)
+	}
+
+	if _, err := parseQuery("Delete From Bill"); err == nil {
+		t.Error("expected error for unsupported statement")
+	}
+}
+
+func TestQueryPaging(t *testing.T) {
+	t.Parallel()
+	rlm := &realm{entities: SandboxFixture().Entities}
+
+	first, err := rlm.query("Select * From vendor ORDERBY Id STARTPOSITION 1 MAXRESULTS 2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if vendors, _ := first["Vendor"].([]map[string]any); len(vendors) != 2 || vendors[0]["Id"] != "1" {
+		t.Errorf("unexpected first page: %v", first)
+	}
+
+	last, _ := rlm.query("Select * From Vendor ORDERBY Id STARTPOSITION 5 MAXRESULTS 2")
+	if vendors, _ := last["Vendor"].([]map[string]any); len(vendors) != 1 {
+		t.Errorf("expected 1 vendor on last page, got %v", last)
+	}
+
+	empty, _ := rlm.query("Select * From Vendor STARTPOSITION 7 MAXRESULTS 2")
+	if len(empty) != 0 {
+		t.Errorf("expected empty response past the end, got %v", empty)
+	}
+
+	attachables, _ := rlm.query("Select Id, AttachableRef From Attachable Where AttachableRef.EntityRef.Type = 'Bill'")
+	if found, _ := attachables["Attachable"].([]map[string]any); len(found) != 1 || found[0]["FileName"] != nil {
+		t.Errorf("expected one projected bill attachable, got %v", attachables)
+	}
+}
+
+func newTestClient(t *testing.T, sim *Server) *quickbooks.Client {
+	t.Helper()
+	discovery, err := quickbooks.CallDiscoveryAPI(sim.DiscoveryURL())
+	if err != nil {
+		t.Fatalf("discovery failed: %v", err)
+	}
+	client, err := quickbooks.NewClient(quickbooks.ClientRequest{
+		Client:       http.DefaultClient,
+		DiscoveryAPI: discovery,
+		ClientId:     ClientId,
+		ClientSecret: ClientSecret,
+		Endpoint:     sim.URL,
+	})
+	if err != nil {
+		t.Fatalf("unable to build client: %v", err)
+	}
+	return client
+}
+
+func TestServerWithClient(t *testing.T) {
+	t.Parallel()
+	fixture := SandboxFixture()
+	sim := New(fixture)
+	defer sim.Close()
+
+	client := newTestClient(t, sim)
+	token, err := client.RetrieveBearerToken(sim.AuthorizationCode(fixture.RealmId), "http://localhost/callback")
+	if err != nil {
+		t.Fatalf("token exchange failed: %v", err)
+	}
+
+	params := quickbooks.RequestParameters{Ctx: context.Background(), RealmId: fixture.RealmId, Token: token}
+
+	info, err := client.FindCompanyInfo(params)
+	if err != nil || info.CompanyName != "Sandbox Landscaping Co" {
+		t.Fatalf("unexpected company info %+v: %v", info, err)
+	}
+
+	batch, err := client.BatchRequest(params, []quickbooks.BatchItemRequest{
+		{BID: "Vendor:1", Query: "Select * From Vendor ORDERBY Id STARTPOSITION 1 MAXRESULTS 10"},
+		{BID: "Bill:1", Query: "Select * From Bill ORDERBY Id STARTPOSITION 1 MAXRESULTS 10"},
+	})
+	if err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	if len(batch) != 2 || len(batch[0].QueryResponse.Vendor) != 5 || len(batch[1].QueryResponse.Bill) != 3 {
+		t.Errorf("unexpected batch response: %+v", batch)
+	}
+
+	since := time.Now().Add(-time.Minute)
+	if err := sim.Upsert(fixture.RealmId, "Vendor", map[string]any{"Id": "2", "DisplayName": "Books by Bessie Inc"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Delete(fixture.RealmId, "Vendor", "5"); err != nil {
+		t.Fatal(err)
+	}
+	cdc, err := client.ChangeDataCapture(params, []string{"Vendor"}, since)
+	if err != nil {
+		t.Fatalf("cdc failed: %v", err)
+	}
+	vendors := quickbooks.CDCQueryExtractor(&cdc, func(r quickbooks.CDCQueryResponse) []quickbooks.Vendor { return r.Vendor })
+	if len(vendors) != 2 {
+		t.Errorf("expected only the update and the deletion in cdc, got %d", len(vendors))
+	}
+
+	refreshed, err := client.RefreshToken(token.RefreshToken)
+	if err != nil || refreshed.AccessToken == token.AccessToken {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if _, err := client.RefreshToken(token.RefreshToken); err == nil {
+		t.Error("expected reused refresh token to be rejected")
+	}
+
+	sim.RateLimitNext(1)
+	params.Token = refreshed
+	_, err = client.FindCompanyInfo(params)
+	var rateLimitErr *quickbooks.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Errorf("expected rate limit error, got %v", err)
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	t.Parallel()
+	payload := WebhookPayload("1", WebhookEntity{Name: "Vendor", Id: "2", Operation: "Update", LastUpdated: time.Now()})
+	if SignWebhook(payload, WebhookToken) == SignWebhook(payload, "other") {
+		t.Error("expected signatures to depend on the verifier token")
+	}
+}
@@ -0,0 +1,295 @@
+package qbosim
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// query is the subset of the QuickBooks query language the app issues:
+// Select <fields|*|count(*)> From <Entity> [Where <cond> [And <cond>]...] [OrderBy <field> [Asc|Desc]] [StartPosition n] [MaxResults n]
+type query struct {
+	fields        []string
+	count         bool
+	entity        string
+	conditions    []condition
+	orderBy       string
+	descending    bool
+	startPosition int
+	maxResults    int
+}
+
+type condition struct {
+	field  string
+	op     string
+	values []string
+}
+
+var (
+	queryPattern = regexp.MustCompile(`(?is)^\s*select\s+(.+?)\s+from\s+(\w+)(?:\s+where\s+(.+?))?(?:\s+orderby\s+([\w.]+)(?:\s+(asc|desc))?)?(?:\s+startposition\s+(\d+))?(?:\s+maxresults\s+(\d+))?\s*$`)
+	condPattern  = regexp.MustCompile(`(?is)^\s*([\w.]+)\s*(>=|<=|=|<|>|\bin\b|\blike\b)\s*(.+?)\s*$`)
+	andPattern   = regexp.MustCompile(`(?i)\s+and\s+`)
+)
+
+const defaultMaxResults = 100
+
+func parseQuery(raw string) (query, error) {
+	m := queryPattern.FindStringSubmatch(raw)
+	if m == nil {
+		return query{}, fmt.Errorf("unsupported query: %s", raw)
+	}
+
+	q := query{
+		entity:        m[2],
+		orderBy:       m[4],
+		descending:    strings.EqualFold(m[5], "desc"),
+		startPosition: 1,
+		maxResults:    defaultMaxResults,
+	}
+
+	selection := strings.TrimSpace(m[1])
+	switch {
+	case strings.EqualFold(selection, "count(*)"):
+		q.count = true
+	case selection != "*":
+		for _, field := range strings.Split(selection, ",") {
+			q.fields = append(q.fields, strings.TrimSpace(field))
+		}
+	}
+
+	if m[3] != "" {
+		for _, raw := range splitConditions(m[3]) {
+			c, err := parseCondition(raw)
+			if err != nil {
+				return query{}, err
+			}
+			q.conditions = append(q.conditions, c)
+		}
+	}
+
+	if m[6] != "" {
+		q.startPosition, _ = strconv.Atoi(m[6])
+	}
+	if m[7] != "" {
+		q.maxResults, _ = strconv.Atoi(m[7])
+	}
+	if q.maxResults > 1000 {
+		return query{}, fmt.Errorf("maxresults of %d exceeds the limit of 1000", q.maxResults)
+	}
+
+	return q, nil
+}
+
+// splitConditions splits on And outside of quoted values.
+func splitConditions(where string) []string {
+	var (
+		parts    []string
+		inQuote  bool
+		start    int
+		quoteIdx []int
+	)
+	for idx, r := range where {
+		if r == '\'' {
+			inQuote = !inQuote
+		}
+		if inQuote {
+			quoteIdx = append(quoteIdx, idx)
+		}
+	}
+
+	for _, loc := range andPattern.FindAllStringIndex(where, -1) {
+		quoted := false
+		for _, idx := range quoteIdx {
+			if idx >= loc[0] && idx < loc[1] {
+				quoted = true
+				break
+			}
+		}
+		if quoted {
+			continue
+		}
+		parts = append(parts, where[start:loc[0]])
+		start = loc[1]
+	}
+	return append(parts, where[start:])
+}
+
+func parseCondition(raw string) (condition, error) {
+	m := condPattern.FindStringSubmatch(raw)
+	if m == nil {
+		return condition{}, fmt.Errorf("unsupported condition: %s", raw)
+	}
+
+	c := condition{field: m[1], op: strings.ToLower(m[2])}
+	value := strings.TrimSpace(m[3])
+
+	if c.op == "in" {
+		value = strings.TrimSuffix(strings.TrimPrefix(value, "("), ")")
+		for _, v := range strings.Split(value, ",") {
+			c.values = append(c.values, unquote(v))
+		}
+	} else {
+		c.values = []string{unquote(value)}
+	}
+
+	return c, nil
+}
+
+func unquote(v string) string {
+	return strings.Trim(strings.TrimSpace(v), "'")
+}
+
+// lookup resolves a dotted path case-insensitively, fanning out across arrays.
+func lookup(value any, path []string) []any {
+	if len(path) == 0 {
+		return []any{value}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if strings.EqualFold(key, path[0]) {
+				return lookup(child, path[1:])
+			}
+		}
+	case []any:
+		var out []any
+		for _, child := range v {
+			out = append(out, lookup(child, path)...)
+		}
+		return out
+	}
+	return nil
+}
+
+func compareValues(a, b string) int {
+	an, aErr := strconv.ParseFloat(a, 64)
+	bn, bErr := strconv.ParseFloat(b, 64)
+	if aErr == nil && bErr == nil {
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+func (c condition) match(entity map[string]any) bool {
+	for _, found := range lookup(entity, strings.Split(c.field, ".")) {
+		actual := fmt.Sprint(found)
+		for _, want := range c.values {
+			cmp := compareValues(actual, want)
+			switch c.op {
+			case "=", "in":
+				if cmp == 0 {
+					return true
+				}
+			case "like":
+				pattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(want), "%", ".*") + "$"
+				if ok, _ := regexp.MatchString("(?i)"+pattern, actual); ok {
+					return true
+				}
+			case ">":
+				if cmp > 0 {
+					return true
+				}
+			case ">=":
+				if cmp >= 0 {
+					return true
+				}
+			case "<":
+				if cmp < 0 {
+					return true
+				}
+			case "<=":
+				if cmp <= 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (q query) run(entities []map[string]any) map[string]any {
+	matched := make([]map[string]any, 0, len(entities))
+	for _, entity := range entities {
+		ok := true
+		for _, c := range q.conditions {
+			if !c.match(entity) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, entity)
+		}
+	}
+
+	if q.count {
+		return map[string]any{"totalCount": len(matched)}
+	}
+
+	orderBy := q.orderBy
+	if orderBy == "" {
+		orderBy = "Id"
+	}
+	sort.SliceStable(matched, func(a, b int) bool {
+		av := fmt.Sprint(lookup(matched[a], strings.Split(orderBy, "."))...)
+		bv := fmt.Sprint(lookup(matched[b], strings.Split(orderBy, "."))...)
+		if q.descending {
+			return compareValues(av, bv) > 0
+		}
+		return compareValues(av, bv) < 0
+	})
+
+	start := q.startPosition - 1
+	if start < 0 {
+		start = 0
+	}
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + q.maxResults
+	if end > len(matched) {
+		end = len(matched)
+	}
+	page := matched[start:end]
+
+	// QuickBooks omits the entity key and paging fields entirely when nothing matches.
+	if len(page) == 0 {
+		return map[string]any{}
+	}
+
+	results := make([]map[string]any, 0, len(page))
+	for _, entity := range page {
+		results = append(results, q.project(entity))
+	}
+
+	return map[string]any{
+		q.entity:        results,
+		"startPosition": q.startPosition,
+		"maxResults":    len(results),
+	}
+}
+
+func (q query) project(entity map[string]any) map[string]any {
+	if len(q.fields) == 0 {
+		return entity
+	}
+
+	projected := make(map[string]any, len(q.fields))
+	for _, field := range q.fields {
+		for key, value := range entity {
+			if strings.EqualFold(key, field) {
+				projected[key] = value
+			}
+		}
+	}
+	return projected
+}
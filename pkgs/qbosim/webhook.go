@@ -0,0 +1,43 @@
+package qbosim
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+type WebhookEntity struct {
+	Name        string    `json:"name"`
+	Id          string    `json:"id"`
+	Operation   string    `json:"operation"`
+	LastUpdated time.Time `json:"lastUpdated"`
+}
+
+type webhookPayload struct {
+	EventNotifications []webhookNotification `json:"eventNotifications"`
+}
+
+type webhookNotification struct {
+	RealmId         string `json:"realmId"`
+	DataChangeEvent struct {
+		Entities []WebhookEntity `json:"entities"`
+	} `json:"dataChangeEvent"`
+}
+
+// WebhookPayload builds the body Intuit posts for data change events in a realm.
+func WebhookPayload(realmId string, entities ...WebhookEntity) []byte {
+	notification := webhookNotification{RealmId: realmId}
+	notification.DataChangeEvent.Entities = entities
+
+	payload, _ := json.Marshal(webhookPayload{EventNotifications: []webhookNotification{notification}})
+	return payload
+}
+
+// SignWebhook returns the intuit-signature header value: base64(HMAC-SHA256(payload, verifier token)).
+func SignWebhook(payload []byte, verifierToken string) string {
+	mac := hmac.New(sha256.New, []byte(verifierToken))
+	mac.Write(payload)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}